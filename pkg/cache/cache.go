@@ -15,6 +15,8 @@ var (
 
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
+	// GetDel atomically returns and deletes a key, so only one caller gets it.
+	GetDel(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
 	Exists(ctx context.Context, key string) (bool, error)
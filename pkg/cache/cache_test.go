@@ -80,3 +80,21 @@ func TestMemoryCacheBasicOps(t *testing.T) {
 		t.Fatalf("expected ErrKeyNotFound after delete, got %v", err)
 	}
 }
+
+func TestMemoryCacheGetDel(t *testing.T) {
+	c := NewMemoryCache()
+	defer c.Close()
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "key1", "value1", time.Minute)
+
+	val, err := c.GetDel(ctx, "key1")
+	if err != nil || val != "value1" {
+		t.Fatalf("expected value1, got %q (%v)", val, err)
+	}
+
+	_, err = c.GetDel(ctx, "key1")
+	if err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound on second GetDel, got %v", err)
+	}
+}
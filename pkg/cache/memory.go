@@ -59,6 +59,26 @@ func (c *memoryCache) Get(_ context.Context, key string) (string, error) {
 	return str, nil
 }
 
+func (c *memoryCache) GetDel(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, exists := c.items[key]
+	if !exists {
+		return "", ErrKeyNotFound
+	}
+	if !item.expiration.IsZero() && item.expiration.Before(time.Now()) {
+		delete(c.items, key)
+		return "", ErrKeyNotFound
+	}
+
+	str, ok := item.value.(string)
+	if !ok {
+		return "", ErrInvalidType
+	}
+	delete(c.items, key)
+	return str, nil
+}
+
 func (c *memoryCache) Set(_ context.Context, key string, value any, ttl time.Duration) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
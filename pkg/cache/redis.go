@@ -138,6 +138,25 @@ func (r *redisCache) Get(ctx context.Context, key string) (string, error) {
 	return val, nil
 }
 
+func (r *redisCache) GetDel(ctx context.Context, key string) (string, error) {
+	if ctx == nil {
+		return "", ErrInvalidContext
+	}
+
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+
+	val, err := r.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrKeyNotFound
+		}
+		return "", fmt.Errorf("redis getdel error: %w", err)
+	}
+	return val, nil
+}
+
 func (r *redisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
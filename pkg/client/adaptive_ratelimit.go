@@ -0,0 +1,130 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// AdaptiveRateLimitConfig tunes an AIMD limiter: every successful response
+// adds IncreaseStep requests/second up to MaxRate, while a 429 or a low
+// X-RateLimit-Remaining multiplies the rate by DecreaseFactor down to MinRate.
+type AdaptiveRateLimitConfig struct {
+	InitialRate    rate.Limit
+	MinRate        rate.Limit
+	MaxRate        rate.Limit
+	Burst          int
+	IncreaseStep   rate.Limit
+	DecreaseFactor float64
+	// LowRemaining triggers a decrease when X-RateLimit-Remaining drops to
+	// this value or below.
+	LowRemaining int
+	// KeyFunc groups requests sharing a limiter. Defaults to the URL host.
+	KeyFunc func(r *http.Request) string
+}
+
+func (c *AdaptiveRateLimitConfig) applyDefaults() {
+	if c.InitialRate <= 0 {
+		c.InitialRate = 10
+	}
+	if c.MinRate <= 0 {
+		c.MinRate = 0.5
+	}
+	if c.MaxRate < c.InitialRate {
+		c.MaxRate = c.InitialRate * 10
+	}
+	if c.Burst < 1 {
+		c.Burst = 1
+	}
+	if c.IncreaseStep <= 0 {
+		c.IncreaseStep = 1
+	}
+	if c.DecreaseFactor <= 0 || c.DecreaseFactor >= 1 {
+		c.DecreaseFactor = 0.5
+	}
+	if c.KeyFunc == nil {
+		c.KeyFunc = func(r *http.Request) string { return r.URL.Host }
+	}
+}
+
+// AdaptiveRateLimitMiddleware paces requests with a limiter whose rate follows
+// the upstream's feedback instead of a hand-tuned static value.
+func AdaptiveRateLimitMiddleware(config *AdaptiveRateLimitConfig) Middleware {
+	if config == nil {
+		config = &AdaptiveRateLimitConfig{}
+	}
+	config.applyDefaults()
+	limiters := &adaptiveLimiters{config: config, byKey: map[string]*adaptiveLimiter{}}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			l := limiters.get(config.KeyFunc(req))
+			if err := l.limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp != nil {
+				l.observe(resp)
+			}
+			return resp, err
+		})
+	}
+}
+
+type adaptiveLimiters struct {
+	config *AdaptiveRateLimitConfig
+	mu     sync.Mutex
+	byKey  map[string]*adaptiveLimiter
+}
+
+func (a *adaptiveLimiters) get(key string) *adaptiveLimiter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	l, ok := a.byKey[key]
+	if !ok {
+		l = &adaptiveLimiter{
+			config:  a.config,
+			limiter: rate.NewLimiter(a.config.InitialRate, a.config.Burst),
+		}
+		a.byKey[key] = l
+	}
+	return l
+}
+
+type adaptiveLimiter struct {
+	config  *AdaptiveRateLimitConfig
+	mu      sync.Mutex
+	limiter *rate.Limiter
+}
+
+func (l *adaptiveLimiter) observe(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.limiter.Limit()
+	next := current
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || remainingIsLow(resp.Header, l.config.LowRemaining):
+		next = current * rate.Limit(l.config.DecreaseFactor)
+		if next < l.config.MinRate {
+			next = l.config.MinRate
+		}
+	case resp.StatusCode < 400:
+		next = current + l.config.IncreaseStep
+		if next > l.config.MaxRate {
+			next = l.config.MaxRate
+		}
+	}
+	if next != current {
+		l.limiter.SetLimit(next)
+	}
+}
+
+func remainingIsLow(h http.Header, threshold int) bool {
+	v := h.Get("X-RateLimit-Remaining")
+	if v == "" {
+		return false
+	}
+	remaining, err := strconv.Atoi(v)
+	return err == nil && remaining <= threshold
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	cfg := &AdaptiveRateLimitConfig{
+		InitialRate:    10,
+		MinRate:        2,
+		MaxRate:        12,
+		IncreaseStep:   1,
+		DecreaseFactor: 0.5,
+	}
+	cfg.applyDefaults()
+	l := (&adaptiveLimiters{config: cfg, byKey: map[string]*adaptiveLimiter{}}).get("api")
+
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	limited := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+
+	l.observe(ok)
+	if got := l.limiter.Limit(); got != 11 {
+		t.Errorf("expected additive increase to 11, got %v", got)
+	}
+	l.observe(ok)
+	l.observe(ok)
+	if got := l.limiter.Limit(); got != 12 {
+		t.Errorf("expected rate capped at MaxRate 12, got %v", got)
+	}
+	l.observe(limited)
+	if got := l.limiter.Limit(); got != 6 {
+		t.Errorf("expected multiplicative decrease to 6, got %v", got)
+	}
+	l.observe(limited)
+	l.observe(limited)
+	if got := l.limiter.Limit(); got != rate.Limit(2) {
+		t.Errorf("expected rate floored at MinRate 2, got %v", got)
+	}
+}
+
+func TestAdaptiveLimiterLowRemaining(t *testing.T) {
+	cfg := &AdaptiveRateLimitConfig{InitialRate: 8, LowRemaining: 5}
+	cfg.applyDefaults()
+	l := (&adaptiveLimiters{config: cfg, byKey: map[string]*adaptiveLimiter{}}).get("api")
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"X-Ratelimit-Remaining": {"3"}}}
+	l.observe(resp)
+	if got := l.limiter.Limit(); got != 4 {
+		t.Errorf("expected low remaining quota to halve the rate, got %v", got)
+	}
+}
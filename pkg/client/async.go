@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+var (
+	ErrAsyncQueueFull = errors.New("client: async queue is full")
+	ErrAsyncClosed    = errors.New("client: async queue is closed")
+)
+
+// AsyncConfig sizes the worker pool behind Client.Async.
+type AsyncConfig struct {
+	// Workers sending queued requests. Defaults to 4.
+	Workers int
+	// QueueSize bounds pending requests; Async fails fast with
+	// ErrAsyncQueueFull beyond it. Defaults to 1000.
+	QueueSize int
+	// MaxAttempts runs the whole Do pipeline, including its endpoint retries,
+	// up to this many times while the failure is retryable. Defaults to 3.
+	MaxAttempts int
+	// Backoff between attempts. Defaults to DefaultBackoff.
+	Backoff func(attempt int) time.Duration
+	// DeadLetter receives requests that still failed after MaxAttempts or
+	// were dropped at Close.
+	DeadLetter func(req *http.Request, err error)
+}
+
+func (c *AsyncConfig) applyDefaults() {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1000
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 3
+	}
+	if c.Backoff == nil {
+		c.Backoff = DefaultBackoff
+	}
+}
+
+func WithAsync(cfg *AsyncConfig) func(*options) {
+	return func(o *options) { o.async = cfg }
+}
+
+// AsyncOptions configures a single Async call.
+type AsyncOptions struct {
+	// OnComplete is called with the final outcome. The response body has
+	// already been read and can be read again.
+	OnComplete func(resp *http.Response, err error)
+	Options    []RequestOption
+}
+
+type asyncJob struct {
+	ctx  context.Context
+	req  *http.Request
+	opts *AsyncOptions
+}
+
+type asyncQueue struct {
+	client *Client
+	config *AsyncConfig
+	jobs   chan asyncJob
+	stop   context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Async queues req to be sent in the background, off the request path, e.g.
+// for webhooks and analytics. The context keeps its values but not its
+// cancellation, so the call outlives the inbound request. The body is
+// buffered when queued. Async only fails when the queue is full or closed;
+// delivery failures go to OnComplete and the DeadLetter hook.
+func (c *Client) Async(ctx context.Context, req *http.Request, opts *AsyncOptions) error {
+	if opts == nil {
+		opts = &AsyncOptions{}
+	}
+	q := c.asyncQueue()
+	if q == nil {
+		return ErrAsyncClosed
+	}
+	if _, _, err := prepareBody(req); err != nil {
+		return &Error{Err: err, Method: req.Method, URL: req.URL.String()}
+	}
+	job := asyncJob{ctx: context.WithoutCancel(ctx), req: req, opts: opts}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrAsyncClosed
+	}
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrAsyncQueueFull
+	}
+}
+
+func (c *Client) asyncQueue() *asyncQueue {
+	c.asyncOnce.Do(func() {
+		cfg := c.options.async
+		if cfg == nil {
+			cfg = &AsyncConfig{}
+		}
+		cfg.applyDefaults()
+		q := &asyncQueue{client: c, config: cfg, jobs: make(chan asyncJob, cfg.QueueSize)}
+		q.stop, q.cancel = context.WithCancel(context.Background())
+		for range cfg.Workers {
+			q.wg.Add(1)
+			go q.work()
+		}
+		c.async = q
+	})
+	return c.async
+}
+
+func (q *asyncQueue) work() {
+	defer q.wg.Done()
+	for job := range q.jobs {
+		q.run(job)
+	}
+}
+
+func (q *asyncQueue) run(job asyncJob) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	for attempt := 0; attempt < q.config.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if !sleepContext(q.stop, q.config.Backoff(attempt-1)) {
+				break
+			}
+			if job.req.GetBody != nil {
+				body, bodyErr := job.req.GetBody()
+				if bodyErr != nil {
+					err = bodyErr
+					break
+				}
+				job.req.Body = body
+			}
+		}
+		resp, err = q.client.Do(WithRequestOptions(job.ctx, job.opts.Options...), job.req)
+		if err == nil || !asyncRetryable(err) {
+			break
+		}
+	}
+	if err != nil {
+		logs.Warn(job.ctx, "async request failed", "method", job.req.Method, "url", job.req.URL.String(), "error", err.Error())
+		if q.config.DeadLetter != nil {
+			q.config.DeadLetter(job.req, err)
+		}
+	}
+	if job.opts.OnComplete != nil {
+		job.opts.OnComplete(resp, err)
+	}
+}
+
+// asyncRetryable keeps retrying transport failures, 5xx and 429, but not
+// other 4xx answers, which would fail the same way again.
+func asyncRetryable(err error) bool {
+	return !errors.Is(err, ErrClient) || errors.Is(err, ErrRateLimited)
+}
+
+// close stops accepting requests and waits for the queued ones to be sent.
+// Pending backoffs are abandoned and their requests dead-lettered.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+	q.cancel()
+	q.wg.Wait()
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAsyncRetriesAndCompletes(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"event":"signup"}` {
+			t.Errorf("unexpected body on attempt %d: %q", atomic.LoadInt32(&hits), body)
+		}
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(noRetrySettings()),
+		WithAsync(&AsyncConfig{Workers: 1, Backoff: func(int) time.Duration { return time.Millisecond }}),
+	)
+	defer c.Close()
+
+	done := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/events", strings.NewReader(`{"event":"signup"}`))
+	err := c.Async(ctx, req, &AsyncOptions{OnComplete: func(resp *http.Response, err error) {
+		if err == nil && resp.StatusCode != http.StatusAccepted {
+			err = errors.New(resp.Status)
+		}
+		done <- err
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The inbound request finishing must not cancel the queued call.
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected delivery after retries, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("async request did not complete")
+	}
+	if got := atomic.LoadInt32(&hits); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestAsyncDeadLetterAndClose(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var deadLetters int32
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(noRetrySettings()),
+		WithAsync(&AsyncConfig{
+			Workers:    1,
+			QueueSize:  1,
+			DeadLetter: func(req *http.Request, err error) { atomic.AddInt32(&deadLetters, 1) },
+		}),
+	)
+
+	newReq := func(path string) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		return req
+	}
+	if err := c.Async(context.Background(), newReq("/slow"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Wait until the worker picked up the first job, then fill the queue.
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.Async(context.Background(), newReq("/queued"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Async(context.Background(), newReq("/overflow"), nil); !errors.Is(err, ErrAsyncQueueFull) {
+		t.Errorf("expected ErrAsyncQueueFull, got %v", err)
+	}
+
+	close(release)
+	c.Close()
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Errorf("expected queued requests to drain on Close without retrying 4xx, got %d attempts", got)
+	}
+	if got := atomic.LoadInt32(&deadLetters); got != 2 {
+		t.Errorf("expected 2 dead letters, got %d", got)
+	}
+	if err := c.Async(context.Background(), newReq("/late"), nil); !errors.Is(err, ErrAsyncClosed) {
+		t.Errorf("expected ErrAsyncClosed after Close, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBackoffBase = 100 * time.Millisecond
+	defaultBackoffMax  = 5 * time.Second
+)
+
+// BackoffExponential returns a BackoffStrategy that doubles the delay on each
+// attempt starting at base, capped at max, with equal jitter: the returned
+// delay is uniformly distributed in [d/2, d] so concurrent clients spread out.
+func BackoffExponential(base, max time.Duration) func(attempt int) time.Duration {
+	if base <= 0 {
+		base = defaultBackoffBase
+	}
+	if max < base {
+		max = base
+	}
+	return func(attempt int) time.Duration {
+		d := max
+		if attempt < 32 {
+			if exp := base << uint(attempt); exp > 0 && exp < max {
+				d = exp
+			}
+		}
+		half := d / 2
+		return half + rand.N(half+1)
+	}
+}
+
+// DefaultBackoff is the BackoffStrategy used when an endpoint does not set one.
+var DefaultBackoff = BackoffExponential(defaultBackoffBase, defaultBackoffMax)
+
+func defaultShouldRetry(resp *http.Response, err error) bool {
+	return err != nil || (resp != nil && (resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests))
+}
+
+// retryDelay returns the delay the server asked for through Retry-After or
+// X-RateLimit-Reset on 429/503 responses, falling back to the strategy.
+func retryDelay(resp *http.Response, attempt int, strategy func(int) time.Duration) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if d, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+			return d
+		}
+	}
+	return strategy(attempt)
+}
+
+func parseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+	if v := strings.TrimSpace(h.Get("X-RateLimit-Reset")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			// Providers send either a unix timestamp or seconds until reset.
+			if n > 1_000_000_000 {
+				return nonNegative(time.Unix(n, 0).Sub(now)), true
+			}
+			return time.Duration(n) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// fitsDeadline reports whether waiting d still leaves time before ctx expires.
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// sleepContext waits for d or until ctx is done, returning false in the latter case.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
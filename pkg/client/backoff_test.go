@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffExponential(t *testing.T) {
+	backoff := BackoffExponential(100*time.Millisecond, time.Second)
+
+	cases := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond, 800 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+		{100, 500 * time.Millisecond, time.Second},
+	}
+	for _, tc := range cases {
+		for i := 0; i < 20; i++ {
+			d := backoff(tc.attempt)
+			if d < tc.min || d > tc.max {
+				t.Fatalf("attempt %d: delay %v outside [%v, %v]", tc.attempt, d, tc.min, tc.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{"http date", http.Header{"Retry-After": {now.Add(10 * time.Second).Format(http.TimeFormat)}}, 10 * time.Second, true},
+		{"rate limit reset delta", http.Header{"X-Ratelimit-Reset": {"7"}}, 7 * time.Second, true},
+		{"rate limit reset epoch", http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}}, time.Minute, true},
+		{"past date clamps to zero", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0, true},
+		{"missing", http.Header{}, 0, false},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.header, now)
+			if ok != tc.ok || got != tc.want {
+				t.Errorf("got (%v, %v), want (%v, %v)", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestRetryHonorsRetryAfterOn429(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:         5 * time.Second,
+			MaxRetries:      2,
+			Headers:         map[string]string{},
+			BackoffStrategy: func(int) time.Duration { return time.Hour },
+		}),
+	)
+
+	start := time.Now()
+	resp, err := c.Get(context.Background(), "/limited", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 after retry, got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Retry-After to override the backoff strategy, took %v", elapsed)
+	}
+}
+
+func TestRetryStopsWhenDelayExceedsDeadline(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: time.Second, MaxRetries: 3, Headers: map[string]string{}}),
+	)
+
+	_, err := c.Get(context.Background(), "/busy", nil)
+	if err == nil {
+		t.Fatal("expected error for 503")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected a single attempt when Retry-After exceeds the timeout, got %d", got)
+	}
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+const (
+	ejectAfterFailures = 3
+	ejectFor           = 30 * time.Second
+)
+
+// Strategy picks the base URL that serves a request.
+type Strategy interface {
+	pick(candidates []*lbTarget) *lbTarget
+}
+
+// RoundRobin cycles through the healthy base URLs.
+func RoundRobin() Strategy { return &roundRobin{} }
+
+// LeastPending sends each request to the base URL with the fewest requests in flight.
+func LeastPending() Strategy { return leastPending{} }
+
+// Weighted spreads requests proportionally to weights, given in the same order
+// as the base URLs. Missing or non-positive weights count as 1.
+func Weighted(weights ...int) Strategy {
+	return &weighted{weights: weights, current: map[int]int{}}
+}
+
+// WithBaseURLs spreads requests across replicas of a dependency. Paths are
+// built against the first URL and rewritten to the chosen one. A host failing
+// several requests in a row is ejected for a while, unless every host is.
+func WithBaseURLs(urls []string, strategy Strategy) func(*options) {
+	return func(o *options) {
+		if len(urls) == 0 {
+			return
+		}
+		if strategy == nil {
+			strategy = RoundRobin()
+		}
+		parsed := make([]*url.URL, 0, len(urls))
+		for _, raw := range urls {
+			u, err := url.Parse(strings.TrimRight(raw, "/"))
+			if err != nil || u.Host == "" {
+				o.configErr = &url.Error{Op: "parse base URL", URL: raw, Err: err}
+				return
+			}
+			parsed = append(parsed, u)
+		}
+		b := &balancer{strategy: strategy, primary: parsed[0]}
+		b.setTargets(parsed)
+		o.baseURL = parsed[0].String()
+		o.middlewares = append(o.middlewares, b.middleware)
+	}
+}
+
+type lbTarget struct {
+	url     *url.URL
+	index   int
+	pending int64
+
+	mu           sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+}
+
+func (t *lbTarget) healthy(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !now.Before(t.ejectedUntil)
+}
+
+func (t *lbTarget) report(failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !failed {
+		t.failures = 0
+		return
+	}
+	t.failures++
+	if t.failures >= ejectAfterFailures {
+		t.ejectedUntil = time.Now().Add(ejectFor)
+		t.failures = 0
+	}
+}
+
+type balancer struct {
+	strategy Strategy
+	primary  *url.URL
+
+	mu      sync.Mutex
+	targets []*lbTarget
+	// discover, when set, refreshes targets every discoveryRefresh.
+	discover  func(ctx context.Context) ([]*url.URL, error)
+	refreshed time.Time
+}
+
+// setTargets replaces the target set, keeping the health and load state of
+// hosts that are still present.
+func (b *balancer) setTargets(urls []*url.URL) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	existing := make(map[string]*lbTarget, len(b.targets))
+	for _, t := range b.targets {
+		existing[t.url.String()] = t
+	}
+	targets := make([]*lbTarget, 0, len(urls))
+	for i, u := range urls {
+		t, ok := existing[u.String()]
+		if !ok {
+			t = &lbTarget{url: u, index: i}
+		}
+		targets = append(targets, t)
+	}
+	b.targets = targets
+}
+
+func (b *balancer) current(ctx context.Context) ([]*lbTarget, error) {
+	b.mu.Lock()
+	targets, stale := b.targets, b.discover != nil && time.Since(b.refreshed) >= discoveryRefresh
+	if stale {
+		b.refreshed = time.Now()
+	}
+	b.mu.Unlock()
+	if !stale {
+		return targets, nil
+	}
+	urls, err := b.discover(ctx)
+	if err != nil || len(urls) == 0 {
+		if len(targets) > 0 {
+			logs.Warn(ctx, "service discovery failed, keeping previous endpoints", "service", b.primary.Host, "error", err)
+			return targets, nil
+		}
+		b.mu.Lock()
+		b.refreshed = time.Time{}
+		b.mu.Unlock()
+		if err == nil {
+			err = ErrNoEndpoints
+		}
+		return nil, err
+	}
+	b.setTargets(urls)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.targets, nil
+}
+
+func (b *balancer) next(ctx context.Context) (*lbTarget, error) {
+	targets, err := b.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	candidates := make([]*lbTarget, 0, len(targets))
+	for _, t := range targets {
+		if t.healthy(now) {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = targets
+	}
+	return b.strategy.pick(candidates), nil
+}
+
+// hasPathPrefix matches prefix on path-segment boundaries, so /api covers
+// /api and /api/users but not /apiv2.
+func hasPathPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func (b *balancer) middleware(next http.RoundTripper) http.RoundTripper {
+	primary := b.primary
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Scheme != primary.Scheme || req.URL.Host != primary.Host || !hasPathPrefix(req.URL.Path, primary.Path) {
+			return next.RoundTrip(req)
+		}
+		target, err := b.next(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		out := req.Clone(req.Context())
+		u := *req.URL
+		u.Scheme = target.url.Scheme
+		u.Host = target.url.Host
+		u.Path = target.url.Path + strings.TrimPrefix(req.URL.Path, primary.Path)
+		u.RawPath = ""
+		out.URL = &u
+		out.Host = ""
+
+		atomic.AddInt64(&target.pending, 1)
+		done := func() { atomic.AddInt64(&target.pending, -1) }
+		resp, err := next.RoundTrip(out)
+		target.report((err != nil && !isContextError(err)) || (resp != nil && resp.StatusCode >= 500))
+		if err != nil || resp == nil || resp.Body == nil {
+			done()
+			return resp, err
+		}
+		resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: done}
+		return resp, nil
+	})
+}
+
+type roundRobin struct {
+	counter uint64
+}
+
+func (r *roundRobin) pick(candidates []*lbTarget) *lbTarget {
+	n := atomic.AddUint64(&r.counter, 1) - 1
+	return candidates[n%uint64(len(candidates))]
+}
+
+type leastPending struct{}
+
+func (leastPending) pick(candidates []*lbTarget) *lbTarget {
+	best := candidates[0]
+	for _, t := range candidates[1:] {
+		if atomic.LoadInt64(&t.pending) < atomic.LoadInt64(&best.pending) {
+			best = t
+		}
+	}
+	return best
+}
+
+// weighted implements smooth weighted round-robin, which interleaves hosts
+// instead of sending bursts to the heaviest one.
+type weighted struct {
+	mu      sync.Mutex
+	weights []int
+	current map[int]int
+}
+
+func (w *weighted) weight(t *lbTarget) int {
+	if t.index < len(w.weights) && w.weights[t.index] > 0 {
+		return w.weights[t.index]
+	}
+	return 1
+}
+
+func (w *weighted) pick(candidates []*lbTarget) *lbTarget {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var (
+		best  *lbTarget
+		total int
+	)
+	for _, t := range candidates {
+		wt := w.weight(t)
+		total += wt
+		w.current[t.index] += wt
+		if best == nil || w.current[t.index] > w.current[best.index] {
+			best = t
+		}
+	}
+	w.current[best.index] -= total
+	return best
+}
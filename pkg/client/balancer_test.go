@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingServer(status int, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.URL.Path != "/api/users" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+}
+
+func newBalancedClient(urls []string, strategy Strategy) *Client {
+	return NewClient(
+		WithBaseURLs(urls, strategy),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:     5 * time.Second,
+			Headers:     map[string]string{},
+			ShouldRetry: func(*http.Response, error) bool { return false },
+		}),
+	)
+}
+
+func TestWithBaseURLsRoundRobin(t *testing.T) {
+	var a, b int32
+	srvA := newCountingServer(http.StatusOK, &a)
+	defer srvA.Close()
+	srvB := newCountingServer(http.StatusOK, &b)
+	defer srvB.Close()
+
+	c := newBalancedClient([]string{srvA.URL + "/api", srvB.URL + "/api/"}, RoundRobin())
+	for i := 0; i < 10; i++ {
+		resp, err := c.Get(context.Background(), "/users", nil)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if a != 5 || b != 5 {
+		t.Errorf("expected an even split, got %d/%d", a, b)
+	}
+}
+
+func TestBalancerMatchesPathSegments(t *testing.T) {
+	primary, _ := url.Parse("http://primary/api")
+	replica, _ := url.Parse("http://replica/v1")
+	b := &balancer{strategy: RoundRobin(), primary: primary}
+	b.setTargets([]*url.URL{replica})
+
+	var got string
+	rt := b.middleware(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.URL.String()
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	for path, want := range map[string]string{
+		"/api":       "http://replica/v1",
+		"/api/users": "http://replica/v1/users",
+		"/apiv2/x":   "http://primary/apiv2/x",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "http://primary"+path, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestWithBaseURLsWeighted(t *testing.T) {
+	var a, b int32
+	srvA := newCountingServer(http.StatusOK, &a)
+	defer srvA.Close()
+	srvB := newCountingServer(http.StatusOK, &b)
+	defer srvB.Close()
+
+	c := newBalancedClient([]string{srvA.URL + "/api", srvB.URL + "/api"}, Weighted(3, 1))
+	for i := 0; i < 8; i++ {
+		resp, err := c.Get(context.Background(), "/users", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if a != 6 || b != 2 {
+		t.Errorf("expected a 3:1 split, got %d/%d", a, b)
+	}
+}
+
+func TestWithBaseURLsEjectsFailingHost(t *testing.T) {
+	var healthy, failing int32
+	srvOK := newCountingServer(http.StatusOK, &healthy)
+	defer srvOK.Close()
+	srvBad := newCountingServer(http.StatusBadGateway, &failing)
+	defer srvBad.Close()
+
+	c := newBalancedClient([]string{srvBad.URL + "/api", srvOK.URL + "/api"}, LeastPending())
+	for i := 0; i < 10; i++ {
+		resp, err := c.Get(context.Background(), "/users", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	if failing != ejectAfterFailures {
+		t.Errorf("expected failing host to be ejected after %d failures, got %d hits", ejectAfterFailures, failing)
+	}
+	if healthy != 10-ejectAfterFailures {
+		t.Errorf("expected remaining traffic on the healthy host, got %d", healthy)
+	}
+}
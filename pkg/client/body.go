@@ -0,0 +1,59 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// streamingBody marks a request body that must not be buffered by Do.
+// Requests carrying it are sent once, without retries.
+type streamingBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b *streamingBody) Close() error {
+	if b.closer != nil {
+		return b.closer.Close()
+	}
+	return nil
+}
+
+// prepareBody makes req's body replayable across retry attempts. It returns
+// a function producing a fresh copy of the body (nil when there is no body)
+// and whether the body can be replayed at all. Bodies with GetBody are
+// rewound through it; other bodies are buffered once, except streaming ones.
+func prepareBody(req *http.Request) (func() (io.ReadCloser, error), bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if _, ok := req.Body.(*streamingBody); ok {
+		return nil, false, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, true, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, false, err
+	}
+	getBody := func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.Body, _ = getBody()
+	req.ContentLength = int64(len(data))
+	req.GetBody = getBody
+	return getBody, true, nil
+}
+
+// isIdempotent reports whether req may be safely sent more than once. Requests
+// carrying an Idempotency-Key header are treated as idempotent.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type bodyRecorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (b *bodyRecorder) handler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		b.mu.Lock()
+		b.bodies = append(b.bodies, string(data))
+		b.mu.Unlock()
+		w.WriteHeader(status)
+	}
+}
+
+func (b *bodyRecorder) get() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.bodies...)
+}
+
+func newRetryClient(url string, settings *EndpointSettings) *Client {
+	settings.Timeout = 5 * time.Second
+	settings.MaxRetries = 2
+	settings.Headers = map[string]string{}
+	settings.BackoffStrategy = func(int) time.Duration { return time.Millisecond }
+	return NewClient(WithBaseURL(url), WithDefaultSettings(settings))
+}
+
+func TestPostIsNotRetriedByDefault(t *testing.T) {
+	rec := &bodyRecorder{}
+	srv := httptest.NewServer(rec.handler(http.StatusBadGateway))
+	defer srv.Close()
+
+	c := newRetryClient(srv.URL, &EndpointSettings{})
+	_, _ = c.Post(context.Background(), "/orders", []byte(`{"id":1}`), nil)
+
+	if got := rec.get(); len(got) != 1 {
+		t.Errorf("expected POST to be sent once, got %d attempts", len(got))
+	}
+}
+
+func TestRetryNonIdempotentReplaysBody(t *testing.T) {
+	rec := &bodyRecorder{}
+	srv := httptest.NewServer(rec.handler(http.StatusBadGateway))
+	defer srv.Close()
+
+	c := newRetryClient(srv.URL, &EndpointSettings{RetryNonIdempotent: true})
+	_, _ = c.Post(context.Background(), "/orders", []byte(`{"id":1}`), nil)
+
+	got := rec.get()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(got))
+	}
+	for i, body := range got {
+		if body != `{"id":1}` {
+			t.Errorf("attempt %d: expected replayed body, got %q", i, body)
+		}
+	}
+}
+
+func TestIdempotencyKeyAllowsRetry(t *testing.T) {
+	rec := &bodyRecorder{}
+	srv := httptest.NewServer(rec.handler(http.StatusBadGateway))
+	defer srv.Close()
+
+	c := newRetryClient(srv.URL, &EndpointSettings{})
+	_, _ = c.Post(context.Background(), "/orders", []byte("payload"), map[string]string{"Idempotency-Key": "k-1"})
+
+	if got := rec.get(); len(got) != 3 {
+		t.Errorf("expected request with Idempotency-Key to be retried, got %d attempts", len(got))
+	}
+}
+
+func TestRetryUsesGetBodyForUnbufferedReaders(t *testing.T) {
+	rec := &bodyRecorder{}
+	srv := httptest.NewServer(rec.handler(http.StatusServiceUnavailable))
+	defer srv.Close()
+
+	c := newRetryClient(srv.URL, &EndpointSettings{})
+	var rewinds int
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/doc", io.NopCloser(bytes.NewReader([]byte("doc"))))
+	req.GetBody = func() (io.ReadCloser, error) {
+		rewinds++
+		return io.NopCloser(bytes.NewReader([]byte("doc"))), nil
+	}
+	req.ContentLength = 3
+	_, _ = c.Do(context.Background(), req)
+
+	got := rec.get()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(got))
+	}
+	if rewinds != 2 {
+		t.Errorf("expected GetBody to be used for the 2 retries, got %d calls", rewinds)
+	}
+	for i, body := range got {
+		if body != "doc" {
+			t.Errorf("attempt %d: expected body %q, got %q", i, "doc", body)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker"
+)
+
+// BreakerRegistryConfig configures NewBreakerRegistry.
+type BreakerRegistryConfig struct {
+	// Settings is the template for every breaker; Name is replaced by the
+	// breaker key. Its OnStateChange, if any, is still called.
+	Settings gobreaker.Settings
+	// Key maps a request to a breaker name. Defaults to method and path, so
+	// set it when paths contain IDs.
+	Key func(method, path string) string
+	// OnStateChange is called after every transition, in addition to the
+	// log entry the registry writes.
+	OnStateChange func(name string, from, to gobreaker.State)
+	// Alert sends breaker openings to the notifiers registered in pkg/logs.
+	Alert bool
+	// Metrics exports circuit_breaker_state (0 closed, 1 half-open, 2 open)
+	// and circuit_breaker_state_changes_total per breaker.
+	Metrics *MetricsConfig
+}
+
+// BreakerStatus is a point-in-time view of a breaker.
+type BreakerStatus struct {
+	Name   string           `json:"name"`
+	State  string           `json:"state"`
+	Counts gobreaker.Counts `json:"counts"`
+}
+
+// BreakerRegistry creates circuit breakers on demand, logs and exports their
+// state changes, and lets them be inspected at runtime. Use BreakerFor with
+// CircuitBreakerConfig:
+//
+//	breakers := client.NewBreakerRegistry(&client.BreakerRegistryConfig{Alert: true})
+//	client.WithCircuitBreaker(&client.CircuitBreakerConfig{BreakerFor: breakers.BreakerFor})
+type BreakerRegistry struct {
+	config  *BreakerRegistryConfig
+	state   *prometheus.GaugeVec
+	changes *prometheus.CounterVec
+
+	mu       sync.RWMutex
+	breakers map[string]*gobreaker.CircuitBreaker
+}
+
+func NewBreakerRegistry(config *BreakerRegistryConfig) *BreakerRegistry {
+	if config == nil {
+		config = &BreakerRegistryConfig{}
+	}
+	if config.Key == nil {
+		config.Key = func(method, path string) string { return method + " " + path }
+	}
+	r := &BreakerRegistry{config: config, breakers: map[string]*gobreaker.CircuitBreaker{}}
+	if config.Metrics != nil {
+		namespace := config.Metrics.Namespace
+		if namespace == "" {
+			namespace = "http_client"
+		}
+		r.state = registerOrReuse(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: config.Metrics.Subsystem,
+				Name:      "circuit_breaker_state",
+				Help:      "Circuit breaker state: 0 closed, 1 half-open, 2 open",
+			},
+			[]string{"name"},
+		)).(*prometheus.GaugeVec)
+		r.changes = registerOrReuse(prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: config.Metrics.Subsystem,
+				Name:      "circuit_breaker_state_changes_total",
+				Help:      "Circuit breaker transitions by target state",
+			},
+			[]string{"name", "state"},
+		)).(*prometheus.CounterVec)
+	}
+	return r
+}
+
+// BreakerFor returns the breaker for a request, creating it on first use.
+func (r *BreakerRegistry) BreakerFor(method, path string) *gobreaker.CircuitBreaker {
+	return r.Get(r.config.Key(method, path))
+}
+
+// Get returns the breaker with the given name, creating it on first use.
+func (r *BreakerRegistry) Get(name string) *gobreaker.CircuitBreaker {
+	return r.getWith(name, r.config.Settings)
+}
+
+// getWith is Get with settings other than the registry template.
+func (r *BreakerRegistry) getWith(name string, settings gobreaker.Settings) *gobreaker.CircuitBreaker {
+	r.mu.RLock()
+	cb, ok := r.breakers[name]
+	r.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cb, ok := r.breakers[name]; ok {
+		return cb
+	}
+	settings.Name = name
+	userHook := settings.OnStateChange
+	settings.OnStateChange = func(name string, from, to gobreaker.State) {
+		r.stateChanged(name, from, to)
+		if userHook != nil {
+			userHook(name, from, to)
+		}
+	}
+	cb = gobreaker.NewCircuitBreaker(settings)
+	r.breakers[name] = cb
+	if r.state != nil {
+		r.state.WithLabelValues(name).Set(float64(gobreaker.StateClosed))
+	}
+	return cb
+}
+
+func (r *BreakerRegistry) stateChanged(name string, from, to gobreaker.State) {
+	if r.state != nil {
+		r.state.WithLabelValues(name).Set(float64(to))
+		r.changes.WithLabelValues(name, to.String()).Inc()
+	}
+	ctx := context.Background()
+	fields := []any{"breaker", name, "from", from.String(), "to", to.String()}
+	switch to {
+	case gobreaker.StateOpen:
+		if r.config.Alert {
+			fields = append(fields, logs.WithNotifier())
+		}
+		logs.Error(ctx, "circuit breaker opened", fields...)
+	case gobreaker.StateHalfOpen:
+		logs.Warn(ctx, "circuit breaker half-open", fields...)
+	default:
+		logs.Info(ctx, "circuit breaker closed", fields...)
+	}
+	if r.config.OnStateChange != nil {
+		r.config.OnStateChange(name, from, to)
+	}
+}
+
+// Snapshot returns the status of every breaker, sorted by name.
+func (r *BreakerRegistry) Snapshot() []BreakerStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]BreakerStatus, 0, len(r.breakers))
+	for name, cb := range r.breakers {
+		out = append(out, BreakerStatus{Name: name, State: cb.State().String(), Counts: cb.Counts()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ServeHTTP writes the snapshot as JSON, for mounting on an ops endpoint.
+func (r *BreakerRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(r.Snapshot())
+}
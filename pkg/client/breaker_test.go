@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker"
+)
+
+func TestBreakerRegistry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+	registry := NewBreakerRegistry(&BreakerRegistryConfig{
+		Settings: gobreaker.Settings{
+			ReadyToTrip: func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 2 },
+			Timeout:     time.Minute,
+		},
+		Key: func(_, path string) string { return path },
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			mu.Lock()
+			transitions = append(transitions, name+":"+to.String())
+			mu.Unlock()
+		},
+		Metrics: &MetricsConfig{Namespace: "breaker_registry_test"},
+	})
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(noRetrySettings()),
+		WithCircuitBreaker(&CircuitBreakerConfig{BreakerFor: registry.BreakerFor}),
+	)
+
+	for range 3 {
+		_, _ = c.Get(context.Background(), "/fail", nil)
+	}
+	if _, err := c.Get(context.Background(), "/ok", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	if len(transitions) != 1 || transitions[0] != "/fail:open" {
+		t.Errorf("unexpected transitions: %v", transitions)
+	}
+	mu.Unlock()
+	if got := testutil.ToFloat64(registry.state.WithLabelValues("/fail")); got != float64(gobreaker.StateOpen) {
+		t.Errorf("expected open gauge, got %v", got)
+	}
+	if got := testutil.ToFloat64(registry.state.WithLabelValues("/ok")); got != float64(gobreaker.StateClosed) {
+		t.Errorf("expected closed gauge, got %v", got)
+	}
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/breakers", nil))
+	var snapshot []BreakerStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if len(snapshot) != 2 || snapshot[0].Name != "/fail" || snapshot[0].State != "open" || snapshot[1].State != "closed" {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrConcurrencyLimit = errors.New("client: concurrency limit reached")
+
+// Bulkhead caps the number of requests in flight to a dependency. Callers
+// beyond the limit queue for up to queueTimeout; a zero queueTimeout rejects
+// them immediately.
+type Bulkhead struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+func NewBulkhead(maxInFlight int, queueTimeout time.Duration) *Bulkhead {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	return &Bulkhead{
+		slots:        make(chan struct{}, maxInFlight),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire reserves a slot, waiting at most queueTimeout or until ctx is done.
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if b.queueTimeout <= 0 {
+		return ErrConcurrencyLimit
+	}
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrConcurrencyLimit
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bulkhead) Release() {
+	<-b.slots
+}
+
+// InFlight returns the number of slots currently held.
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
+
+type ConcurrencyLimitConfig struct {
+	BulkheadFor func(method, path string) *Bulkhead
+}
+
+// ConcurrencyLimitMiddleware applies a single bulkhead to every request.
+// Use BulkheadMiddleware to give endpoints their own limits.
+func ConcurrencyLimitMiddleware(maxInFlight int, queueTimeout time.Duration) Middleware {
+	b := NewBulkhead(maxInFlight, queueTimeout)
+	return BulkheadMiddleware(&ConcurrencyLimitConfig{
+		BulkheadFor: func(string, string) *Bulkhead { return b },
+	})
+}
+
+// BulkheadMiddleware limits concurrency with the bulkhead returned for each
+// request. A slot is held until the response body is closed, since the
+// connection stays busy while the body is read.
+func BulkheadMiddleware(cfg *ConcurrencyLimitConfig) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if cfg == nil || cfg.BulkheadFor == nil {
+				return next.RoundTrip(req)
+			}
+			b := cfg.BulkheadFor(req.Method, req.URL.Path)
+			if b == nil {
+				return next.RoundTrip(req)
+			}
+			if err := b.Acquire(req.Context()); err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp == nil || resp.Body == nil {
+				b.Release()
+				return resp, err
+			}
+			resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: b.Release}
+			return resp, nil
+		})
+	}
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkheadAcquire(t *testing.T) {
+	b := NewBulkhead(1, 0)
+	ctx := context.Background()
+
+	if err := b.Acquire(ctx); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if err := b.Acquire(ctx); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("expected ErrConcurrencyLimit without queue, got %v", err)
+	}
+	b.Release()
+	if b.InFlight() != 0 {
+		t.Errorf("expected 0 in flight, got %d", b.InFlight())
+	}
+
+	queued := NewBulkhead(1, time.Second)
+	_ = queued.Acquire(ctx)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		queued.Release()
+	}()
+	if err := queued.Acquire(ctx); err != nil {
+		t.Errorf("expected queued acquire to succeed once a slot frees up, got %v", err)
+	}
+}
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	var current, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithMiddleware(ConcurrencyLimitMiddleware(2, 2*time.Second)),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Get(context.Background(), "/slow", nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Errorf("expected at most 2 concurrent requests, saw %d", got)
+	}
+}
+
+func TestBulkheadMiddlewarePerEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	full := NewBulkhead(1, 0)
+	_ = full.Acquire(context.Background())
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithConcurrencyLimit(&ConcurrencyLimitConfig{
+			BulkheadFor: func(method, path string) *Bulkhead {
+				if path == "/reports" {
+					return full
+				}
+				return nil
+			},
+		}),
+	)
+
+	if _, err := c.Get(context.Background(), "/users", nil); err != nil {
+		t.Errorf("expected unlimited endpoint to succeed, got %v", err)
+	}
+	_, err := c.Get(context.Background(), "/reports", nil)
+	if !errors.Is(err, ErrConcurrencyLimit) {
+		t.Errorf("expected ErrConcurrencyLimit for saturated endpoint, got %v", err)
+	}
+}
@@ -3,15 +3,30 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Client struct {
 	httpClient *http.Client
+	transport  *http.Transport
 	options    *options
+	versions   *versionRouter
+	asyncOnce  sync.Once
+	async      *asyncQueue
 }
 
 type options struct {
@@ -20,6 +35,22 @@ type options struct {
 	defaultSettings *EndpointSettings
 	middlewares     []Middleware
 	hooks           *HooksConfig
+	tlsConfig       *tls.Config
+	proxy           func(*http.Request) (*url.URL, error)
+	transportConfig *TransportConfig
+	poolMetrics     *poolMetrics
+	dnsCache        *dnsCache
+	resolver        Resolver
+	cookieJar       http.CookieJar
+	decompress      bool
+	graphQL         *GraphQLConfig
+	roundTripper    http.RoundTripper
+	retryBudget     *retryBudget
+	routeTemplates  []string
+	validationErrs  *prometheus.CounterVec
+	async           *AsyncConfig
+	httpVersion     HTTPVersion
+	configErr       error
 }
 
 func WithBaseURL(url string) func(*options) {
@@ -31,6 +62,13 @@ func WithEndpointConfig(ec EndpointConfig) func(*options) {
 func WithDefaultSettings(s *EndpointSettings) func(*options) {
 	return func(o *options) { o.defaultSettings = s }
 }
+
+// WithTransport replaces the network transport underneath the middleware
+// chain, e.g. with a clienttest.Fake. Transport level options such as TLS,
+// proxies and pool metrics are ignored when it is set.
+func WithTransport(rt http.RoundTripper) func(*options) {
+	return func(o *options) { o.roundTripper = rt }
+}
 func WithMiddleware(mw Middleware) func(*options) {
 	return func(o *options) { o.middlewares = append(o.middlewares, mw) }
 }
@@ -42,6 +80,11 @@ func WithRateLimit(cfg *RateLimitConfig) func(*options) {
 		}
 	}
 }
+func WithAdaptiveRateLimit(cfg *AdaptiveRateLimitConfig) func(*options) {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, AdaptiveRateLimitMiddleware(cfg))
+	}
+}
 func WithCircuitBreaker(cfg *CircuitBreakerConfig) func(*options) {
 	return func(o *options) {
 		if mw := CircuitBreakerMiddleware(cfg); mw != nil {
@@ -49,6 +92,37 @@ func WithCircuitBreaker(cfg *CircuitBreakerConfig) func(*options) {
 		}
 	}
 }
+func WithOAuth2(cfg *OAuth2Config) func(*options) {
+	return func(o *options) {
+		if mw := OAuth2Middleware(cfg); mw != nil {
+			o.middlewares = append(o.middlewares, mw)
+		}
+	}
+}
+func WithHMACSigning(cfg *HMACSigningConfig) func(*options) {
+	return func(o *options) {
+		if mw := HMACSigningMiddleware(cfg); mw != nil {
+			o.middlewares = append(o.middlewares, mw)
+		}
+	}
+}
+func WithSigV4(cfg *SigV4Config) func(*options) {
+	return func(o *options) {
+		if mw := SigV4SigningMiddleware(cfg); mw != nil {
+			o.middlewares = append(o.middlewares, mw)
+		}
+	}
+}
+func WithLogging(cfg *LoggingConfig) func(*options) {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, LoggingMiddleware(cfg))
+	}
+}
+func WithVCR(cfg *VCRConfig) func(*options) {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, VCRMiddleware(cfg))
+	}
+}
 func WithCache(cfg *CacheConfig) func(*options) {
 	return func(o *options) {
 		if mw := CacheMiddleware(cfg); mw != nil {
@@ -56,6 +130,16 @@ func WithCache(cfg *CacheConfig) func(*options) {
 		}
 	}
 }
+func WithConcurrencyLimit(cfg *ConcurrencyLimitConfig) func(*options) {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, BulkheadMiddleware(cfg))
+	}
+}
+func WithDedup(cfg *DedupConfig) func(*options) {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, DedupMiddleware(cfg))
+	}
+}
 func WithMaxResponseSize(maxSize int64) func(*options) {
 	return func(o *options) {
 		if maxSize > 0 {
@@ -67,6 +151,7 @@ func WithMetrics(cfg *MetricsConfig) func(*options) {
 	return func(o *options) {
 		if mw := MetricsMiddleware(cfg); mw != nil {
 			o.middlewares = append(o.middlewares, mw)
+			o.validationErrs = newValidationErrorsMetric(cfg)
 		}
 	}
 }
@@ -88,19 +173,51 @@ func NewClient(opts ...func(*options)) *Client {
 	for _, opt := range opts {
 		opt(o)
 	}
-	transport := http.DefaultTransport
+	base := newBaseTransport(o)
+	versions := newVersionRouter(base, o.httpVersion)
+	var transport http.RoundTripper = versions
+	if o.roundTripper != nil {
+		transport = o.roundTripper
+	} else if o.poolMetrics != nil {
+		transport = o.poolMetrics.wrap(versions)
+	}
+	if o.decompress {
+		transport = DecompressionMiddleware()(transport)
+	}
 	for i := len(o.middlewares) - 1; i >= 0; i-- {
 		transport = o.middlewares[i](transport)
 	}
 	return &Client{
-		httpClient: &http.Client{Transport: transport},
+		httpClient: &http.Client{Transport: transport, Jar: o.cookieJar},
+		transport:  base,
+		versions:   versions,
 		options:    o,
 	}
 }
 
 type EndpointConfigKey struct{}
 
+type attemptKey struct{}
+
+// retryAttempt returns how many retries preceded the current attempt.
+func retryAttempt(ctx context.Context) int {
+	if a, ok := ctx.Value(attemptKey{}).(*atomic.Int32); ok {
+		return int(a.Load())
+	}
+	return 0
+}
+
 func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return c.do(ctx, req, nil)
+}
+
+// do runs the request pipeline. When consume is set, successful response
+// bodies are handed to it unbuffered while the request context is still
+// alive, instead of being read into memory.
+func (c *Client) do(ctx context.Context, req *http.Request, consume func(*http.Response) error) (*http.Response, error) {
+	if c.options.configErr != nil {
+		return nil, &Error{Err: c.options.configErr, Method: req.Method, URL: req.URL.String()}
+	}
 	var cfg *EndpointSettings
 	if c.options.endpointConfig != nil {
 		cfg = c.options.endpointConfig(req.Method, req.URL.Path)
@@ -109,10 +226,19 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 		cfg = c.options.defaultSettings
 	}
 	cfg = applyDefaults(cfg)
+	applyRequestOptions(ctx, cfg)
 	ValidateEndpointConfig(cfg, req.URL.Path)
 	ctx = context.WithValue(ctx, EndpointConfigKey{}, cfg)
+	ctx = resolveRoute(ctx, cfg, c.options.routeTemplates, req.URL.Path)
+	attempt := new(atomic.Int32)
+	ctx = context.WithValue(ctx, attemptKey{}, attempt)
 
-	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	var cancel context.CancelFunc
+	if ro := requestOptionsFrom(ctx); ro != nil && ro.noTimeout {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+	}
 	defer cancel()
 	req = req.WithContext(ctx)
 
@@ -145,45 +271,105 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	)
 	shouldRetry := cfg.ShouldRetry
 	if shouldRetry == nil {
-		shouldRetry = func(resp *http.Response, err error) bool {
-			return err != nil || (resp != nil && resp.StatusCode >= 500)
-		}
+		shouldRetry = defaultShouldRetry
 	}
 	backoffStrategy := cfg.BackoffStrategy
 	if backoffStrategy == nil {
-		backoffStrategy = func(attempt int) time.Duration { return 200 * time.Millisecond }
+		backoffStrategy = DefaultBackoff
 	}
 
-	var bodyBytes []byte
-	if req.Body != nil {
-		var readErr error
-		bodyBytes, readErr = io.ReadAll(req.Body)
-		if readErr != nil {
-			return nil, &Error{Err: readErr, Method: req.Method, URL: req.URL.String()}
-		}
-		req.Body.Close()
+	rewindBody, replayable, bodyErr := prepareBody(req)
+	if bodyErr != nil {
+		return nil, &Error{Err: bodyErr, Method: req.Method, URL: req.URL.String()}
+	}
+	maxRetries := cfg.MaxRetries
+	safeToRepeat := replayable && (cfg.RetryNonIdempotent || isIdempotent(req))
+	if !safeToRepeat {
+		maxRetries = 0
+	}
+	hedge := safeToRepeat && cfg.HedgeAfter > 0 && cfg.MaxHedges > 0
+	if c.options.retryBudget != nil {
+		c.options.retryBudget.recordRequest()
 	}
 
-	for retry = 0; retry <= cfg.MaxRetries; retry++ {
-		if bodyBytes != nil {
-			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-			req.ContentLength = int64(len(bodyBytes))
+	for retry = 0; retry <= maxRetries; retry++ {
+		attempt.Store(int32(retry))
+		if retry > 0 && rewindBody != nil {
+			rc, rewindErr := rewindBody()
+			if rewindErr != nil {
+				resp, err = nil, fmt.Errorf("rewind request body: %w", rewindErr)
+				break
+			}
+			req.Body = rc
+		}
+		if hedge {
+			resp, err = c.sendHedged(req, cfg.HedgeAfter, cfg.MaxHedges, rewindBody)
+		} else {
+			resp, err = c.httpClient.Do(req)
 		}
-		resp, err = c.httpClient.Do(req)
-		if !shouldRetry(resp, err) {
+		if retry == maxRetries || !shouldRetry(resp, err) {
+			break
+		}
+		if c.options.retryBudget != nil && !c.options.retryBudget.withdraw() {
+			logs.Debug(ctx, "retry budget exhausted, not retrying", "method", req.Method, "path", req.URL.Path)
+			break
+		}
+		delay := retryDelay(resp, retry, backoffStrategy)
+		if !fitsDeadline(ctx, delay) {
+			// Waiting would outlive the request timeout; keep the last outcome.
 			break
 		}
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
 		}
-		if retry < cfg.MaxRetries {
-			time.Sleep(backoffStrategy(retry))
+		if !sleepContext(ctx, delay) {
+			resp, err = nil, ctx.Err()
+			break
 		}
 	}
-	if resp != nil && resp.Body != nil {
+	if consume != nil && err == nil && resp != nil && resp.StatusCode < 400 {
+		consumeErr := consume(resp)
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+		resp.Body = http.NoBody
+		if consumeErr != nil {
+			clientErr = &Error{
+				StatusCode: resp.StatusCode,
+				Err:        consumeErr,
+				Retries:    retry,
+				Method:     req.Method,
+				URL:        req.URL.String(),
+			}
+			if c.options.hooks.OnError != nil {
+				c.options.hooks.OnError(ctx, reqInfo, clientErr)
+			}
+			return resp, clientErr
+		}
+	} else if resp != nil && resp.Body != nil {
 		body, _ = io.ReadAll(resp.Body)
 		resp.Body.Close()
 		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err == nil && resp.StatusCode < 400 && cfg.ValidateResponse != nil {
+			if vErr := cfg.ValidateResponse(resp, body); vErr != nil {
+				if c.options.validationErrs != nil {
+					c.options.validationErrs.WithLabelValues(req.Method, req.URL.Host, routeLabel(req)).Inc()
+				}
+				clientErr = &Error{
+					StatusCode:   resp.StatusCode,
+					Body:         body,
+					Err:          &ValidationError{Err: vErr},
+					Retries:      retry,
+					Method:       req.Method,
+					URL:          req.URL.String(),
+					LastResponse: resp,
+				}
+				if c.options.hooks.OnError != nil {
+					c.options.hooks.OnError(ctx, reqInfo, clientErr)
+				}
+				return resp, clientErr
+			}
+		}
 	}
 	if err != nil || (resp != nil && resp.StatusCode >= 400) {
 		clientErr = &Error{
@@ -199,9 +385,14 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 			clientErr.Body = body
 		}
 		if cfg.Fallback != nil {
-			fbResp, fbErr := cfg.Fallback(req, err)
+			cause := err
+			if cause == nil {
+				cause = clientErr
+			}
+			fbResp, fbErr := cfg.Fallback(req, cause)
 			if fbErr != nil {
-				if cErr, ok := fbErr.(*Error); ok {
+				var cErr *Error
+				if errors.As(fbErr, &cErr) {
 					if c.options.hooks.OnError != nil {
 						c.options.hooks.OnError(ctx, reqInfo, cErr)
 					}
@@ -226,43 +417,57 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	return resp, nil
 }
 
-func (c *Client) Get(ctx context.Context, path string, headers map[string]string) (*http.Response, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, c.options.baseURL+path, nil)
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	return c.Do(ctx, req)
+func (c *Client) Get(ctx context.Context, path string, headers map[string]string, opts ...RequestOption) (*http.Response, error) {
+	return c.send(ctx, http.MethodGet, path, nil, headers, opts)
 }
-func (c *Client) Post(ctx context.Context, path string, body []byte, headers map[string]string) (*http.Response, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, c.options.baseURL+path, bytes.NewReader(body))
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	return c.Do(ctx, req)
+func (c *Client) Post(ctx context.Context, path string, body []byte, headers map[string]string, opts ...RequestOption) (*http.Response, error) {
+	return c.send(ctx, http.MethodPost, path, bytes.NewReader(body), headers, opts)
 }
-func (c *Client) Put(ctx context.Context, path string, body []byte, headers map[string]string) (*http.Response, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, c.options.baseURL+path, bytes.NewReader(body))
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	return c.Do(ctx, req)
+func (c *Client) Put(ctx context.Context, path string, body []byte, headers map[string]string, opts ...RequestOption) (*http.Response, error) {
+	return c.send(ctx, http.MethodPut, path, bytes.NewReader(body), headers, opts)
 }
-func (c *Client) Patch(ctx context.Context, path string, body []byte, headers map[string]string) (*http.Response, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPatch, c.options.baseURL+path, bytes.NewReader(body))
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	return c.Do(ctx, req)
+func (c *Client) Patch(ctx context.Context, path string, body []byte, headers map[string]string, opts ...RequestOption) (*http.Response, error) {
+	return c.send(ctx, http.MethodPatch, path, bytes.NewReader(body), headers, opts)
 }
-func (c *Client) Delete(ctx context.Context, path string, headers map[string]string) (*http.Response, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, c.options.baseURL+path, nil)
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	return c.Do(ctx, req)
+func (c *Client) Delete(ctx context.Context, path string, headers map[string]string, opts ...RequestOption) (*http.Response, error) {
+	return c.send(ctx, http.MethodDelete, path, nil, headers, opts)
+}
+func (c *Client) Head(ctx context.Context, path string, headers map[string]string, opts ...RequestOption) (*http.Response, error) {
+	return c.send(ctx, http.MethodHead, path, nil, headers, opts)
 }
-func (c *Client) Head(ctx context.Context, path string, headers map[string]string) (*http.Response, error) {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodHead, c.options.baseURL+path, nil)
+
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader, headers map[string]string, opts []RequestOption) (*http.Response, error) {
+	ctx = WithRequestOptions(ctx, opts...)
+	ctx, path, err := withRoute(ctx, path)
+	if err != nil {
+		return nil, &Error{Err: err, Method: method, URL: c.options.baseURL + path}
+	}
+	ro := requestOptionsFrom(ctx)
+	if ro != nil && ro.hasJSONBody {
+		data, err := json.Marshal(ro.jsonBody)
+		if err != nil {
+			return nil, &Error{Err: fmt.Errorf("encode request body: %w", err), Method: method, URL: c.options.baseURL + path}
+		}
+		body = bytes.NewReader(data)
+		if _, ok := headers["Content-Type"]; !ok {
+			headers = maps.Clone(headers)
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers["Content-Type"] = "application/json"
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.options.baseURL+path, body)
+	if err != nil {
+		return nil, &Error{Err: err, Method: method, URL: c.options.baseURL + path}
+	}
+	if ro != nil && len(ro.query) > 0 {
+		query := req.URL.Query()
+		for k, v := range ro.query {
+			query[k] = append(query[k], v...)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
@@ -270,9 +475,15 @@ func (c *Client) Head(ctx context.Context, path string, headers map[string]strin
 }
 
 func (c *Client) Close() {
-	if c.httpClient != nil {
-		if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
-			transport.CloseIdleConnections()
-		}
+	// Settle the lazy async queue so it cannot start after Close.
+	c.asyncOnce.Do(func() {})
+	if c.async != nil {
+		c.async.close()
+	}
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
+	if c.versions != nil {
+		c.versions.closeIdleConnections()
 	}
 }
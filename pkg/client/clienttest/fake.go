@@ -0,0 +1,277 @@
+// Package clienttest provides a programmable fake transport for code that
+// uses pkg/client, so tests can stub upstream responses and assert on the
+// calls that were made without running an HTTP server.
+//
+//	fake := clienttest.NewFake()
+//	fake.On(http.MethodGet, "/users/{id}").Return(200, map[string]any{"id": 1})
+//	c := client.NewClient(client.WithBaseURL("http://users"), client.WithTransport(fake))
+//	...
+//	fake.AssertCalled(t, http.MethodGet, "/users/1")
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Call is a request received by the fake.
+type Call struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Fake is an http.RoundTripper that answers requests from registered stubs.
+// Requests without a matching stub fail with a transport error.
+type Fake struct {
+	mu    sync.Mutex
+	stubs []*Stub
+	calls []Call
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// On registers a stub for method and path. Path segments written as {name}
+// or * match any single segment. Stubs are tried in registration order, so a
+// stub limited with Times can be followed by a fallback for the same route.
+func (f *Fake) On(method, path string) *Stub {
+	s := &Stub{method: method, path: path, status: http.StatusOK, header: http.Header{}}
+	f.mu.Lock()
+	f.stubs = append(f.stubs, s)
+	f.mu.Unlock()
+	return s
+}
+
+// Stub describes the response for a route. Its methods are meant to be
+// chained at registration time.
+type Stub struct {
+	method string
+	path   string
+
+	status int
+	header http.Header
+	body   []byte
+	err    error
+	delay  time.Duration
+	times  int
+	hits   int
+}
+
+// Return sets the status and body. A []byte or string body is sent as is;
+// anything else is encoded as JSON.
+func (s *Stub) Return(status int, body any) *Stub {
+	s.status = status
+	switch b := body.(type) {
+	case nil:
+		s.body = nil
+	case []byte:
+		s.body = b
+	case string:
+		s.body = []byte(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			panic(fmt.Sprintf("clienttest: encode stub body: %v", err))
+		}
+		s.body = data
+		if s.header.Get("Content-Type") == "" {
+			s.header.Set("Content-Type", "application/json")
+		}
+	}
+	return s
+}
+
+func (s *Stub) WithHeader(key, value string) *Stub {
+	s.header.Set(key, value)
+	return s
+}
+
+// ReturnError makes the transport fail with err instead of responding.
+func (s *Stub) ReturnError(err error) *Stub {
+	s.err = err
+	return s
+}
+
+// Delay waits d before answering, or until the request context is done.
+func (s *Stub) Delay(d time.Duration) *Stub {
+	s.delay = d
+	return s
+}
+
+// Times limits how many requests the stub answers; zero means unlimited.
+func (s *Stub) Times(n int) *Stub {
+	s.times = n
+	return s
+}
+
+func (s *Stub) matches(method, path string) bool {
+	if s.method != method || (s.times > 0 && s.hits >= s.times) {
+		return false
+	}
+	return matchPath(s.path, path)
+}
+
+func (f *Fake) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	var stub *Stub
+	for _, s := range f.stubs {
+		if s.matches(req.Method, req.URL.Path) {
+			s.hits++
+			stub = s
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if stub == nil {
+		return nil, fmt.Errorf("clienttest: no stub for %s %s", req.Method, req.URL.Path)
+	}
+	if stub.delay > 0 {
+		timer := time.NewTimer(stub.delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	if stub.err != nil {
+		return nil, stub.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", stub.status, http.StatusText(stub.status)),
+		StatusCode:    stub.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        stub.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(stub.body)),
+		ContentLength: int64(len(stub.body)),
+		Request:       req,
+	}, nil
+}
+
+// Calls returns the requests received so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallCount returns how many requests matched method and path, which may use
+// the same placeholders as On.
+func (f *Fake) CallCount(method, path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c.Method == method && matchPath(path, c.Path) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *Fake) AssertCalled(t testing.TB, method, path string) {
+	t.Helper()
+	if f.CallCount(method, path) == 0 {
+		t.Errorf("expected a call to %s %s, got %s", method, path, f.describeCalls())
+	}
+}
+
+func (f *Fake) AssertNotCalled(t testing.TB, method, path string) {
+	t.Helper()
+	if n := f.CallCount(method, path); n > 0 {
+		t.Errorf("expected no call to %s %s, got %d", method, path, n)
+	}
+}
+
+func (f *Fake) AssertCallCount(t testing.TB, method, path string, want int) {
+	t.Helper()
+	if got := f.CallCount(method, path); got != want {
+		t.Errorf("expected %d calls to %s %s, got %d", want, method, path, got)
+	}
+}
+
+// AssertExpectations fails if a stub was never used, or if a stub limited
+// with Times was used fewer times than expected.
+func (f *Fake) AssertExpectations(t testing.TB) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.stubs {
+		switch {
+		case s.times > 0 && s.hits < s.times:
+			t.Errorf("expected %d calls to %s %s, got %d", s.times, s.method, s.path, s.hits)
+		case s.hits == 0:
+			t.Errorf("expected a call to %s %s", s.method, s.path)
+		}
+	}
+}
+
+// Reset drops all stubs and recorded calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stubs = nil
+	f.calls = nil
+}
+
+func (f *Fake) describeCalls() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) == 0 {
+		return "no calls"
+	}
+	parts := make([]string, len(f.calls))
+	for i, c := range f.calls {
+		parts[i] = c.Method + " " + c.Path
+	}
+	return strings.Join(parts, ", ")
+}
+
+func matchPath(pattern, path string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, seg := range want {
+		if seg == "*" || (strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")) {
+			continue
+		}
+		if seg != got[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/client"
+)
+
+func newTestClient(fake *Fake) *client.Client {
+	return client.NewClient(
+		client.WithBaseURL("http://users"),
+		client.WithTransport(fake),
+		client.WithDefaultSettings(&client.EndpointSettings{
+			Timeout:     time.Second,
+			MaxRetries:  1,
+			ShouldRetry: func(*http.Response, error) bool { return false },
+			Headers:     map[string]string{},
+		}),
+	)
+}
+
+func TestFakeReturnsStubbedResponses(t *testing.T) {
+	fake := NewFake()
+	fake.On(http.MethodGet, "/users/{id}").Return(http.StatusOK, map[string]any{"id": 1})
+	fake.On(http.MethodPost, "/users").Return(http.StatusServiceUnavailable, "busy").Times(1)
+	fake.On(http.MethodPost, "/users").Return(http.StatusCreated, `{"id":2}`)
+	c := newTestClient(fake)
+
+	resp, err := c.Get(context.Background(), "/users/1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"id":1}` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response: %q %v", body, resp.Header)
+	}
+
+	if _, err := c.Post(context.Background(), "/users", []byte(`{"name":"Ada"}`), nil); err == nil {
+		t.Error("expected the first POST to fail with 503")
+	}
+	resp, err = c.Post(context.Background(), "/users", []byte(`{"name":"Ada"}`), nil)
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected the fallback stub to answer, got %v %v", resp, err)
+	}
+
+	fake.AssertCalled(t, http.MethodGet, "/users/1")
+	fake.AssertCallCount(t, http.MethodPost, "/users", 2)
+	fake.AssertNotCalled(t, http.MethodDelete, "/users/1")
+	fake.AssertExpectations(t)
+	if calls := fake.Calls(); string(calls[1].Body) != `{"name":"Ada"}` {
+		t.Errorf("expected the request body to be recorded, got %q", calls[1].Body)
+	}
+}
+
+func TestFakeInjectsErrorsAndLatency(t *testing.T) {
+	fake := NewFake()
+	boom := errors.New("connection reset")
+	fake.On(http.MethodGet, "/flaky").ReturnError(boom)
+	fake.On(http.MethodGet, "/slow").Delay(time.Second).Return(http.StatusOK, nil)
+	c := newTestClient(fake)
+
+	if _, err := c.Get(context.Background(), "/flaky", nil); !errors.Is(err, boom) {
+		t.Errorf("expected injected error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Get(ctx, "/slow", nil); err == nil {
+		t.Error("expected the delayed stub to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected delay to honour the context, took %v", elapsed)
+	}
+
+	if _, err := c.Get(context.Background(), "/unknown", nil); err == nil {
+		t.Error("expected an error for an unstubbed route")
+	}
+}
@@ -11,6 +11,9 @@ import (
 )
 
 type EndpointSettings struct {
+	// Name labels the endpoint in metrics, spans and logs instead of its
+	// path, e.g. "get_user_orders".
+	Name            string
 	Timeout         time.Duration
 	MaxRetries      int
 	ShouldRetry     func(resp *http.Response, err error) bool
@@ -19,17 +22,38 @@ type EndpointSettings struct {
 	RequireAuth     bool
 	RateLimiter     *rate.Limiter
 	// Deprecated: use WithCircuitBreaker middleware instead. This field is not used by Do().
-	Breaker *gobreaker.CircuitBreaker
+	Breaker         *gobreaker.CircuitBreaker
 	AuthTokenFn     func(*RequestInfo) (string, error)
 	EnableCache     bool
 	CacheTTL        time.Duration
 	Fallback        func(*http.Request, error) (*http.Response, error)
 	MaxResponseSize int64
+	// RetryNonIdempotent allows retrying POST/PATCH requests. Otherwise only
+	// idempotent methods or requests with an Idempotency-Key header are retried.
+	RetryNonIdempotent bool
+	// HedgeAfter fires an extra attempt when the previous one has not
+	// answered within this budget, up to MaxHedges extra attempts. The first
+	// successful response wins. Only applies to requests safe to repeat.
+	HedgeAfter time.Duration
+	MaxHedges  int
+	// Proxy overrides the client proxy for this endpoint with an http, https
+	// or socks5 URL. "direct" bypasses any proxy.
+	Proxy string
+	// ValidateResponse checks the contract of successful responses, e.g.
+	// required fields. A non-nil error fails the call with a
+	// *ValidationError matching ErrInvalidResponse.
+	ValidateResponse func(resp *http.Response, body []byte) error
+	// HTTPVersion overrides the client HTTP version for this endpoint.
+	HTTPVersion HTTPVersion
 }
 
-func applyDefaults(cfg *EndpointSettings) *EndpointSettings {
-	if cfg == nil {
-		cfg = &EndpointSettings{}
+// applyDefaults returns a copy of cfg with defaults filled in. It never
+// mutates cfg, which is shared by every concurrent request to the endpoint.
+func applyDefaults(settings *EndpointSettings) *EndpointSettings {
+	cfg := &EndpointSettings{}
+	if settings != nil {
+		copied := *settings
+		cfg = &copied
 	}
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 10 * time.Second
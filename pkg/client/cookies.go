@@ -0,0 +1,24 @@
+package client
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// WithCookieJar stores and replays cookies across calls and redirects, for
+// upstreams relying on session cookies.
+func WithCookieJar(jar http.CookieJar) func(*options) {
+	return func(o *options) { o.cookieJar = jar }
+}
+
+// WithInMemoryCookieJar keeps cookies in a jar owned by the client.
+func WithInMemoryCookieJar() func(*options) {
+	return func(o *options) {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			o.configErr = err
+			return
+		}
+		o.cookieJar = jar
+	}
+}
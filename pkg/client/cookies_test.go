@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithInMemoryCookieJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/me", http.StatusFound)
+		case "/me":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithInMemoryCookieJar(),
+	)
+	resp, err := c.Post(context.Background(), "/login", nil, nil)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected cookie to follow the redirect, got %d", resp.StatusCode)
+	}
+	resp, err = c.Get(context.Background(), "/me", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected session cookie on subsequent call, got %d", resp.StatusCode)
+	}
+}
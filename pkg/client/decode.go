@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned by DoInto when the upstream answers with a non-2xx
+// status. Body holds the raw payload and Decoded holds it parsed as a JSON
+// object when possible, so callers can inspect error codes without string
+// matching on the raw bytes.
+type APIError struct {
+	Status  int
+	Body    []byte
+	Decoded map[string]any
+	Err     error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("[HTTP] api error: status=%d", e.Status)
+	if len(e.Body) > 0 {
+		msg += fmt.Sprintf(", body=%s", string(e.Body))
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+// Is reports whether the error belongs to the target category.
+func (e *APIError) Is(target error) bool {
+	return matchesCategory(target, e.Status, nil)
+}
+
+// Field returns a top-level value from the decoded error body.
+func (e *APIError) Field(key string) (any, bool) {
+	if e.Decoded == nil {
+		return nil, false
+	}
+	v, ok := e.Decoded[key]
+	return v, ok
+}
+
+// DoInto executes req through Do and decodes a 2xx JSON body into out.
+// Non-2xx responses are returned as *APIError; transport failures are
+// returned unchanged as *Error. A nil out discards the body.
+func (c *Client) DoInto(ctx context.Context, req *http.Request, out any) error {
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		var cErr *Error
+		if errors.As(err, &cErr) && cErr.StatusCode != 0 {
+			return newAPIError(cErr.StatusCode, cErr.Body, cErr)
+		}
+		return err
+	}
+	if resp == nil {
+		return nil
+	}
+	body, err := readAndRestoreBody(resp)
+	if err != nil {
+		return &Error{Err: err, Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp.StatusCode, body, nil)
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Body:       body,
+			Err:        decodingError(err),
+			Method:     req.Method,
+			URL:        req.URL.String(),
+		}
+	}
+	return nil
+}
+
+func newAPIError(status int, body []byte, cause error) *APIError {
+	apiErr := &APIError{Status: status, Body: body, Err: cause}
+	if len(body) > 0 {
+		var decoded map[string]any
+		if json.Unmarshal(body, &decoded) == nil {
+			apiErr.Decoded = decoded
+		}
+	}
+	return apiErr
+}
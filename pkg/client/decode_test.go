@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoInto(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(`{"id":"u1","name":"Ana"}`))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"not_found","message":"user not found"}`))
+		case "/plain":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("bad request"))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+	ctx := context.Background()
+
+	t.Run("decodes success body", func(t *testing.T) {
+		var out struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/ok", nil)
+		if err := c.DoInto(ctx, req, &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.ID != "u1" || out.Name != "Ana" {
+			t.Errorf("unexpected decoded value: %+v", out)
+		}
+	})
+
+	t.Run("captures structured error body", func(t *testing.T) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/missing", nil)
+		err := c.DoInto(ctx, req, nil)
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("expected *APIError, got %T: %v", err, err)
+		}
+		if apiErr.Status != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", apiErr.Status)
+		}
+		if code, _ := apiErr.Field("code"); code != "not_found" {
+			t.Errorf("expected code=not_found, got %v", code)
+		}
+		var cErr *Error
+		if !errors.As(err, &cErr) {
+			t.Error("expected APIError to unwrap to *Error")
+		}
+	})
+
+	t.Run("keeps raw body when not JSON", func(t *testing.T) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/plain", nil)
+		err := c.DoInto(ctx, req, nil)
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("expected *APIError, got %T", err)
+		}
+		if apiErr.Decoded != nil {
+			t.Errorf("expected nil Decoded for non-JSON body, got %v", apiErr.Decoded)
+		}
+		if string(apiErr.Body) != "bad request" {
+			t.Errorf("unexpected body %q", apiErr.Body)
+		}
+	})
+}
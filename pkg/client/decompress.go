@@ -0,0 +1,83 @@
+package client
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// WithDecompression installs DecompressionMiddleware next to the transport,
+// so every middleware, including the cache, sees plain bodies.
+func WithDecompression() func(*options) {
+	return func(o *options) { o.decompress = true }
+}
+
+// DecompressionMiddleware advertises gzip, br and deflate and transparently
+// decodes compressed responses. The Content-Encoding and Content-Length
+// headers are removed since they no longer describe the body;
+// resp.ContentLength is set to -1 (unknown) and resp.Uncompressed to true,
+// as net/http does for the gzip it decodes itself.
+func DecompressionMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Accept-Encoding") == "" {
+				req.Header.Set("Accept-Encoding", "gzip, br, deflate")
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp == nil || resp.Body == nil {
+				return resp, err
+			}
+			encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+			var open func(io.Reader) (io.ReadCloser, error)
+			switch encoding {
+			case "gzip", "x-gzip":
+				open = func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+			case "br":
+				open = func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(brotli.NewReader(r)), nil }
+			case "deflate":
+				open = zlib.NewReader
+			default:
+				return resp, nil
+			}
+			if req.Method == http.MethodHead || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+				return resp, nil
+			}
+			resp.Body = &decompressingBody{raw: resp.Body, open: open}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			resp.Uncompressed = true
+			return resp, nil
+		})
+	}
+}
+
+// decompressingBody creates the decoder on first read, since decoders read
+// the stream header eagerly.
+type decompressingBody struct {
+	raw     io.ReadCloser
+	open    func(io.Reader) (io.ReadCloser, error)
+	decoder io.ReadCloser
+	err     error
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	if b.decoder == nil && b.err == nil {
+		b.decoder, b.err = b.open(b.raw)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.decoder.Read(p)
+}
+
+func (b *decompressingBody) Close() error {
+	if b.decoder != nil {
+		_ = b.decoder.Close()
+	}
+	return b.raw.Close()
+}
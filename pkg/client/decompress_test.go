@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestDecompressionMiddleware(t *testing.T) {
+	const payload = `{"message":"hello"}`
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+	for encoding, newWriter := range encoders {
+		t.Run(encoding, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var buf bytes.Buffer
+				zw := newWriter(&buf)
+				_, _ = zw.Write([]byte(payload))
+				_ = zw.Close()
+				w.Header().Set("Content-Encoding", encoding)
+				_, _ = w.Write(buf.Bytes())
+			}))
+			defer srv.Close()
+
+			c := NewClient(
+				WithBaseURL(srv.URL),
+				WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+				WithDecompression(),
+			)
+			var out struct {
+				Message string `json:"message"`
+			}
+			if err := c.DoInto(context.Background(), mustRequest(t, srv.URL), &out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.Message != "hello" {
+				t.Errorf("expected decoded body, got %+v", out)
+			}
+		})
+	}
+}
+
+func TestDecompressionMiddlewarePassesPlainBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip, br, deflate" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("plain"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithDecompression(),
+	)
+	resp, err := c.Get(context.Background(), "/", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := io.ReadAll(resp.Body); string(b) != "plain" {
+		t.Errorf("expected plain body untouched, got %q", b)
+	}
+}
+
+func TestDecompressionMiddlewareResetsLength(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte("hello"))
+	_ = zw.Close()
+	compressed := buf.Len()
+
+	rt := DecompressionMiddleware()(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{"Content-Encoding": {"gzip"}, "Content-Length": {strconv.Itoa(compressed)}},
+			ContentLength: int64(compressed),
+			Body:          io.NopCloser(&buf),
+		}, nil
+	}))
+	resp, err := rt.RoundTrip(mustRequest(t, "http://example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ContentLength != -1 || resp.Header.Get("Content-Length") != "" || !resp.Uncompressed {
+		t.Errorf("expected an unknown length, got %d %q (uncompressed %v)", resp.ContentLength, resp.Header.Get("Content-Length"), resp.Uncompressed)
+	}
+	if b, _ := io.ReadAll(resp.Body); string(b) != "hello" {
+		t.Errorf("expected decoded body, got %q", b)
+	}
+}
+
+func mustRequest(t *testing.T, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
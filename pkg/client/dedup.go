@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type DedupConfig struct {
+	Methods []string
+	KeyFunc func(r *http.Request) string
+}
+
+// DedupMiddleware coalesces identical concurrent requests so only one reaches
+// the upstream and every caller receives its own copy of the shared response.
+// By default GET and HEAD requests are keyed by method, URL and the
+// credential and Accept headers, so callers with different credentials or
+// asking for another representation never share a response.
+// config is copied, so the caller's value is left untouched.
+func DedupMiddleware(cfg *DedupConfig) Middleware {
+	config := &DedupConfig{}
+	if cfg != nil {
+		copied := *cfg
+		config = &copied
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = defaultDedupKey
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &dedupTransport{next: next, config: config, calls: map[string]*dedupCall{}}
+	}
+}
+
+type dedupCall struct {
+	done   chan struct{}
+	status string
+	code   int
+	header http.Header
+	body   []byte
+	err    error
+}
+
+type dedupTransport struct {
+	next   http.RoundTripper
+	config *DedupConfig
+	mu     sync.Mutex
+	calls  map[string]*dedupCall
+}
+
+func (t *dedupTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !containsString(t.config.Methods, req.Method) {
+		return t.next.RoundTrip(req)
+	}
+	key := t.config.KeyFunc(req)
+
+	t.mu.Lock()
+	if call, ok := t.calls[key]; ok {
+		t.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if call.err != nil && isContextError(call.err) && req.Context().Err() == nil {
+			// The leader was cancelled by its own caller; do not propagate
+			// that to callers that are still waiting.
+			return t.next.RoundTrip(req)
+		}
+		return call.response(req)
+	}
+	call := &dedupCall{done: make(chan struct{})}
+	t.calls[key] = call
+	t.mu.Unlock()
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		call.status, call.code, call.header = resp.Status, resp.StatusCode, resp.Header
+		call.body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	call.err = err
+
+	t.mu.Lock()
+	delete(t.calls, key)
+	t.mu.Unlock()
+	close(call.done)
+
+	return call.response(req)
+}
+
+func (c *dedupCall) response(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &http.Response{
+		Status:        c.status,
+		StatusCode:    c.code,
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}, nil
+}
+
+// dedupKeyHeaders are the request headers the response may vary on.
+var dedupKeyHeaders = []string{"Authorization", "Cookie", "X-Auth-App-Token", "X-Api-Key", "Accept"}
+
+func defaultDedupKey(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Method + ":" + r.URL.String())
+	for _, name := range dedupKeyHeaders {
+		// Header values cannot hold newlines, so keys are unambiguous.
+		b.WriteString("\n" + strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedupMiddlewareCoalescesConcurrentGets(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, _ = w.Write([]byte("shared"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithDedup(nil),
+	)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	bodies := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := c.Get(context.Background(), "/config", nil)
+			if err != nil {
+				t.Errorf("caller %d: unexpected error: %v", i, err)
+				return
+			}
+			b, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(b)
+		}(i)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected 1 upstream call, got %d", got)
+	}
+	for i, b := range bodies {
+		if b != "shared" {
+			t.Errorf("caller %d: expected shared body, got %q", i, b)
+		}
+	}
+}
+
+func TestDedupMiddlewareKeepsDistinctCredentialsApart(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithDedup(nil),
+	)
+
+	var wg sync.WaitGroup
+	for _, token := range []string{"Bearer a", "Bearer b"} {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			resp, err := c.Get(context.Background(), "/me", map[string]string{"Authorization": token})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if b, _ := io.ReadAll(resp.Body); string(b) != token {
+				t.Errorf("expected response for %q, got %q", token, b)
+			}
+		}(token)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", got)
+	}
+}
+
+func TestDedupMiddlewareKeepsDistinctCookiesApart(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(r.Header.Get("Cookie")))
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithDedup(nil),
+	)
+
+	var wg sync.WaitGroup
+	for _, cookie := range []string{"session=alice", "session=bob"} {
+		wg.Add(1)
+		go func(cookie string) {
+			defer wg.Done()
+			resp, err := c.Get(context.Background(), "/me", map[string]string{"Cookie": cookie})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if b, _ := io.ReadAll(resp.Body); string(b) != cookie {
+				t.Errorf("expected response for %q, got %q", cookie, b)
+			}
+		}(cookie)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", got)
+	}
+}
+
+func TestDedupMiddlewareLeavesConfigUntouched(t *testing.T) {
+	cfg := &DedupConfig{}
+	_ = DedupMiddleware(cfg)
+	if cfg.Methods != nil || cfg.KeyFunc != nil {
+		t.Error("expected defaults not to be written into the caller's config")
+	}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+var ErrNoEndpoints = errors.New("client: no endpoints available")
+
+// discoveryRefresh is how long resolved endpoints are reused.
+const discoveryRefresh = 30 * time.Second
+
+// Endpoint is one instance of a discovered service.
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+// Resolver finds the instances backing a service name.
+type Resolver interface {
+	Resolve(ctx context.Context, serviceName string) ([]Endpoint, error)
+}
+
+// StaticResolver serves a fixed endpoint list per service, for tests and
+// environments without a registry.
+type StaticResolver map[string][]Endpoint
+
+func (r StaticResolver) Resolve(_ context.Context, serviceName string) ([]Endpoint, error) {
+	endpoints, ok := r[serviceName]
+	if !ok || len(endpoints) == 0 {
+		return nil, fmt.Errorf("%w for service %q", ErrNoEndpoints, serviceName)
+	}
+	return endpoints, nil
+}
+
+// ConsulResolver returns the passing instances of a service from the Consul
+// health API.
+type ConsulResolver struct {
+	// Address of the Consul agent. Defaults to CONSUL_HTTP_ADDR or http://127.0.0.1:8500.
+	Address    string
+	Datacenter string
+	Tag        string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func (r *ConsulResolver) Resolve(ctx context.Context, serviceName string) ([]Endpoint, error) {
+	addr := r.Address
+	if addr == "" {
+		addr = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if addr == "" {
+		addr = "http://127.0.0.1:8500"
+	}
+	query := url.Values{"passing": {"true"}}
+	if r.Datacenter != "" {
+		query.Set("dc", r.Datacenter)
+	}
+	if r.Tag != "" {
+		query.Set("tag", r.Tag)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		addr+"/v1/health/service/"+url.PathEscape(serviceName)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.Token != "" {
+		req.Header.Set("X-Consul-Token", r.Token)
+	}
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("consul: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul: unexpected status %d", resp.StatusCode)
+	}
+
+	var entries []struct {
+		Node    struct{ Address string }
+		Service struct {
+			Address string
+			Port    int
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("consul: decode response: %w", err)
+	}
+	endpoints := make([]Endpoint, 0, len(entries))
+	for _, e := range entries {
+		host := e.Service.Address
+		if host == "" {
+			host = e.Node.Address
+		}
+		endpoints = append(endpoints, Endpoint{Host: host, Port: e.Service.Port})
+	}
+	return endpoints, nil
+}
+
+// KubernetesResolver discovers pods behind a headless Service through cluster
+// DNS. With PortName set it uses the SRV record of that port; otherwise it
+// resolves the Service name and uses Port.
+type KubernetesResolver struct {
+	// Namespace defaults to POD_NAMESPACE, then "default".
+	Namespace     string
+	PortName      string
+	Port          int
+	ClusterDomain string
+	Resolver      *net.Resolver
+}
+
+func (r *KubernetesResolver) Resolve(ctx context.Context, serviceName string) ([]Endpoint, error) {
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	domain := r.ClusterDomain
+	if domain == "" {
+		domain = "cluster.local"
+	}
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	name := serviceName + "." + namespace + ".svc." + domain
+
+	if r.PortName != "" {
+		_, records, err := resolver.LookupSRV(ctx, r.PortName, "tcp", name)
+		if err != nil {
+			return nil, err
+		}
+		endpoints := make([]Endpoint, 0, len(records))
+		for _, srv := range records {
+			endpoints = append(endpoints, Endpoint{Host: srv.Target, Port: int(srv.Port)})
+		}
+		return endpoints, nil
+	}
+
+	port := r.Port
+	if port == 0 {
+		port = 80
+	}
+	addrs, err := resolver.LookupHost(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]Endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		endpoints = append(endpoints, Endpoint{Host: addr, Port: port})
+	}
+	return endpoints, nil
+}
+
+// WithResolver sets the resolver used by WithService. Defaults to KubernetesResolver.
+func WithResolver(r Resolver) func(*options) {
+	return func(o *options) { o.resolver = r }
+}
+
+// WithService targets a discovered service instead of a fixed base URL. Paths
+// are built against http://<serviceName> and sent round-robin to the resolved
+// instances, which are refreshed every 30 seconds.
+func WithService(serviceName string) func(*options) {
+	return func(o *options) {
+		primary := &url.URL{Scheme: "http", Host: serviceName}
+		b := &balancer{strategy: RoundRobin(), primary: primary}
+		b.discover = func(ctx context.Context) ([]*url.URL, error) {
+			resolver := o.resolver
+			if resolver == nil {
+				resolver = &KubernetesResolver{}
+			}
+			endpoints, err := resolver.Resolve(ctx, serviceName)
+			if err != nil {
+				return nil, err
+			}
+			urls := make([]*url.URL, 0, len(endpoints))
+			for _, e := range endpoints {
+				urls = append(urls, &url.URL{Scheme: "http", Host: net.JoinHostPort(e.Host, strconv.Itoa(e.Port))})
+			}
+			return urls, nil
+		}
+		o.baseURL = primary.String()
+		o.middlewares = append(o.middlewares, b.middleware)
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func endpointOf(t *testing.T, srv *httptest.Server) Endpoint {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return Endpoint{Host: host, Port: p}
+}
+
+func TestWithServiceStaticResolver(t *testing.T) {
+	var a, b int32
+	srvA := newCountingServer(http.StatusOK, &a)
+	defer srvA.Close()
+	srvB := newCountingServer(http.StatusOK, &b)
+	defer srvB.Close()
+
+	c := NewClient(
+		WithService("users"),
+		WithResolver(StaticResolver{"users": {endpointOf(t, srvA), endpointOf(t, srvB)}}),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, Headers: map[string]string{}}),
+	)
+	for i := 0; i < 4; i++ {
+		resp, err := c.Get(context.Background(), "/api/users", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		resp.Body.Close()
+	}
+	if atomic.LoadInt32(&a) != 2 || atomic.LoadInt32(&b) != 2 {
+		t.Errorf("expected requests spread across instances, got %d/%d", a, b)
+	}
+}
+
+func TestWithServiceNoEndpoints(t *testing.T) {
+	c := NewClient(
+		WithService("ghost"),
+		WithResolver(StaticResolver{}),
+		WithDefaultSettings(&EndpointSettings{Timeout: time.Second, Headers: map[string]string{}}),
+	)
+	if _, err := c.Get(context.Background(), "/", nil); !errors.Is(err, ErrNoEndpoints) {
+		t.Errorf("expected ErrNoEndpoints, got %v", err)
+	}
+}
+
+func TestConsulResolver(t *testing.T) {
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/health/service/users" || r.URL.Query().Get("passing") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `[
+			{"Node":{"Address":"10.0.0.1"},"Service":{"Address":"","Port":8080}},
+			{"Node":{"Address":"10.0.0.2"},"Service":{"Address":"10.1.0.2","Port":9090}}
+		]`)
+	}))
+	defer consul.Close()
+
+	endpoints, err := (&ConsulResolver{Address: consul.URL}).Resolve(context.Background(), "users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Endpoint{{Host: "10.0.0.1", Port: 8080}, {Host: "10.1.0.2", Port: 9090}}
+	if len(endpoints) != len(want) || endpoints[0] != want[0] || endpoints[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, endpoints)
+	}
+}
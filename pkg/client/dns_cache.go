@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+// DNSCacheConfig configures the caching resolver used by the dialer. Lookups
+// are reused for TTL; when a refresh fails, the previous answer keeps being
+// served for up to StaleTTL so resolver blips do not fail requests.
+type DNSCacheConfig struct {
+	TTL      time.Duration
+	StaleTTL time.Duration
+	// Resolver defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+func WithDNSCache(cfg *DNSCacheConfig) func(*options) {
+	return func(o *options) {
+		if cfg == nil {
+			cfg = &DNSCacheConfig{}
+		}
+		o.dnsCache = newDNSCache(cfg)
+	}
+}
+
+type dnsEntry struct {
+	addrs    []string
+	resolved time.Time
+}
+
+type dnsCache struct {
+	ttl      time.Duration
+	staleTTL time.Duration
+	lookup   func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+func newDNSCache(cfg *DNSCacheConfig) *dnsCache {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	staleTTL := cfg.StaleTTL
+	if staleTTL <= 0 {
+		staleTTL = 5 * time.Minute
+	}
+	resolver := cfg.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &dnsCache{
+		ttl:      ttl,
+		staleTTL: staleTTL,
+		lookup:   resolver.LookupHost,
+		entries:  map[string]dnsEntry{},
+	}
+}
+
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	age := time.Since(entry.resolved)
+	if ok && age < c.ttl {
+		return entry.addrs, nil
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err == nil && len(addrs) > 0 {
+		c.mu.Lock()
+		c.entries[host] = dnsEntry{addrs: addrs, resolved: time.Now()}
+		c.mu.Unlock()
+		return addrs, nil
+	}
+	if ok && age < c.ttl+c.staleTTL {
+		logs.Warn(ctx, "dns lookup failed, serving stale addresses", "host", host, "error", err)
+		return entry.addrs, nil
+	}
+	if err == nil {
+		err = &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+	}
+	return nil, err
+}
+
+func (c *dnsCache) dial(next dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return next(ctx, network, addr)
+		}
+		addrs, err := c.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error
+		for _, ip := range addrs {
+			conn, err := next(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, lastErr
+	}
+}
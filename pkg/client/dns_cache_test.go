@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDNSCacheResolve(t *testing.T) {
+	calls := 0
+	fail := false
+	c := newDNSCache(&DNSCacheConfig{TTL: 20 * time.Millisecond, StaleTTL: time.Second})
+	c.lookup = func(ctx context.Context, host string) ([]string, error) {
+		calls++
+		if fail {
+			return nil, errors.New("resolver unavailable")
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.resolve(ctx, "users.svc"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected a single lookup within TTL, got %d", calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	fail = true
+	addrs, err := c.resolve(ctx, "users.svc")
+	if err != nil || len(addrs) != 1 || addrs[0] != "10.0.0.1" {
+		t.Errorf("expected stale addresses on resolver failure, got %v, %v", addrs, err)
+	}
+	if _, err := c.resolve(ctx, "orders.svc"); err == nil {
+		t.Error("expected error for a host never resolved")
+	}
+}
+
+func TestWithDNSCacheDialsResolvedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	c := NewClient(
+		WithBaseURL("http://users.internal:"+port),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithDNSCache(nil),
+	)
+	c.options.dnsCache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		if host != "users.internal" {
+			return nil, errors.New("unexpected host " + host)
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	resp, err := c.Get(context.Background(), "/", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+}
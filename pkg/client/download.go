@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type downloadOptions struct {
+	headers       map[string]string
+	progress      ProgressFunc
+	offset        int64
+	resumeRetries int
+}
+
+type DownloadOption func(*downloadOptions)
+
+// WithDownloadHeaders sets extra request headers for a download.
+func WithDownloadHeaders(headers map[string]string) DownloadOption {
+	return func(o *downloadOptions) { o.headers = headers }
+}
+
+// WithDownloadProgress registers a callback invoked as bytes are written.
+// transferred includes the resume offset so it can drive a progress bar for
+// the whole file.
+func WithDownloadProgress(fn ProgressFunc) DownloadOption {
+	return func(o *downloadOptions) { o.progress = fn }
+}
+
+// WithResumeFrom starts the download at offset using a Range request, e.g.
+// to continue a partially written file.
+func WithResumeFrom(offset int64) DownloadOption {
+	return func(o *downloadOptions) {
+		if offset > 0 {
+			o.offset = offset
+		}
+	}
+}
+
+// WithResumeRetries re-issues a Range request from the last written byte up
+// to n times when the connection drops mid-stream.
+func WithResumeRetries(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		if n > 0 {
+			o.resumeRetries = n
+		}
+	}
+}
+
+// Download streams the response body of a GET to w without buffering it in
+// memory and returns the number of bytes written. The endpoint Timeout bounds
+// the whole transfer, so configure a suitable one for large files.
+func (c *Client) Download(ctx context.Context, path string, w io.Writer, opts ...DownloadOption) (int64, error) {
+	o := &downloadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var written int64
+	for attempt := 0; ; attempt++ {
+		start := o.offset + written
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.options.baseURL+path, nil)
+		if err != nil {
+			return written, &Error{Err: err, Method: http.MethodGet, URL: c.options.baseURL + path}
+		}
+		for k, v := range o.headers {
+			req.Header.Set(k, v)
+		}
+		if start > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+		}
+
+		var streamErr error
+		_, err = c.do(ctx, req, func(resp *http.Response) error {
+			n, err := copyDownload(w, resp, start, o.progress)
+			written += n
+			streamErr = err
+			return err
+		})
+		if err == nil {
+			return written, nil
+		}
+		var cErr *Error
+		if start > 0 && errors.As(err, &cErr) && cErr.StatusCode == http.StatusRequestedRangeNotSatisfiable &&
+			rangeEndsAt(cErr.LastResponse, start) {
+			// Nothing left to fetch: the resume offset is already at the end.
+			return written, nil
+		}
+		if streamErr == nil || attempt >= o.resumeRetries || ctx.Err() != nil {
+			return written, err
+		}
+	}
+}
+
+// rangeEndsAt reports whether a 416 response carries "Content-Range:
+// bytes */<size>" with size equal to offset, i.e. the local copy is already
+// complete rather than longer than, or unrelated to, the remote file.
+func rangeEndsAt(resp *http.Response, offset int64) bool {
+	if resp == nil {
+		return false
+	}
+	size, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes */")
+	if !ok {
+		return false
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	return err == nil && n == offset
+}
+
+func copyDownload(w io.Writer, resp *http.Response, start int64, progress ProgressFunc) (int64, error) {
+	total := int64(-1)
+	if start > 0 && resp.StatusCode != http.StatusPartialContent {
+		// The server ignored the Range header and sent the full body; skip
+		// what was already written.
+		if _, err := io.CopyN(io.Discard, resp.Body, start); err != nil {
+			return 0, fmt.Errorf("skip %d already downloaded bytes: %w", start, err)
+		}
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	} else if resp.ContentLength >= 0 {
+		total = start + resp.ContentLength
+	}
+
+	var src io.Reader = resp.Body
+	if progress != nil {
+		src = &progressReader{r: resp.Body, transferred: start, total: total, fn: progress}
+	}
+	return io.Copy(w, src)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const downloadPayload = "0123456789abcdefghij"
+
+func newDownloadClient(url string) *Client {
+	return NewClient(
+		WithBaseURL(url),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+}
+
+func TestDownloadStreamsBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(downloadPayload))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	var lastTransferred, lastTotal int64
+	n, err := newDownloadClient(srv.URL).Download(context.Background(), "/file", &buf,
+		WithDownloadProgress(func(transferred, total int64) {
+			lastTransferred, lastTotal = transferred, total
+		}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(len(downloadPayload)) || buf.String() != downloadPayload {
+		t.Errorf("unexpected download: n=%d body=%q", n, buf.String())
+	}
+	if lastTransferred != int64(len(downloadPayload)) || lastTotal != int64(len(downloadPayload)) {
+		t.Errorf("unexpected progress: transferred=%d total=%d", lastTransferred, lastTotal)
+	}
+}
+
+func TestDownloadResumeFromOffset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(downloadPayload))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	_, err := newDownloadClient(srv.URL).Download(context.Background(), "/file", &buf, WithResumeFrom(15))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != downloadPayload[15:] {
+		t.Errorf("expected %q, got %q", downloadPayload[15:], buf.String())
+	}
+}
+
+func TestDownloadResumeAtEnd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(downloadPayload))
+	}))
+	defer srv.Close()
+	c := newDownloadClient(srv.URL)
+
+	var buf bytes.Buffer
+	if _, err := c.Download(context.Background(), "/file", &buf, WithResumeFrom(int64(len(downloadPayload)))); err != nil {
+		t.Errorf("expected a complete file to need nothing, got %v", err)
+	}
+	if _, err := c.Download(context.Background(), "/file", &buf, WithResumeFrom(int64(len(downloadPayload))+5)); err == nil {
+		t.Error("expected an error for an offset past the remote size")
+	}
+}
+
+func TestDownloadResumesAfterDroppedConnection(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Content-Length", "20")
+			_, _ = w.Write([]byte(downloadPayload[:8]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(downloadPayload))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	n, err := newDownloadClient(srv.URL).Download(context.Background(), "/file", &buf, WithResumeRetries(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != downloadPayload || n != int64(len(downloadPayload)) {
+		t.Errorf("expected full payload after resume, got n=%d body=%q", n, buf.String())
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+}
+
+func TestDownloadErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	_, err := newDownloadClient(srv.URL).Download(context.Background(), "/missing", &buf)
+	if err == nil {
+		t.Fatal("expected error for 404")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", buf.String())
+	}
+}
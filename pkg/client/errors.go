@@ -1,8 +1,28 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+
+	"github.com/sony/gobreaker"
+)
+
+// Error categories. Errors returned by the client match them with errors.Is
+// while still unwrapping to their cause, e.g.
+//
+//	if errors.Is(err, client.ErrRateLimited) { ... }
+var (
+	ErrTimeout     = errors.New("client: request timed out")
+	ErrCircuitOpen = errors.New("client: circuit breaker is open")
+	ErrRateLimited = errors.New("client: rate limited")
+	ErrServer      = errors.New("client: server error")
+	ErrClient      = errors.New("client: client error")
+	ErrDecoding    = errors.New("client: cannot decode response")
+	// ErrInvalidResponse marks responses rejected by ValidateResponse.
+	ErrInvalidResponse = errors.New("client: response violates contract")
 )
 
 type Error struct {
@@ -22,3 +42,42 @@ func (e *Error) Error() string {
 	}
 	return msg
 }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is reports whether the error belongs to the target category.
+func (e *Error) Is(target error) bool {
+	return matchesCategory(target, e.StatusCode, e.Err)
+}
+
+func matchesCategory(target error, status int, cause error) bool {
+	switch target {
+	case ErrTimeout:
+		var netErr net.Error
+		return errors.Is(cause, context.DeadlineExceeded) || (errors.As(cause, &netErr) && netErr.Timeout())
+	case ErrCircuitOpen:
+		return errors.Is(cause, gobreaker.ErrOpenState) || errors.Is(cause, gobreaker.ErrTooManyRequests)
+	case ErrRateLimited:
+		return status == http.StatusTooManyRequests
+	case ErrServer:
+		return status >= 500
+	case ErrClient:
+		return status >= 400 && status < 500
+	}
+	return false
+}
+
+// ValidationError wraps the error returned by EndpointSettings.ValidateResponse.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string { return "invalid response: " + e.Err.Error() }
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+func (e *ValidationError) Is(target error) bool { return target == ErrInvalidResponse }
+
+func decodingError(err error) error {
+	return fmt.Errorf("%w: %w", ErrDecoding, err)
+}
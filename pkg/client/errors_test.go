@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+func noRetrySettings() *EndpointSettings {
+	return &EndpointSettings{
+		Timeout:     time.Second,
+		ShouldRetry: func(*http.Response, error) bool { return false },
+		Headers:     map[string]string{},
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/throttled":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/garbage":
+			_, _ = w.Write([]byte("not json"))
+		}
+	}))
+	defer srv.Close()
+	settings := noRetrySettings()
+	settings.Timeout = 50 * time.Millisecond
+	c := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(settings))
+	ctx := context.Background()
+
+	cases := []struct {
+		path     string
+		want     error
+		excluded []error
+	}{
+		{"/slow", ErrTimeout, []error{ErrServer, ErrClient}},
+		{"/throttled", ErrRateLimited, []error{ErrServer}},
+		{"/missing", ErrClient, []error{ErrRateLimited, ErrServer}},
+		{"/broken", ErrServer, []error{ErrClient, ErrTimeout}},
+	}
+	for _, tc := range cases {
+		_, err := c.Get(ctx, tc.path, nil)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.path, tc.want, err)
+		}
+		for _, other := range tc.excluded {
+			if errors.Is(err, other) {
+				t.Errorf("%s: did not expect %v", tc.path, other)
+			}
+		}
+		var cErr *Error
+		if !errors.As(err, &cErr) {
+			t.Errorf("%s: expected *Error, got %T", tc.path, err)
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/missing", nil)
+	if err := c.DoInto(ctx, req, nil); !errors.Is(err, ErrClient) {
+		t.Errorf("expected APIError to match ErrClient, got %v", err)
+	}
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/garbage", nil)
+	var out map[string]any
+	if err := c.DoInto(ctx, req, &out); !errors.Is(err, ErrDecoding) {
+		t.Errorf("expected ErrDecoding, got %v", err)
+	}
+}
+
+func TestErrorCircuitOpen(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		ReadyToTrip: func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 1 },
+		Timeout:     time.Minute,
+	})
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(noRetrySettings()),
+		WithCircuitBreaker(&CircuitBreakerConfig{BreakerFor: func(string, string) *gobreaker.CircuitBreaker { return breaker }}),
+	)
+	_, _ = c.Get(context.Background(), "/", nil)
+	_, err := c.Get(context.Background(), "/", nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestFallbackWrappedClientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	settings := noRetrySettings()
+	settings.Fallback = func(req *http.Request, err error) (*http.Response, error) {
+		return nil, fmt.Errorf("fallback failed: %w", &Error{StatusCode: http.StatusBadGateway, Method: req.Method, URL: req.URL.String()})
+	}
+	c := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(settings))
+
+	_, err := c.Get(context.Background(), "/", nil)
+	var cErr *Error
+	if !errors.As(err, &cErr) || cErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected the wrapped *Error from the fallback, got %v", err)
+	}
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("expected ErrServer, got %v", err)
+	}
+}
+
+func TestJSONBody(t *testing.T) {
+	var gotBody, gotType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody, gotType = string(b), r.Header.Get("Content-Type")
+	}))
+	defer srv.Close()
+	c := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(noRetrySettings()))
+
+	if _, err := c.Post(context.Background(), "/users", nil, nil, JSONBody(map[string]string{"name": "Ada"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotBody != `{"name":"Ada"}` || gotType != "application/json" {
+		t.Errorf("unexpected request: body=%q content-type=%q", gotBody, gotType)
+	}
+
+	_, err := c.Post(context.Background(), "/users", nil, nil, JSONBody(make(chan int)))
+	if err == nil {
+		t.Error("expected an encoding error")
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// FallbackFunc produces a response when a request fails after its retries.
+// The error is the transport error or, for HTTP failures, the *Error
+// carrying the status and body. It can be assigned to EndpointSettings.Fallback.
+type FallbackFunc func(*http.Request, error) (*http.Response, error)
+
+// FallbackChain tries each fallback in order and returns the first
+// successful response. When all of them fail their errors are joined.
+func FallbackChain(fallbacks ...FallbackFunc) FallbackFunc {
+	return func(req *http.Request, cause error) (*http.Response, error) {
+		errs := make([]error, 0, len(fallbacks))
+		for _, fb := range fallbacks {
+			resp, err := fb(req, cause)
+			if err == nil {
+				return resp, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+// SecondaryBaseURLFallback resends the request to the scheme and host of
+// baseURL, keeping its path, query and headers. It runs with its own
+// endpoint timeout so a primary that timed out still leaves room for the
+// secondary. A nil rt uses http.DefaultTransport. 5xx answers from the
+// secondary are reported as failures so the chain can move on.
+func SecondaryBaseURLFallback(baseURL string, rt http.RoundTripper) FallbackFunc {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	target, parseErr := url.Parse(baseURL)
+	return func(req *http.Request, _ error) (*http.Response, error) {
+		if parseErr != nil {
+			return nil, fmt.Errorf("secondary base URL: %w", parseErr)
+		}
+		timeout := 10 * time.Second
+		if cfg, ok := req.Context().Value(EndpointConfigKey{}).(*EndpointSettings); ok && cfg.Timeout > 0 {
+			timeout = cfg.Timeout
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), timeout)
+
+		out := req.Clone(ctx)
+		out.URL.Scheme = target.Scheme
+		out.URL.Host = target.Host
+		out.Host = ""
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("secondary base URL: rewind body: %w", err)
+			}
+			out.Body = body
+		}
+		resp, err := rt.RoundTrip(out)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if resp.StatusCode >= 500 {
+			body, _ := readAndRestoreBody(resp)
+			resp.Body.Close()
+			cancel()
+			return nil, &Error{StatusCode: resp.StatusCode, Body: body, Method: out.Method, URL: out.URL.String()}
+		}
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+}
+
+// CachedResponseFallback serves the last response stored by CacheMiddleware
+// for the request, however stale, marked with a Warning header. Set
+// CacheConfig.StaleIfError to keep entries around past their freshness.
+func CachedResponseFallback(config *CacheConfig) FallbackFunc {
+	return func(req *http.Request, _ error) (*http.Response, error) {
+		if config == nil || config.Cache == nil {
+			return nil, errors.New("cached fallback: no cache configured")
+		}
+		keyFunc := config.KeyFunc
+		if keyFunc == nil {
+			keyFunc = defaultCacheKey
+		}
+		t := &cacheTransport{config: config}
+		entry, ok := t.lookup(context.WithoutCancel(req.Context()), keyFunc(req))
+		if !ok {
+			return nil, fmt.Errorf("cached fallback: no cached response for %s %s", req.Method, req.URL.Path)
+		}
+		resp := entry.response()
+		resp.Request = req
+		resp.Header.Set("Warning", `110 - "Response is Stale"`)
+		return resp, nil
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func TestFallbackChainSecondaryBaseURL(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte("secondary:" + r.URL.Path + ":" + string(b)))
+	}))
+	defer secondary.Close()
+
+	var causes []error
+	settings := noRetrySettings()
+	settings.Fallback = FallbackChain(
+		func(req *http.Request, err error) (*http.Response, error) {
+			causes = append(causes, err)
+			return nil, errors.New("first fallback unavailable")
+		},
+		SecondaryBaseURLFallback(secondary.URL, nil),
+	)
+	c := NewClient(WithBaseURL(primary.URL), WithDefaultSettings(settings))
+
+	resp, err := c.Post(context.Background(), "/orders", []byte("payload"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "secondary:/orders:payload" {
+		t.Errorf("unexpected secondary response %q", body)
+	}
+	if len(causes) != 1 || !errors.Is(causes[0], ErrServer) {
+		t.Errorf("expected fallbacks to receive the server error, got %v", causes)
+	}
+}
+
+func TestFallbackChainAllFail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	settings := noRetrySettings()
+	settings.Fallback = FallbackChain(SecondaryBaseURLFallback(srv.URL, nil))
+	c := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(settings))
+
+	_, err := c.Get(context.Background(), "/", nil)
+	var cErr *Error
+	if !errors.As(err, &cErr) || cErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected the secondary's *Error, got %v", err)
+	}
+}
+
+func TestCachedResponseFallback(t *testing.T) {
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		_, _ = w.Write([]byte("catalog v1"))
+	}))
+	defer srv.Close()
+
+	store := cache.NewMemoryCache()
+	defer func() { _ = store.Close() }()
+	cacheCfg := &CacheConfig{
+		Cache:        store,
+		DefaultTTL:   time.Minute,
+		Methods:      []string{http.MethodGet},
+		StatusCodes:  []int{http.StatusOK},
+		StaleIfError: time.Hour,
+	}
+	settings := noRetrySettings()
+	settings.EnableCache = true
+	settings.Fallback = CachedResponseFallback(cacheCfg)
+	c := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(settings), WithCache(cacheCfg))
+
+	if _, err := c.Get(context.Background(), "/catalog", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failing.Store(true)
+	resp, err := c.Get(context.Background(), "/catalog", nil)
+	if err != nil {
+		t.Fatalf("expected the stale response, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "catalog v1" || resp.Header.Get("Warning") == "" {
+		t.Errorf("unexpected fallback response %q %v", body, resp.Header)
+	}
+
+	if _, err := c.Get(context.Background(), "/other", nil); err == nil {
+		t.Error("expected an error without a cached response")
+	}
+}
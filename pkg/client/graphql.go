@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLConfig configures Client.GraphQL.
+type GraphQLConfig struct {
+	// Path of the GraphQL endpoint relative to the base URL. Defaults to /graphql.
+	Path string
+	// PersistedQueries sends the SHA-256 hash of the query first and only
+	// uploads the full document when the server does not know it yet
+	// (automatic persisted queries).
+	PersistedQueries bool
+}
+
+func WithGraphQL(cfg *GraphQLConfig) func(*options) {
+	return func(o *options) { o.graphQL = cfg }
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Path       []any             `json:"path,omitempty"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLErrors is returned when the response carries an errors array. Any
+// partial data is still decoded into the output value.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, gqlErr := range e {
+		msgs[i] = gqlErr.Message
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+func (e GraphQLErrors) persistedQueryNotFound() bool {
+	for _, gqlErr := range e {
+		if gqlErr.Message == "PersistedQueryNotFound" || gqlErr.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// GraphQL posts query with variables and decodes the data field into out.
+// Errors reported by the server are returned as GraphQLErrors; transport
+// and HTTP failures are returned as from DoInto.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	cfg := c.options.graphQL
+	if cfg == nil {
+		cfg = &GraphQLConfig{}
+	}
+	path := cfg.Path
+	if path == "" {
+		path = "/graphql"
+	}
+
+	payload := graphQLRequest{Query: query, Variables: variables}
+	if cfg.PersistedQueries {
+		sum := sha256.Sum256([]byte(query))
+		payload.Extensions = map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])},
+		}
+		payload.Query = ""
+	}
+
+	resp, err := c.graphQLExec(ctx, path, payload)
+	if err == nil && cfg.PersistedQueries && resp.Errors.persistedQueryNotFound() {
+		payload.Query = query
+		resp, err = c.graphQLExec(ctx, path, payload)
+	}
+	if err != nil {
+		return err
+	}
+	if out != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return decodingError(err)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+func (c *Client) graphQLExec(ctx context.Context, path string, payload graphQLRequest) (*graphQLResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("graphql: encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var out graphQLResponse
+	if err := c.DoInto(ctx, req, &out); err != nil {
+		// Servers commonly answer validation failures with 4xx and a
+		// regular errors array; surface those as GraphQLErrors.
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && json.Unmarshal(apiErr.Body, &out) == nil && len(out.Errors) > 0 {
+			return &out, nil
+		}
+		return nil, err
+	}
+	return &out, nil
+}
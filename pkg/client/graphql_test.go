@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGraphQL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path != "/api/graphql":
+			w.WriteHeader(http.StatusNotFound)
+		case req.Variables["id"] == "missing":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"user not found","path":["user"],"extensions":{"code":"NOT_FOUND"}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"user":{"id":"` + req.Variables["id"].(string) + `","name":"Ada"}}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithGraphQL(&GraphQLConfig{Path: "/api/graphql"}),
+	)
+	const query = `query($id: ID!) { user(id: $id) { id name } }`
+
+	var out struct {
+		User struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+	if err := c.GraphQL(context.Background(), query, map[string]any{"id": "7"}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.User.ID != "7" || out.User.Name != "Ada" {
+		t.Errorf("unexpected data: %+v", out)
+	}
+
+	err := c.GraphQL(context.Background(), query, map[string]any{"id": "missing"}, &out)
+	var gqlErrs GraphQLErrors
+	if !errors.As(err, &gqlErrs) || len(gqlErrs) != 1 || gqlErrs[0].Extensions["code"] != "NOT_FOUND" {
+		t.Errorf("expected typed GraphQL errors, got %v", err)
+	}
+}
+
+func TestGraphQLPersistedQueries(t *testing.T) {
+	known := map[string]bool{}
+	var withQuery int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		hash := req.Extensions["persistedQuery"].(map[string]any)["sha256Hash"].(string)
+		if req.Query != "" {
+			withQuery++
+			known[hash] = true
+		}
+		if !known[hash] {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithGraphQL(&GraphQLConfig{PersistedQueries: true}),
+	)
+	for i := 0; i < 3; i++ {
+		var out struct{ OK bool }
+		if err := c.GraphQL(context.Background(), "{ ok }", nil, &out); err != nil || !out.OK {
+			t.Fatalf("call %d: unexpected result %+v, %v", i, out, err)
+		}
+	}
+	if withQuery != 1 {
+		t.Errorf("expected the full query to be uploaded once, got %d", withQuery)
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type hedgeResult struct {
+	resp *http.Response
+	err  error
+	idx  int
+}
+
+// sendHedged sends req and, while no attempt has answered, fires another copy
+// every hedgeAfter up to maxHedges extra attempts. The first successful
+// (non-5xx) response wins and the remaining attempts are cancelled. If every
+// attempt fails, the last outcome is returned.
+func (c *Client) sendHedged(req *http.Request, hedgeAfter time.Duration, maxHedges int, getBody func() (io.ReadCloser, error)) (*http.Response, error) {
+	ctx := req.Context()
+	results := make(chan hedgeResult, maxHedges+1)
+	cancels := make([]context.CancelFunc, 0, maxHedges+1)
+	inflight := 0
+
+	launch := func() error {
+		idx := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		attempt := req.Clone(attemptCtx)
+		if idx > 0 && getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				cancel()
+				return err
+			}
+			attempt.Body = body
+		}
+		cancels = append(cancels, cancel)
+		inflight++
+		go func() {
+			resp, err := c.httpClient.Do(attempt)
+			results <- hedgeResult{resp: resp, err: err, idx: idx}
+		}()
+		return nil
+	}
+
+	if err := launch(); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(hedgeAfter)
+	defer timer.Stop()
+
+	var last hedgeResult
+	for {
+		select {
+		case r := <-results:
+			inflight--
+			if r.err == nil && r.resp.StatusCode < 500 {
+				for i, cancel := range cancels {
+					if i != r.idx {
+						cancel()
+					}
+				}
+				if last.resp != nil {
+					last.resp.Body.Close()
+				}
+				go drainHedges(results, inflight)
+				r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: cancels[r.idx]}
+				return r.resp, nil
+			}
+			if last.resp != nil {
+				last.resp.Body.Close()
+				cancels[last.idx]()
+			}
+			if r.resp == nil {
+				cancels[r.idx]()
+			}
+			last = r
+			if inflight == 0 {
+				if last.resp != nil {
+					last.resp.Body = &cancelOnClose{ReadCloser: last.resp.Body, cancel: cancels[last.idx]}
+				}
+				return last.resp, last.err
+			}
+		case <-timer.C:
+			if len(cancels) <= maxHedges {
+				if err := launch(); err == nil {
+					timer.Reset(hedgeAfter)
+				}
+			}
+		}
+	}
+}
+
+// drainHedges closes the bodies of losing attempts once they return.
+func drainHedges(results <-chan hedgeResult, n int) {
+	for i := 0; i < n; i++ {
+		if r := <-results; r.resp != nil && r.resp.Body != nil {
+			r.resp.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose releases the context of the winning attempt once its body
+// has been consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgedRequestReturnsFastestAttempt(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+			_, _ = w.Write([]byte("slow"))
+			return
+		}
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:    5 * time.Second,
+			MaxRetries: 1,
+			Headers:    map[string]string{},
+			HedgeAfter: 50 * time.Millisecond,
+			MaxHedges:  1,
+		}),
+	)
+
+	start := time.Now()
+	resp, err := c.Get(context.Background(), "/users/1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "fast" {
+		t.Errorf("expected hedged response to win, got %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected hedge to cut latency, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestHedgeNotFiredForFastResponses(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:    5 * time.Second,
+			MaxRetries: 1,
+			Headers:    map[string]string{},
+			HedgeAfter: 500 * time.Millisecond,
+			MaxHedges:  2,
+		}),
+	)
+
+	if _, err := c.Get(context.Background(), "/users/1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestHedgeSkippedForNonIdempotentRequests(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(150 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:    5 * time.Second,
+			MaxRetries: 1,
+			Headers:    map[string]string{},
+			HedgeAfter: 20 * time.Millisecond,
+			MaxHedges:  2,
+		}),
+	)
+
+	if _, err := c.Post(context.Background(), "/orders", []byte("{}"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected POST not to be hedged, got %d attempts", got)
+	}
+}
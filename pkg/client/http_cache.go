@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheDirectives holds the parts of Cache-Control the client cache acts on.
+type cacheDirectives struct {
+	noStore bool
+	noCache bool
+	private bool
+	maxAge  time.Duration
+	hasAge  bool
+}
+
+func parseCacheControl(h http.Header) cacheDirectives {
+	var d cacheDirectives
+	sharedMaxAge := false
+	for _, part := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			d.noStore = true
+		case "no-cache":
+			d.noCache = true
+		case "private":
+			d.private = true
+		case "s-maxage":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				d.maxAge, d.hasAge, sharedMaxAge = time.Duration(secs)*time.Second, true, true
+			}
+		case "max-age":
+			if sharedMaxAge {
+				continue
+			}
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				d.maxAge, d.hasAge = time.Duration(secs)*time.Second, true
+			}
+		}
+	}
+	return d
+}
+
+// freshness returns how long resp can be served without revalidation and
+// whether it may be stored at all. The client cache is shared by every caller,
+// so private responses are not stored. Without freshness information the
+// endpoint TTL applies.
+func freshness(h http.Header, fallback time.Duration, now time.Time) (time.Duration, bool) {
+	d := parseCacheControl(h)
+	if d.noStore || d.private {
+		return 0, false
+	}
+	if d.noCache {
+		return 0, true
+	}
+	if d.hasAge {
+		return d.maxAge, true
+	}
+	if expires := h.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, true
+		}
+		date := now
+		if t, err := http.ParseTime(h.Get("Date")); err == nil {
+			date = t
+		}
+		return nonNegative(exp.Sub(date)), true
+	}
+	return fallback, true
+}
+
+func (e *cacheEntry) hasValidators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+func (e *cacheEntry) response() *http.Response {
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+	}
+}
+
+// conditionalRequest asks the upstream whether the cached entry is still
+// valid, unless the caller already sent its own validators.
+func conditionalRequest(req *http.Request, e *cacheEntry) *http.Request {
+	if !e.hasValidators() || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return req
+	}
+	out := req.Clone(req.Context())
+	if etag := e.Header.Get("ETag"); etag != "" {
+		out.Header.Set("If-None-Match", etag)
+	}
+	if lm := e.Header.Get("Last-Modified"); lm != "" {
+		out.Header.Set("If-Modified-Since", lm)
+	}
+	return out
+}
+
+func (t *cacheTransport) lookup(ctx context.Context, key string) (*cacheEntry, bool) {
+	cached, err := t.config.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal([]byte(cached), &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// store saves entry fresh for fresh. Entries with validators are kept at least
+// ttl so they can be revalidated once stale, and every entry is kept
+// StaleIfError longer for CachedResponseFallback.
+func (t *cacheTransport) store(ctx context.Context, key string, entry *cacheEntry, fresh, ttl time.Duration) {
+	entry.FreshUntil = time.Now().Add(fresh)
+	keep := fresh
+	if entry.hasValidators() && keep < ttl {
+		keep = ttl
+	}
+	if keep <= 0 && t.config.StaleIfError <= 0 {
+		return
+	}
+	keep += t.config.StaleIfError
+	if data, err := json.Marshal(entry); err == nil {
+		_ = t.config.Cache.Set(ctx, key, string(data), keep)
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func newHTTPCacheClient(t *testing.T, url string) *Client {
+	t.Helper()
+	store := cache.NewMemoryCache()
+	t.Cleanup(func() { _ = store.Close() })
+	return NewClient(
+		WithBaseURL(url),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, EnableCache: true, Headers: map[string]string{}}),
+		WithCache(&CacheConfig{Cache: store, DefaultTTL: time.Minute, Methods: []string{http.MethodGet}, StatusCodes: []int{http.StatusOK}}),
+	)
+}
+
+func TestCacheMiddlewareRevalidatesWithETag(t *testing.T) {
+	var full, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		_, _ = w.Write([]byte("payload"))
+	}))
+	defer srv.Close()
+
+	c := newHTTPCacheClient(t, srv.URL)
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(context.Background(), "/catalog", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+			t.Errorf("call %d: expected cached payload, got %d %q", i, resp.StatusCode, body)
+		}
+	}
+	if full != 1 || notModified != 2 {
+		t.Errorf("expected 1 full download and 2 revalidations, got %d/%d", full, notModified)
+	}
+}
+
+func TestCacheMiddlewareHonoursCacheControl(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		wantCalls int32
+	}{
+		{"max-age serves from cache", "max-age=60", 1},
+		{"no-store is never cached", "no-store", 2},
+		{"private is not shared", "private, max-age=60", 2},
+		{"expired max-age without validators refetches", "max-age=0", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.Header().Set("Cache-Control", tt.header)
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			c := newHTTPCacheClient(t, srv.URL)
+			for i := 0; i < 2; i++ {
+				if _, err := c.Get(context.Background(), "/r", nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("expected %d upstream calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestFreshnessFromExpires(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("Date", now.Format(http.TimeFormat))
+	h.Set("Expires", now.Add(90*time.Second).Format(http.TimeFormat))
+	fresh, ok := freshness(h, time.Minute, now)
+	if !ok || fresh != 90*time.Second {
+		t.Errorf("expected 90s freshness from Expires, got %v (storable=%v)", fresh, ok)
+	}
+	if fresh, _ := freshness(http.Header{}, time.Minute, now); fresh != time.Minute {
+		t.Errorf("expected fallback TTL without directives, got %v", fresh)
+	}
+}
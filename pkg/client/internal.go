@@ -12,16 +12,10 @@ func NewInternalClient(appName string) *Client {
 	baseURL := os.Getenv("BACKEND_URL")
 
 	defaultSettings := &EndpointSettings{
-		Timeout:    10 * time.Second,
-		MaxRetries: 3,
-		BackoffStrategy: func(attempt int) time.Duration {
-			d := 200 * time.Millisecond * time.Duration(1<<uint(attempt))
-			if d > 2*time.Second {
-				d = 2 * time.Second
-			}
-			return d
-		},
-		RequireAuth: true,
+		Timeout:         10 * time.Second,
+		MaxRetries:      3,
+		BackoffStrategy: BackoffExponential(200*time.Millisecond, 2*time.Second),
+		RequireAuth:     true,
 	}
 
 	return NewClient(
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+const redacted = "[REDACTED]"
+
+// LoggingConfig configures LoggingMiddleware.
+type LoggingConfig struct {
+	// RedactHeaders replaces the listed header values when headers are
+	// logged. Defaults to Authorization, Proxy-Authorization, Cookie,
+	// Set-Cookie and X-Api-Key.
+	RedactHeaders []string
+	// LogHeaders adds request and response headers to every entry.
+	LogHeaders bool
+	// LogErrorBody adds the response body of non-2xx responses, truncated to
+	// MaxBodyBytes (1 KiB by default).
+	LogErrorBody bool
+	MaxBodyBytes int
+	// Log overrides the destination, which defaults to pkg/logs.
+	Log func(ctx context.Context, level, msg string, fields ...any)
+}
+
+// LoggingMiddleware logs one entry per attempt with the method, templated
+// path, status, duration and retry number. Transport errors and 5xx are
+// logged as errors, 4xx as warnings and the rest as info.
+func LoggingMiddleware(config *LoggingConfig) Middleware {
+	if config == nil {
+		config = &LoggingConfig{}
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = 1024
+	}
+	if config.Log == nil {
+		config.Log = defaultLog
+	}
+	redact := make(map[string]bool, len(config.RedactHeaders))
+	for _, h := range config.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			ctx := req.Context()
+			fields := []any{
+				"method", req.Method,
+				"host", req.URL.Host,
+				"path", routeLabel(req),
+				"duration_ms", time.Since(start).Milliseconds(),
+				"retry", retryAttempt(ctx),
+			}
+			if config.LogHeaders {
+				fields = append(fields, "request_headers", redactHeaders(req.Header, redact))
+			}
+			if err != nil {
+				config.Log(ctx, "error", "http client request failed", append(fields, "error", err.Error())...)
+				return resp, err
+			}
+			fields = append(fields, "status", resp.StatusCode)
+			if config.LogHeaders {
+				fields = append(fields, "response_headers", redactHeaders(resp.Header, redact))
+			}
+			if config.LogErrorBody && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+				fields = append(fields, "response_body", peekBody(resp, config.MaxBodyBytes))
+			}
+			switch {
+			case resp.StatusCode >= 500:
+				config.Log(ctx, "error", "http client request", fields...)
+			case resp.StatusCode >= 400:
+				config.Log(ctx, "warn", "http client request", fields...)
+			default:
+				config.Log(ctx, "info", "http client request", fields...)
+			}
+			return resp, nil
+		})
+	}
+}
+
+func defaultLog(ctx context.Context, level, msg string, fields ...any) {
+	switch level {
+	case "error":
+		logs.Error(ctx, msg, fields...)
+	case "warn":
+		logs.Warn(ctx, msg, fields...)
+	default:
+		logs.Info(ctx, msg, fields...)
+	}
+}
+
+func redactHeaders(h http.Header, redact map[string]bool) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if redact[http.CanonicalHeaderKey(k)] {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// peekBody reads up to limit bytes for logging and puts them back in front of
+// the remaining body.
+func peekBody(resp *http.Response, limit int) string {
+	if resp.Body == nil {
+		return ""
+	}
+	buf := make([]byte, limit)
+	n, _ := io.ReadFull(resp.Body, buf)
+	buf = buf[:n]
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+	s := string(buf)
+	if n == limit {
+		s += "...(truncated)"
+	}
+	return s
+}
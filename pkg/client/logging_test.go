@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type logEntry struct {
+	level  string
+	fields map[string]any
+}
+
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (r *logRecorder) log(_ context.Context, level, _ string, fields ...any) {
+	m := map[string]any{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		m[fields[i].(string)] = fields[i+1]
+	}
+	r.mu.Lock()
+	r.entries = append(r.entries, logEntry{level: level, fields: m})
+	r.mu.Unlock()
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+			return
+		}
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rec := &logRecorder{}
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:         5 * time.Second,
+			MaxRetries:      1,
+			BackoffStrategy: func(int) time.Duration { return time.Millisecond },
+			Headers:         map[string]string{},
+		}),
+		WithLogging(&LoggingConfig{LogHeaders: true, LogErrorBody: true, MaxBodyBytes: 10, Log: rec.log}),
+	)
+	_, err := c.Get(context.Background(), "/users/{id}", map[string]string{"Authorization": "Bearer token"}, Params{"id": 9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rec.entries) != 2 {
+		t.Fatalf("expected one entry per attempt, got %d", len(rec.entries))
+	}
+	first, second := rec.entries[0], rec.entries[1]
+	if first.level != "error" || first.fields["status"] != 503 || first.fields["retry"] != 0 {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if body := first.fields["response_body"]; body != strings.Repeat("x", 10)+"...(truncated)" {
+		t.Errorf("expected truncated body, got %v", body)
+	}
+	if second.level != "info" || second.fields["retry"] != 1 || second.fields["path"] != "/users/{id}" {
+		t.Errorf("unexpected second entry: %+v", second)
+	}
+	if h := second.fields["request_headers"].(map[string]string); h["Authorization"] != redacted {
+		t.Errorf("expected Authorization to be redacted, got %q", h["Authorization"])
+	}
+	if h := second.fields["response_headers"].(map[string]string); h["Set-Cookie"] != redacted {
+		t.Errorf("expected Set-Cookie to be redacted, got %q", h["Set-Cookie"])
+	}
+}
@@ -3,7 +3,6 @@ package client
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -166,6 +165,9 @@ func DefaultTracingConfig() *TracingConfig {
 		TracerProvider: otel.GetTracerProvider(),
 		Propagators:    otel.GetTextMapPropagator(),
 		SpanNameFormatter: func(r *http.Request) string {
+			if route, ok := r.Context().Value(routeKey{}).(string); ok {
+				return fmt.Sprintf("HTTP %s %s", r.Method, route)
+			}
 			return fmt.Sprintf("HTTP %s", r.Method)
 		},
 	}
@@ -211,6 +213,7 @@ func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		attribute.String("http.target", req.URL.Path),
 		attribute.String("http.scheme", req.URL.Scheme),
 		attribute.String("http.host", req.Host),
+		attribute.String("http.route", routeLabel(req)),
 	)
 	if req.ContentLength > 0 {
 		span.SetAttributes(attribute.Int("http.request_content_length", int(req.ContentLength)))
@@ -286,6 +289,18 @@ func MetricsMiddleware(config *MetricsConfig) Middleware {
 	}
 }
 
+func newValidationErrorsMetric(config *MetricsConfig) *prometheus.CounterVec {
+	return registerOrReuse(prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Subsystem: config.Subsystem,
+			Name:      "response_validation_errors_total",
+			Help:      "Total number of responses rejected by ValidateResponse",
+		},
+		[]string{"method", "host", "path"},
+	)).(*prometheus.CounterVec)
+}
+
 func registerOrReuse(c prometheus.Collector) prometheus.Collector {
 	err := prometheus.Register(c)
 	if err == nil {
@@ -331,7 +346,7 @@ func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	start := time.Now()
 	method := req.Method
 	host := req.URL.Host
-	path := req.URL.Path
+	path := routeLabel(req)
 	resp, err := t.next.RoundTrip(req)
 	duration := time.Since(start).Seconds()
 	if err != nil {
@@ -351,6 +366,12 @@ type CacheConfig struct {
 	StatusCodes     []int
 	KeyFunc         func(r *http.Request) string
 	SkipCacheHeader string
+	// IgnoreCacheControl restores the TTL-only behaviour: Cache-Control,
+	// Expires and validators sent by the upstream are not honoured.
+	IgnoreCacheControl bool
+	// StaleIfError keeps entries this long past their freshness so that
+	// CachedResponseFallback can serve them when the upstream fails.
+	StaleIfError time.Duration
 }
 
 func CacheMiddleware(config *CacheConfig) Middleware {
@@ -370,6 +391,7 @@ type cacheEntry struct {
 	StatusCode int         `json:"status_code"`
 	Header     http.Header `json:"header"`
 	Body       string      `json:"body"`
+	FreshUntil time.Time   `json:"fresh_until"`
 }
 type cacheTransport struct {
 	next   http.RoundTripper
@@ -407,23 +429,31 @@ func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return t.next.RoundTrip(req)
 	}
 	key := t.config.KeyFunc(req)
-	if cached, err := t.config.Cache.Get(req.Context(), key); err == nil {
-		var entry cacheEntry
-		if err := json.Unmarshal([]byte(cached), &entry); err == nil {
-			resp := &http.Response{
-				Status:        entry.Status,
-				StatusCode:    entry.StatusCode,
-				Header:        entry.Header.Clone(),
-				Body:          io.NopCloser(strings.NewReader(entry.Body)),
-				ContentLength: int64(len(entry.Body)),
-			}
-			return resp, nil
-		}
+	entry, hit := t.lookup(req.Context(), key)
+	if hit && ((t.config.IgnoreCacheControl && t.config.StaleIfError == 0) || time.Now().Before(entry.FreshUntil)) {
+		return entry.response(), nil
 	}
-	resp, err := t.next.RoundTrip(req)
+	outReq := req
+	if hit && !t.config.IgnoreCacheControl {
+		outReq = conditionalRequest(req, entry)
+	}
+	resp, err := t.next.RoundTrip(outReq)
 	if err != nil {
 		return nil, err
 	}
+	if outReq != req && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		for k, v := range resp.Header {
+			entry.Header[k] = v
+		}
+		entry.Header.Del("X-Request-Id")
+		fresh, storable := freshness(entry.Header, ttl, time.Now())
+		if storable {
+			t.store(req.Context(), key, entry, fresh, ttl)
+		}
+		return entry.response(), nil
+	}
 	body, err := readAndRestoreBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response body: %w", err)
@@ -436,16 +466,19 @@ func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		}
 	}
 	if statusCacheable {
-		headers := resp.Header.Clone()
-		headers.Del("X-Request-Id")
-		entry := cacheEntry{
-			Status:     resp.Status,
-			StatusCode: resp.StatusCode,
-			Header:     headers,
-			Body:       string(body),
+		fresh, storable := ttl, true
+		if !t.config.IgnoreCacheControl {
+			fresh, storable = freshness(resp.Header, ttl, time.Now())
 		}
-		if cachedData, err := json.Marshal(entry); err == nil {
-			_ = t.config.Cache.Set(req.Context(), key, string(cachedData), ttl)
+		if storable {
+			headers := resp.Header.Clone()
+			headers.Del("X-Request-Id")
+			t.store(req.Context(), key, &cacheEntry{
+				Status:     resp.Status,
+				StatusCode: resp.StatusCode,
+				Header:     headers,
+				Body:       string(body),
+			}, fresh, ttl)
 		}
 	}
 	return resp, nil
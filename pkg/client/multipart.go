@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// File describes a file part of a multipart upload. Reader is streamed as-is
+// and closed after it is consumed when it implements io.Closer.
+type File struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Reader      io.Reader
+}
+
+// ProgressFunc reports transferred bytes. total is -1 when the size is unknown.
+type ProgressFunc func(transferred, total int64)
+
+type multipartOptions struct {
+	headers  map[string]string
+	progress ProgressFunc
+}
+
+type MultipartOption func(*multipartOptions)
+
+// WithUploadHeaders sets extra request headers for a multipart upload.
+func WithUploadHeaders(headers map[string]string) MultipartOption {
+	return func(o *multipartOptions) { o.headers = headers }
+}
+
+// WithUploadProgress registers a callback invoked as the body is sent.
+func WithUploadProgress(fn ProgressFunc) MultipartOption {
+	return func(o *multipartOptions) { o.progress = fn }
+}
+
+// PostMultipart sends a multipart/form-data POST. The body is produced on the
+// fly through a pipe, so file readers are never fully buffered in memory.
+// Because the body cannot be replayed, the request is attempted only once.
+func (c *Client) PostMultipart(ctx context.Context, path string, fields map[string]string, files []File, opts ...MultipartOption) (*http.Response, error) {
+	o := &multipartOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	var body io.Reader = pr
+	if o.progress != nil {
+		body = &progressReader{r: pr, total: -1, fn: o.progress}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.options.baseURL+path, &streamingBody{Reader: body, closer: pr})
+	if err != nil {
+		return nil, &Error{Err: err, Method: http.MethodPost, URL: c.options.baseURL + path}
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+	return c.Do(ctx, req)
+}
+
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files []File) error {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := mw.WriteField(k, fields[k]); err != nil {
+			closeFiles(files)
+			return fmt.Errorf("write field %q: %w", k, err)
+		}
+	}
+	for i, f := range files {
+		if err := writeFilePart(mw, f); err != nil {
+			closeFiles(files[i+1:])
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// closeFiles closes the readers of files that will not be written.
+func closeFiles(files []File) {
+	for _, f := range files {
+		if closer, ok := f.Reader.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+func writeFilePart(mw *multipart.Writer, f File) error {
+	if f.Reader == nil {
+		return fmt.Errorf("file %q has no reader", f.FileName)
+	}
+	if closer, ok := f.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(f.FieldName), escapeQuotes(f.FileName)))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("create part %q: %w", f.FieldName, err)
+	}
+	if _, err := io.Copy(part, f.Reader); err != nil {
+		return fmt.Errorf("copy file %q: %w", f.FileName, err)
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+type progressReader struct {
+	r           io.Reader
+	transferred int64
+	total       int64
+	fn          ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.transferred += int64(n)
+		p.fn(p.transferred, p.total)
+	}
+	return n, err
+}
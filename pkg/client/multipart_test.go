@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostMultipart(t *testing.T) {
+	var (
+		gotField   string
+		gotFile    string
+		gotName    string
+		gotType    string
+		gotMethod  string
+		gotHeaders http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotHeaders = r.Header.Clone()
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		gotField = r.FormValue("title")
+		f, fh, err := r.FormFile("upload")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		gotFile = string(data)
+		gotName = fh.Filename
+		gotType = fh.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 2, Headers: map[string]string{}}),
+	)
+
+	var progressed int64
+	resp, err := c.PostMultipart(context.Background(), "/media",
+		map[string]string{"title": "holiday"},
+		[]File{{FieldName: "upload", FileName: "photo.png", ContentType: "image/png", Reader: strings.NewReader("PNGDATA")}},
+		WithUploadProgress(func(transferred, total int64) { progressed = transferred }),
+		WithUploadHeaders(map[string]string{"X-Trace": "abc"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if gotMethod != http.MethodPost {
+		t.Errorf("expected POST, got %s", gotMethod)
+	}
+	if gotField != "holiday" {
+		t.Errorf("expected field title=holiday, got %q", gotField)
+	}
+	if gotFile != "PNGDATA" || gotName != "photo.png" || gotType != "image/png" {
+		t.Errorf("unexpected file part: data=%q name=%q type=%q", gotFile, gotName, gotType)
+	}
+	if !strings.HasPrefix(gotHeaders.Get("Content-Type"), "multipart/form-data; boundary=") {
+		t.Errorf("unexpected content type %q", gotHeaders.Get("Content-Type"))
+	}
+	if gotHeaders.Get("X-Trace") != "abc" {
+		t.Errorf("expected custom header to be forwarded")
+	}
+	if progressed == 0 {
+		t.Error("expected progress callback to be invoked")
+	}
+}
+
+func TestPostMultipartIsNotRetried(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 3, Headers: map[string]string{}}),
+	)
+	_, err := c.PostMultipart(context.Background(), "/media", nil,
+		[]File{{FieldName: "upload", FileName: "a.txt", Reader: strings.NewReader("a")}})
+	if err == nil {
+		t.Fatal("expected error for 500 response")
+	}
+	if calls != 1 {
+		t.Errorf("expected streaming upload to be sent once, got %d calls", calls)
+	}
+}
+
+type trackedReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *trackedReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestWriteMultipartClosesFilesOnError(t *testing.T) {
+	first := &trackedReader{Reader: strings.NewReader("first")}
+	last := &trackedReader{Reader: strings.NewReader("last")}
+	files := []File{
+		{FieldName: "a", FileName: "a.txt", Reader: first},
+		{FieldName: "b", FileName: "b.txt"},
+		{FieldName: "c", FileName: "c.txt", Reader: last},
+	}
+	mw := multipart.NewWriter(io.Discard)
+	if err := writeMultipart(mw, nil, files); err == nil {
+		t.Fatal("expected an error for the file without reader")
+	}
+	if !first.closed || !last.closed {
+		t.Errorf("expected every file to be closed, got first=%v last=%v", first.closed, last.closed)
+	}
+}
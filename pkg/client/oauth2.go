@@ -0,0 +1,249 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+// OAuth2Config configures the client-credentials grant used by OAuth2Middleware.
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Audience     string
+	// SendCredentialsInBody posts client_id/client_secret as form fields
+	// instead of HTTP Basic auth, for providers that require it.
+	SendCredentialsInBody bool
+	// Cache shares tokens across instances. When nil tokens are only kept in memory.
+	Cache    cache.Cache
+	CacheKey string
+	// RefreshBefore renews the token in the background once it is this close
+	// to expiry. Defaults to one minute.
+	RefreshBefore time.Duration
+	HTTPClient    *http.Client
+}
+
+type oauth2Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (t *oauth2Token) header() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+// OAuth2Middleware authenticates outbound requests with machine-to-machine
+// tokens obtained through the client-credentials grant. Tokens are cached until
+// expiry, refreshed ahead of time and dropped when the upstream answers 401.
+func OAuth2Middleware(config *OAuth2Config) Middleware {
+	if config == nil || config.TokenURL == "" {
+		return nil
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.CacheKey == "" {
+		config.CacheKey = "oauth2_token:" + config.ClientID + ":" + strings.Join(config.Scopes, " ")
+	}
+	src := &oauth2TokenSource{config: config}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			token, err := src.token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", token.header())
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp != nil && resp.StatusCode == http.StatusUnauthorized {
+				src.invalidate(req.Context())
+			}
+			return resp, err
+		})
+	}
+}
+
+type oauth2TokenSource struct {
+	config     *OAuth2Config
+	mu         sync.Mutex
+	current    *oauth2Token
+	refreshing bool
+	// inflight is the cache lookup and fetch shared by the callers waiting
+	// for a token; mu is never held across that I/O.
+	inflight *oauth2Call
+}
+
+type oauth2Call struct {
+	done chan struct{}
+	tok  *oauth2Token
+	err  error
+}
+
+func (s *oauth2TokenSource) token(ctx context.Context) (*oauth2Token, error) {
+	s.mu.Lock()
+	now := time.Now()
+	if s.current != nil && now.Before(s.current.ExpiresAt) {
+		if !s.refreshing && s.current.ExpiresAt.Sub(now) < s.config.RefreshBefore {
+			s.refreshing = true
+			go s.refreshAsync()
+		}
+		tok := s.current
+		s.mu.Unlock()
+		return tok, nil
+	}
+	call := s.inflight
+	if call == nil {
+		call = &oauth2Call{done: make(chan struct{})}
+		s.inflight = call
+		// Detached from the caller so one canceled request does not fail
+		// the others waiting on the same fetch.
+		go s.load(context.WithoutCancel(ctx), call)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.tok, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load gets a token from the shared cache or the token endpoint for the
+// callers of call.
+func (s *oauth2TokenSource) load(ctx context.Context, call *oauth2Call) {
+	tok := s.loadCached(ctx)
+	if tok == nil || !time.Now().Before(tok.ExpiresAt) {
+		tok, call.err = s.fetch(ctx)
+	}
+	call.tok = tok
+
+	s.mu.Lock()
+	if call.err == nil {
+		s.current = tok
+	}
+	s.inflight = nil
+	s.mu.Unlock()
+	close(call.done)
+}
+
+func (s *oauth2TokenSource) refreshAsync() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	tok, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshing = false
+	if err != nil {
+		logs.Warn(ctx, "oauth2: background token refresh failed", "error", err)
+		return
+	}
+	s.current = tok
+}
+
+func (s *oauth2TokenSource) invalidate(ctx context.Context) {
+	s.mu.Lock()
+	s.current = nil
+	s.mu.Unlock()
+	if s.config.Cache != nil {
+		_ = s.config.Cache.Delete(ctx, s.config.CacheKey)
+	}
+}
+
+func (s *oauth2TokenSource) loadCached(ctx context.Context) *oauth2Token {
+	if s.config.Cache == nil {
+		return nil
+	}
+	raw, err := s.config.Cache.Get(ctx, s.config.CacheKey)
+	if err != nil {
+		return nil
+	}
+	var tok oauth2Token
+	if err := json.Unmarshal([]byte(raw), &tok); err != nil || tok.AccessToken == "" {
+		return nil
+	}
+	return &tok
+}
+
+func (s *oauth2TokenSource) fetch(ctx context.Context) (*oauth2Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.Audience != "" {
+		form.Set("audience", s.config.Audience)
+	}
+	if s.config.SendCredentialsInBody {
+		form.Set("client_id", s.config.ClientID)
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !s.config.SendCredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth2: token endpoint returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("oauth2: decode token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return nil, errors.New("oauth2: token response has no access_token")
+	}
+	expiresIn := time.Duration(payload.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	tok := &oauth2Token{
+		AccessToken: payload.AccessToken,
+		TokenType:   payload.TokenType,
+		ExpiresAt:   time.Now().Add(expiresIn),
+	}
+	if s.config.Cache != nil {
+		if data, err := json.Marshal(tok); err == nil {
+			_ = s.config.Cache.Set(ctx, s.config.CacheKey, string(data), expiresIn)
+		}
+	}
+	return tok, nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func TestOAuth2Middleware(t *testing.T) {
+	var tokenCalls int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenCalls, 1)
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "svc" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "orders:read" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"tok-%d","token_type":"bearer","expires_in":3600}`, n)
+	}))
+	defer tokenSrv.Close()
+
+	var lastAuth atomic.Value
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastAuth.Store(r.Header.Get("Authorization"))
+		if r.URL.Path == "/expired" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	c := NewClient(
+		WithBaseURL(api.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithOAuth2(&OAuth2Config{
+			TokenURL:     tokenSrv.URL,
+			ClientID:     "svc",
+			ClientSecret: "s3cret",
+			Scopes:       []string{"orders:read"},
+			Cache:        store,
+		}),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, "/orders", nil); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt32(&tokenCalls); got != 1 {
+		t.Errorf("expected token to be fetched once and cached, got %d fetches", got)
+	}
+	if got := lastAuth.Load(); got != "Bearer tok-1" {
+		t.Errorf("expected Authorization 'Bearer tok-1', got %v", got)
+	}
+	if ok, _ := store.Exists(ctx, "oauth2_token:svc:orders:read"); !ok {
+		t.Error("expected token to be stored in the cache")
+	}
+
+	_, _ = c.Get(ctx, "/expired", nil)
+	if _, err := c.Get(ctx, "/orders", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := lastAuth.Load(); got != "Bearer tok-2" {
+		t.Errorf("expected a fresh token after 401, got %v", got)
+	}
+}
+
+func TestOAuth2MiddlewareTokenEndpointFailure(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	defer tokenSrv.Close()
+
+	var apiCalls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+	}))
+	defer api.Close()
+
+	c := NewClient(
+		WithBaseURL(api.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithOAuth2(&OAuth2Config{TokenURL: tokenSrv.URL, ClientID: "svc", ClientSecret: "bad"}),
+	)
+	if _, err := c.Get(context.Background(), "/orders", nil); err == nil {
+		t.Fatal("expected error when the token endpoint rejects the client")
+	}
+	if atomic.LoadInt32(&apiCalls) != 0 {
+		t.Error("expected no upstream call without a token")
+	}
+}
+
+func TestOAuth2TokenSourceSharesFetch(t *testing.T) {
+	var tokenCalls int32
+	release := make(chan struct{})
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenCalls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+	}))
+	defer tokenSrv.Close()
+
+	src := &oauth2TokenSource{config: &OAuth2Config{
+		TokenURL:      tokenSrv.URL,
+		RefreshBefore: time.Minute,
+		HTTPClient:    tokenSrv.Client(),
+	}}
+
+	results := make(chan error, 5)
+	for range 5 {
+		go func() {
+			_, err := src.token(context.Background())
+			results <- err
+		}()
+	}
+
+	// A caller giving up does not wait for the fetch, nor cancel it.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := src.token(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the caller deadline, got %v", err)
+	}
+
+	close(release)
+	for range 5 {
+		if err := <-results; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&tokenCalls); got != 1 {
+		t.Errorf("expected concurrent callers to share one fetch, got %d", got)
+	}
+}
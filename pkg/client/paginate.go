@@ -0,0 +1,189 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrPaginationLoop is yielded by Paginate when the server points back to a
+// page already fetched.
+var ErrPaginationLoop = errors.New("client: pagination loop")
+
+// PageOptions configures Paginate. Page and Limit are sent as the page and
+// limit query parameters understood by pkg/paginate.
+type PageOptions struct {
+	Page  int
+	Limit int
+	// MaxPages stops after this many pages; zero means no limit.
+	MaxPages int
+	Headers  map[string]string
+	Options  []RequestOption
+}
+
+// pageEnvelope matches paginate.PaginatedResponse without importing its
+// database dependencies.
+type pageEnvelope[T any] struct {
+	Data       []T `json:"data"`
+	Pagination *struct {
+		NextPage int  `json:"next_page"`
+		HasNext  bool `json:"has_next"`
+	} `json:"pagination"`
+}
+
+// Paginate lazily walks a paged collection and yields its items one by one.
+// The next page comes from a Link rel="next" header when present, otherwise
+// from the pagination block of a paginate.PaginatedResponse; plain JSON
+// arrays stop at the first short page. Each page goes through Get, so the
+// endpoint retry policy applies per page. Iteration stops at the first error,
+// which is yielded with the zero value; a next page that was already fetched
+// is reported as ErrPaginationLoop.
+func Paginate[T any](ctx context.Context, c *Client, path string, opts *PageOptions) iter.Seq2[T, error] {
+	if opts == nil {
+		opts = &PageOptions{}
+	}
+	return func(yield func(T, error) bool) {
+		var zero T
+		page := opts.Page
+		if page < 1 {
+			page = 1
+		}
+		nextURL := ""
+		// visited holds the Link targets and page numbers already fetched,
+		// so a server repeating its next page cannot loop forever.
+		visited := map[string]bool{pageKey(page): true}
+		revisit := func(key string) bool {
+			if visited[key] {
+				yield(zero, fmt.Errorf("%w: %s", ErrPaginationLoop, key))
+				return true
+			}
+			visited[key] = true
+			return false
+		}
+		for fetched := 0; opts.MaxPages == 0 || fetched < opts.MaxPages; fetched++ {
+			resp, err := c.fetchPage(ctx, path, nextURL, page, opts)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			body, err := readAndRestoreBody(resp)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			items, hasNext, nextPage, err := decodePage[T](body, opts.Limit)
+			if err != nil {
+				yield(zero, &Error{StatusCode: resp.StatusCode, Body: body, Err: decodingError(err)})
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if link := nextLink(resp.Header, resp.Request); link != "" {
+				if revisit(link) {
+					return
+				}
+				nextURL = link
+				continue
+			}
+			if !hasNext {
+				return
+			}
+			nextURL = ""
+			if nextPage > 0 {
+				page = nextPage
+			} else {
+				page++
+			}
+			if revisit(pageKey(page)) {
+				return
+			}
+		}
+	}
+}
+
+func pageKey(page int) string {
+	return "page " + strconv.Itoa(page)
+}
+
+func (c *Client) fetchPage(ctx context.Context, path, nextURL string, page int, opts *PageOptions) (*http.Response, error) {
+	if nextURL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, nextURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range opts.Headers {
+			req.Header.Set(k, v)
+		}
+		return c.Do(WithRequestOptions(ctx, opts.Options...), req)
+	}
+	query := url.Values{"page": {strconv.Itoa(page)}}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	reqOpts := append([]RequestOption{WithQuery(query)}, opts.Options...)
+	return c.Get(ctx, path, opts.Headers, reqOpts...)
+}
+
+func decodePage[T any](body []byte, limit int) (items []T, hasNext bool, nextPage int, err error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, false, 0, err
+		}
+		return items, limit > 0 && len(items) >= limit, 0, nil
+	}
+	var env pageEnvelope[T]
+	if err := json.Unmarshal(trimmed, &env); err != nil {
+		return nil, false, 0, err
+	}
+	if env.Pagination == nil {
+		return env.Data, limit > 0 && len(env.Data) >= limit, 0, nil
+	}
+	return env.Data, env.Pagination.HasNext, env.Pagination.NextPage, nil
+}
+
+// nextLink returns the absolute rel="next" target of an RFC 8288 Link header.
+func nextLink(h http.Header, req *http.Request) string {
+	for _, header := range h.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			isNext := false
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "rel") {
+					for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+						if strings.EqualFold(rel, "next") {
+							isNext = true
+						}
+					}
+				}
+			}
+			if !isNext {
+				continue
+			}
+			u, err := url.Parse(target[1 : len(target)-1])
+			if err != nil {
+				continue
+			}
+			if req != nil && req.URL != nil {
+				u = req.URL.ResolveReference(u)
+			}
+			return u.String()
+		}
+	}
+	return ""
+}
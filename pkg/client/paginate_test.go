@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type pagedItem struct {
+	ID int `json:"id"`
+}
+
+func newPagingClient(url string) *Client {
+	return NewClient(
+		WithBaseURL(url),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+}
+
+func TestPaginateEnvelope(t *testing.T) {
+	var pages int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		items := []pagedItem{}
+		for i := 0; i < limit && (page-1)*limit+i < 5; i++ {
+			items = append(items, pagedItem{ID: (page-1)*limit + i + 1})
+		}
+		hasNext := page*limit < 5
+		next := 0
+		if hasNext {
+			next = page + 1
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data":       items,
+			"pagination": map[string]any{"page": page, "limit": limit, "has_next": hasNext, "next_page": next},
+		})
+	}))
+	defer srv.Close()
+
+	var ids []int
+	for item, err := range Paginate[pagedItem](context.Background(), newPagingClient(srv.URL), "/items", &PageOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" || pages != 3 {
+		t.Errorf("expected 5 items over 3 pages, got %v over %d", ids, pages)
+	}
+}
+
+func TestPaginateLinkHeaderAndEarlyStop(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set("Link", `</items?cursor=b>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "b":
+			w.Header().Set("Link", `</items?cursor=c>; rel="next", </items>; rel="first"`)
+			_, _ = w.Write([]byte(`[{"id":3}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newPagingClient(srv.URL)
+	var ids []int
+	for item, err := range Paginate[pagedItem](context.Background(), c, "/items", nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" || requests != 3 {
+		t.Errorf("expected to follow Link headers, got %v in %d requests", ids, requests)
+	}
+
+	requests = 0
+	for item := range Paginate[pagedItem](context.Background(), c, "/items", nil) {
+		if item.ID == 1 {
+			break
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected no further pages after break, got %d requests", requests)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var gotErr error
+	for _, err := range Paginate[pagedItem](context.Background(), newPagingClient(srv.URL), "/items", nil) {
+		gotErr = err
+	}
+	if gotErr == nil {
+		t.Fatal("expected the page error to be yielded")
+	}
+}
+
+func TestPaginateLoop(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/links" {
+			w.Header().Set("Link", `</links?cursor=a>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"id":1}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":1}],"pagination":{"has_next":true,"next_page":1}}`))
+	}))
+	defer srv.Close()
+
+	c := newPagingClient(srv.URL)
+	for _, path := range []string{"/links", "/pages"} {
+		requests = 0
+		var gotErr error
+		for _, err := range Paginate[pagedItem](context.Background(), c, path, nil) {
+			gotErr = err
+		}
+		if !errors.Is(gotErr, ErrPaginationLoop) {
+			t.Errorf("%s: expected ErrPaginationLoop, got %v", path, gotErr)
+		}
+		if requests > 2 {
+			t.Errorf("%s: expected the loop to stop early, got %d requests", path, requests)
+		}
+	}
+}
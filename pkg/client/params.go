@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Params fills the {name} placeholders of a path template, e.g.
+// c.Get(ctx, "/users/{id}", nil, client.Params{"id": 42}). Values are
+// path-escaped, and the template instead of the expanded path is used as the
+// metrics and tracing label.
+type Params map[string]any
+
+func (p Params) applyRequest(o *requestOptions) {
+	merged := make(Params, len(o.params)+len(p))
+	for k, v := range o.params {
+		merged[k] = v
+	}
+	for k, v := range p {
+		merged[k] = v
+	}
+	o.params = merged
+}
+
+type routeKey struct{}
+
+// expandPath replaces every {name} in template with the escaped parameter.
+func expandPath(template string, params Params) (string, error) {
+	var b strings.Builder
+	rest := template
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		closing := strings.IndexByte(rest[open:], '}')
+		if closing < 0 {
+			return "", fmt.Errorf("unterminated placeholder in path template %q", template)
+		}
+		name := rest[open+1 : open+closing]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing path parameter %q for template %q", name, template)
+		}
+		b.WriteString(rest[:open])
+		b.WriteString(url.PathEscape(fmt.Sprint(value)))
+		rest = rest[open+closing+1:]
+	}
+}
+
+// withRoute expands path when Params were given and records the template
+// as the request's route.
+func withRoute(ctx context.Context, path string) (context.Context, string, error) {
+	ro := requestOptionsFrom(ctx)
+	if ro == nil || len(ro.params) == 0 {
+		return ctx, path, nil
+	}
+	expanded, err := expandPath(path, ro.params)
+	if err != nil {
+		return ctx, path, err
+	}
+	return context.WithValue(ctx, routeKey{}, path), expanded, nil
+}
+
+// WithRouteTemplates lists path templates such as /users/{id}/orders/{id}
+// used as the metrics and tracing label of requests whose path matches them,
+// for callers that build paths themselves instead of passing Params. Each
+// {name} matches one path segment; templates are compared with the full
+// request path, including any base URL path.
+func WithRouteTemplates(templates ...string) func(*options) {
+	return func(o *options) { o.routeTemplates = append(o.routeTemplates, templates...) }
+}
+
+// resolveRoute records the route label for a request: the endpoint Name when
+// set, otherwise the Params template, otherwise the first matching route
+// template.
+func resolveRoute(ctx context.Context, cfg *EndpointSettings, templates []string, path string) context.Context {
+	if cfg.Name != "" {
+		return context.WithValue(ctx, routeKey{}, cfg.Name)
+	}
+	if _, ok := ctx.Value(routeKey{}).(string); ok {
+		return ctx
+	}
+	for _, tpl := range templates {
+		if matchRouteTemplate(tpl, path) {
+			return context.WithValue(ctx, routeKey{}, tpl)
+		}
+	}
+	return ctx
+}
+
+func matchRouteTemplate(template, path string) bool {
+	want := strings.Split(strings.Trim(template, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, seg := range want {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			continue
+		}
+		if seg != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// routeLabel is the low-cardinality name used in metrics, spans and logs.
+func routeLabel(req *http.Request) string {
+	if route, ok := req.Context().Value(routeKey{}).(string); ok {
+		return route
+	}
+	return req.URL.Path
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExpandPath(t *testing.T) {
+	got, err := expandPath("/users/{id}/orders/{orderID}", Params{"id": 42, "orderID": "a/b c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "/users/42/orders/a%2Fb%20c"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if _, err := expandPath("/users/{id}", Params{}); err == nil {
+		t.Error("expected error for missing parameter")
+	}
+	if _, err := expandPath("/users/{id", Params{"id": 1}); err == nil {
+		t.Error("expected error for unterminated placeholder")
+	}
+}
+
+func TestParamsUseTemplateAsMetricLabel(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithMetrics(&MetricsConfig{Namespace: "params_test"}),
+	)
+	for _, id := range []string{"1", "2"} {
+		if _, err := c.Get(context.Background(), "/users/{id}", nil, Params{"id": id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if gotPath != "/users/2" {
+		t.Errorf("expected expanded path on the wire, got %q", gotPath)
+	}
+
+	mw := MetricsMiddleware(&MetricsConfig{Namespace: "params_test"})
+	total := mw(nil).(*metricsTransport).requestsTotal
+	host := srv.Listener.Addr().String()
+	if got := testutil.ToFloat64(total.WithLabelValues("GET", host, "/users/{id}", "200")); got != 2 {
+		t.Errorf("expected both calls under the template label, got %v", got)
+	}
+	if got := testutil.CollectAndCount(total); got != 1 {
+		t.Errorf("expected a single label set, got %d", got)
+	}
+}
+
+func TestRouteNameAndTemplatesAsMetricLabel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithEndpointConfig(func(method, path string) *EndpointSettings {
+			if strings.HasPrefix(path, "/accounts/") {
+				return &EndpointSettings{Name: "get_account", Timeout: 5 * time.Second}
+			}
+			return nil
+		}),
+		WithRouteTemplates("/users/{id}/orders/{orderID}"),
+		WithMetrics(&MetricsConfig{Namespace: "route_name_test"}),
+	)
+	for _, path := range []string{"/users/123/orders/987", "/users/456/orders/1", "/accounts/9", "/accounts/10"} {
+		if _, err := c.Get(context.Background(), path, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	mw := MetricsMiddleware(&MetricsConfig{Namespace: "route_name_test"})
+	total := mw(nil).(*metricsTransport).requestsTotal
+	host := srv.Listener.Addr().String()
+	if got := testutil.ToFloat64(total.WithLabelValues("GET", host, "/users/{id}/orders/{orderID}", "200")); got != 2 {
+		t.Errorf("expected matched template label, got %v", got)
+	}
+	if got := testutil.ToFloat64(total.WithLabelValues("GET", host, "get_account", "200")); got != 2 {
+		t.Errorf("expected endpoint name label, got %v", got)
+	}
+	if got := testutil.CollectAndCount(total); got != 2 {
+		t.Errorf("expected two label sets, got %d", got)
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolMetrics reports how connections are opened and handed out by the
+// transport, to size MaxConnsPerHost and the idle pool.
+type poolMetrics struct {
+	open     *prometheus.GaugeVec
+	acquired *prometheus.CounterVec
+	wait     *prometheus.HistogramVec
+}
+
+func newPoolMetrics(config *MetricsConfig) *poolMetrics {
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = "http_client"
+	}
+	m := &poolMetrics{
+		open: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "pool_open_connections",
+				Help:      "Number of open connections per host",
+			},
+			[]string{"host"},
+		),
+		acquired: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "pool_connections_acquired_total",
+				Help:      "Connections handed to requests, by whether they were reused",
+			},
+			[]string{"host", "reused"},
+		),
+		wait: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "pool_conn_wait_seconds",
+				Help:      "Time spent waiting for a connection from the pool",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"host"},
+		),
+	}
+	m.open = registerOrReuse(m.open).(*prometheus.GaugeVec)
+	m.acquired = registerOrReuse(m.acquired).(*prometheus.CounterVec)
+	m.wait = registerOrReuse(m.wait).(*prometheus.HistogramVec)
+	return m
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (m *poolMetrics) dial(next dialFunc) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		gauge := m.open.WithLabelValues(addr)
+		gauge.Inc()
+		return &trackedConn{Conn: conn, onClose: gauge.Dec}, nil
+	}
+}
+
+func (m *poolMetrics) wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var (
+			start    time.Time
+			hostPort string
+		)
+		trace := &httptrace.ClientTrace{
+			GetConn: func(hp string) {
+				start = time.Now()
+				hostPort = hp
+			},
+			GotConn: func(info httptrace.GotConnInfo) {
+				m.acquired.WithLabelValues(hostPort, strconv.FormatBool(info.Reused)).Inc()
+				m.wait.WithLabelValues(hostPort).Observe(time.Since(start).Seconds())
+			},
+		}
+		return next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	})
+}
+
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	onClose func()
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.onClose)
+	return err
+}
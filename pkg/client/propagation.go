@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fsandov/go-sdk/pkg/tokens"
+)
+
+// inboundHeadersContextKey is the context key holding the headers of the
+// inbound request, set by pkg/web. Defined here to prevent a circular import.
+type inboundHeadersContextKey struct{}
+
+// InboundHeadersContextKey is used to propagate inbound request headers
+// through context.Context.
+var InboundHeadersContextKey = inboundHeadersContextKey{}
+
+// DefaultPropagatedHeaders are copied by PropagationMiddleware when no
+// allowlist is given.
+var DefaultPropagatedHeaders = []string{
+	"X-Request-ID",
+	"Traceparent",
+	"Tracestate",
+	"X-Tenant-ID",
+	"Accept-Language",
+}
+
+// WithInboundHeaders stores the headers of an inbound request in ctx, for
+// servers that do not use pkg/web.
+func WithInboundHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, InboundHeadersContextKey, h)
+}
+
+// PropagationMiddleware copies the allowlisted headers from the inbound
+// request context to outbound requests. Values are looked up in the inbound
+// headers, then in the IP headers, request ID, user ID and authorization
+// stored by the pkg/web and pkg/tokens middlewares. Headers already set on
+// the outbound request are left untouched. A nil allowlist uses
+// DefaultPropagatedHeaders.
+//
+// Do not list CF-Connecting-IP: see IPPropagationMiddleware.
+func PropagationMiddleware(allowlist []string) Middleware {
+	if allowlist == nil {
+		allowlist = DefaultPropagatedHeaders
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			for _, name := range allowlist {
+				if req.Header.Get(name) != "" {
+					continue
+				}
+				if v := propagatedValue(ctx, name); v != "" {
+					req.Header.Set(name, v)
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func propagatedValue(ctx context.Context, name string) string {
+	if h, ok := ctx.Value(InboundHeadersContextKey).(http.Header); ok {
+		if v := h.Get(name); v != "" {
+			return v
+		}
+	}
+	if v := getHeaderFromContext(ctx, name); v != "" {
+		return v
+	}
+	switch http.CanonicalHeaderKey(name) {
+	case "X-Request-Id":
+		id, _ := ctx.Value(RequestIDContextKey{}).(string)
+		return id
+	case "X-User-Id":
+		uid, _ := ctx.Value(UserIDContextKey).(string)
+		return uid
+	case "Authorization":
+		token, _ := ctx.Value(tokens.AuthContextKey).(string)
+		return token
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/tokens"
+)
+
+func TestPropagationMiddleware(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithMiddleware(PropagationMiddleware([]string{"X-Tenant-ID", "Accept-Language", "X-Client-IP", "X-Request-ID", "Authorization", "X-Locale"})),
+	)
+
+	inbound := http.Header{}
+	inbound.Set("X-Tenant-ID", "acme")
+	inbound.Set("Accept-Language", "es-CL")
+	inbound.Set("Cookie", "session=secret")
+	ctx := WithInboundHeaders(context.Background(), inbound)
+	ctx = context.WithValue(ctx, IPHeadersContextKey, map[string]string{"X-Client-IP": "203.0.113.7"})
+	ctx = context.WithValue(ctx, RequestIDContextKey{}, "req-1")
+	ctx = context.WithValue(ctx, tokens.AuthContextKey, "Bearer abc")
+
+	if _, err := c.Get(ctx, "/", map[string]string{"Accept-Language": "en"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"X-Tenant-Id":     "acme",
+		"Accept-Language": "en",
+		"X-Client-Ip":     "203.0.113.7",
+		"X-Request-Id":    "req-1",
+		"Authorization":   "Bearer abc",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got.Get(k))
+		}
+	}
+	if got.Get("Cookie") != "" || got.Get("X-Locale") != "" {
+		t.Errorf("expected only allowlisted, known headers, got %v", got)
+	}
+}
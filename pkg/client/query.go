@@ -0,0 +1,168 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query builds query strings without manual concatenation. It can be passed
+// directly as a RequestOption:
+//
+//	c.Get(ctx, "/users", nil, client.NewQuery().Set("page", 2).Set("limit", 50))
+type Query struct {
+	values url.Values
+}
+
+func NewQuery() *Query {
+	return &Query{values: url.Values{}}
+}
+
+// Set replaces the values of key. Nil pointers are skipped.
+func (q *Query) Set(key string, value any) *Query {
+	if s, ok := formatQueryValue(reflect.ValueOf(value)); ok {
+		q.values.Set(key, s)
+	}
+	return q
+}
+
+// Add appends a value to key. Nil pointers are skipped.
+func (q *Query) Add(key string, value any) *Query {
+	if s, ok := formatQueryValue(reflect.ValueOf(value)); ok {
+		q.values.Add(key, s)
+	}
+	return q
+}
+
+// Values returns a copy of the accumulated parameters.
+func (q *Query) Values() url.Values {
+	out := make(url.Values, len(q.values))
+	for k, v := range q.values {
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+func (q *Query) Encode() string { return q.values.Encode() }
+
+func (q *Query) applyRequest(o *requestOptions) { WithQuery(q.values).applyRequest(o) }
+
+// WithQuery adds query parameters to the request URL.
+func WithQuery(values url.Values) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		merged := url.Values{}
+		for k, v := range o.query {
+			merged[k] = append(merged[k], v...)
+		}
+		for k, v := range values {
+			merged[k] = append(merged[k], v...)
+		}
+		o.query = merged
+	})
+}
+
+// EncodeQuery converts a struct into query parameters using `query` tags:
+//
+//	type ListUsers struct {
+//		Page   int      `query:"page"`
+//		Limit  int      `query:"limit,omitempty"`
+//		Tags   []string `query:"tag"`
+//		Secret string   `query:"-"`
+//	}
+//
+// Untagged exported fields use their name, slices repeat the key, nil pointers
+// are skipped and time.Time is encoded as RFC 3339.
+func EncodeQuery(v any) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("EncodeQuery: expected struct, got %s", rv.Kind())
+	}
+	values := url.Values{}
+	encodeStruct(rv, values)
+	return values, nil
+}
+
+func encodeStruct(rv reflect.Value, values url.Values) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("query")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		omitEmpty := opts == "omitempty"
+		fv := rv.Field(i)
+
+		if field.Anonymous && tag == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				encodeStruct(fv, values)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+			for j := 0; j < fv.Len(); j++ {
+				if s, ok := formatQueryValue(fv.Index(j)); ok {
+					values.Add(name, s)
+				}
+			}
+			continue
+		}
+		if s, ok := formatQueryValue(fv); ok {
+			values.Add(name, s)
+		}
+	}
+}
+
+func formatQueryValue(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", false
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339), true
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), true
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	default:
+		return fmt.Sprint(v.Interface()), true
+	}
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestEncodeQuery(t *testing.T) {
+	type Paging struct {
+		Page  int `query:"page"`
+		Limit int `query:"limit,omitempty"`
+	}
+	type ListUsers struct {
+		Paging
+		Status *string   `query:"status"`
+		Tags   []string  `query:"tag"`
+		Since  time.Time `query:"since,omitempty"`
+		Active bool
+		Secret string `query:"-"`
+	}
+	active := "active"
+	values, err := EncodeQuery(ListUsers{
+		Paging: Paging{Page: 2},
+		Status: &active,
+		Tags:   []string{"a", "b"},
+		Active: true,
+		Secret: "x",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := url.Values{"page": {"2"}, "status": {"active"}, "tag": {"a", "b"}, "Active": {"true"}}
+	if values.Encode() != want.Encode() {
+		t.Errorf("expected %q, got %q", want.Encode(), values.Encode())
+	}
+
+	if _, err := EncodeQuery("not a struct"); err == nil {
+		t.Error("expected error for non-struct input")
+	}
+}
+
+func TestQueryRequestOption(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+	_, err := c.Get(context.Background(), "/users?sort=name", nil,
+		NewQuery().Set("page", 2).Set("limit", 50),
+		WithQuery(url.Values{"tag": {"x", "y"}}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Get("sort") != "name" || got.Get("page") != "2" || got.Get("limit") != "50" || len(got["tag"]) != 2 {
+		t.Errorf("unexpected query: %v", got)
+	}
+}
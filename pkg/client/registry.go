@@ -0,0 +1,303 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/config"
+	"github.com/sony/gobreaker"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRegistryKey is the AppConfig.Extras entry read by
+// NewRegistryFromAppConfig.
+const DefaultRegistryKey = "http_clients"
+
+// Duration reads "1.5s" style strings, or nanoseconds as a plain number.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		n, numErr := strconv.ParseInt(string(data), 10, 64)
+		if numErr != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ClientConfig declares a named client for Registry.
+type ClientConfig struct {
+	BaseURL         string            `json:"base_url"`
+	Timeout         Duration          `json:"timeout"`
+	MaxRetries      int               `json:"max_retries"`
+	RetryBackoff    Duration          `json:"retry_backoff"`
+	RetryBackoffMax Duration          `json:"retry_backoff_max"`
+	Headers         map[string]string `json:"headers"`
+	// RateLimit in requests per second, with Burst defaulting to 1.
+	RateLimit float64              `json:"rate_limit"`
+	Burst     int                  `json:"burst"`
+	Breaker   *ClientBreakerConfig `json:"breaker"`
+	Cache     *ClientCacheConfig   `json:"cache"`
+	Metrics   bool                 `json:"metrics"`
+	Tracing   bool                 `json:"tracing"`
+}
+
+// ClientBreakerConfig opens a client-wide breaker after Failures
+// consecutive failures, for OpenFor (60s by default).
+type ClientBreakerConfig struct {
+	Failures uint32   `json:"failures"`
+	OpenFor  Duration `json:"open_for"`
+}
+
+// ClientCacheConfig caches GET 200 responses for TTL in the registry cache.
+type ClientCacheConfig struct {
+	TTL Duration `json:"ttl"`
+}
+
+// Registry builds named clients from declarative configuration and caches
+// them, so services share one setup per upstream:
+//
+//	registry, err := client.NewRegistryFromAppConfig(config.Get())
+//	payments := registry.MustGet("payments")
+type Registry struct {
+	mu       sync.Mutex
+	configs  map[string]ClientConfig
+	extra    map[string][]func(*options)
+	clients  map[string]*Client
+	cache    cache.Cache
+	breakers *BreakerRegistry
+}
+
+func NewRegistry(configs map[string]ClientConfig) *Registry {
+	if configs == nil {
+		configs = map[string]ClientConfig{}
+	}
+	return &Registry{
+		configs: configs,
+		extra:   map[string][]func(*options){},
+		clients: map[string]*Client{},
+	}
+}
+
+// NewRegistryFromAppConfig reads the client configs from
+// AppConfig.Extras[DefaultRegistryKey], given either as a map or as a YAML
+// string.
+func NewRegistryFromAppConfig(app *config.AppConfig) (*Registry, error) {
+	raw, ok := app.Extras[DefaultRegistryKey]
+	if !ok {
+		return NewRegistry(nil), nil
+	}
+	if s, ok := raw.(string); ok {
+		return NewRegistryFromYAML([]byte(s))
+	}
+	configs, err := decodeClientConfigs(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(configs), nil
+}
+
+// NewRegistryFromYAML reads a YAML mapping of client names to configs.
+func NewRegistryFromYAML(data []byte) (*Registry, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("client registry: %w", err)
+	}
+	configs, err := decodeClientConfigs(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(configs), nil
+}
+
+func decodeClientConfigs(raw any) (map[string]ClientConfig, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("client registry: %w", err)
+	}
+	var configs map[string]ClientConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("client registry: %w", err)
+	}
+	return configs, nil
+}
+
+// WithCacheBackend sets the store used by clients that declare a cache.
+// Defaults to an in-memory cache.
+func (r *Registry) WithCacheBackend(c cache.Cache) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = c
+	return r
+}
+
+// WithBreakers makes clients that declare a breaker register it in breakers,
+// e.g. to expose them on an ops endpoint.
+func (r *Registry) WithBreakers(breakers *BreakerRegistry) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.breakers = breakers
+	return r
+}
+
+// Register adds or replaces a named config, with extra options applied after
+// the declarative ones. It must be called before the client is first used.
+func (r *Registry) Register(name string, cfg ClientConfig, opts ...func(*options)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configs[name] = cfg
+	r.extra[name] = opts
+}
+
+// Options adds programmatic options, such as middlewares, to a configured
+// client before it is first used.
+func (r *Registry) Options(name string, opts ...func(*options)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extra[name] = append(r.extra[name], opts...)
+}
+
+// Get returns the named client, building it on first use.
+func (r *Registry) Get(name string) (*Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clients[name]; ok {
+		return c, nil
+	}
+	cfg, ok := r.configs[name]
+	if !ok {
+		return nil, fmt.Errorf("client registry: unknown client %q", name)
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("client registry: client %q has no base_url", name)
+	}
+	c := NewClient(append(r.buildOptions(name, cfg), r.extra[name]...)...)
+	r.clients[name] = c
+	return c, nil
+}
+
+// MustGet is Get for clients that must exist at startup; it panics otherwise.
+func (r *Registry) MustGet(name string) *Client {
+	c, err := r.Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Names lists the configured clients.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.configs))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every client built so far.
+func (r *Registry) Close() {
+	r.mu.Lock()
+	clients := r.clients
+	r.clients = map[string]*Client{}
+	r.mu.Unlock()
+	for _, c := range clients {
+		c.Close()
+	}
+}
+
+func (r *Registry) buildOptions(name string, cfg ClientConfig) []func(*options) {
+	settings := &EndpointSettings{
+		Timeout:    time.Duration(cfg.Timeout),
+		MaxRetries: cfg.MaxRetries,
+		Headers:    map[string]string{},
+	}
+	for k, v := range cfg.Headers {
+		settings.Headers[k] = v
+	}
+	if cfg.RetryBackoff > 0 {
+		maxBackoff := time.Duration(cfg.RetryBackoffMax)
+		if maxBackoff <= 0 {
+			maxBackoff = 10 * time.Duration(cfg.RetryBackoff)
+		}
+		settings.BackoffStrategy = BackoffExponential(time.Duration(cfg.RetryBackoff), maxBackoff)
+	}
+	opts := []func(*options){WithBaseURL(cfg.BaseURL)}
+
+	if cfg.Tracing {
+		opts = append(opts, WithTracing(DefaultTracingConfig()))
+	}
+	if cfg.Metrics {
+		opts = append(opts, WithMetrics(&MetricsConfig{}))
+	}
+	if cfg.RateLimit > 0 {
+		burst := cfg.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter := rate.NewLimiter(rate.Limit(cfg.RateLimit), burst)
+		opts = append(opts, WithRateLimit(&RateLimitConfig{
+			LimiterFor: func(string, string) *rate.Limiter { return limiter },
+		}))
+	}
+	if cfg.Breaker != nil {
+		opts = append(opts, WithCircuitBreaker(&CircuitBreakerConfig{BreakerFor: r.breakerFor(name, cfg.Breaker)}))
+	}
+	if cfg.Cache != nil {
+		if r.cache == nil {
+			r.cache = cache.NewMemoryCache()
+		}
+		settings.EnableCache = true
+		settings.CacheTTL = time.Duration(cfg.Cache.TTL)
+		opts = append(opts, WithCache(&CacheConfig{
+			Cache:       r.cache,
+			DefaultTTL:  time.Minute,
+			Methods:     []string{http.MethodGet},
+			StatusCodes: []int{http.StatusOK},
+		}))
+	}
+	return append(opts, WithDefaultSettings(settings))
+}
+
+// breakerFor returns a single breaker shared by every request of the client.
+func (r *Registry) breakerFor(name string, cfg *ClientBreakerConfig) func(method, path string) *gobreaker.CircuitBreaker {
+	failures := cfg.Failures
+	if failures == 0 {
+		failures = 5
+	}
+	openFor := time.Duration(cfg.OpenFor)
+	if openFor <= 0 {
+		openFor = time.Minute
+	}
+	breakers := r.breakers
+	if breakers == nil {
+		breakers = NewBreakerRegistry(nil)
+	}
+	cb := breakers.getWith(name, gobreaker.Settings{
+		Timeout:     openFor,
+		ReadyToTrip: func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= failures },
+	})
+	return func(string, string) *gobreaker.CircuitBreaker { return cb }
+}
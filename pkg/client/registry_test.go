@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/config"
+)
+
+func TestRegistryFromYAML(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("X-Api-Version") != "2" {
+			t.Errorf("expected configured header, got %q", r.Header.Get("X-Api-Version"))
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	registry, err := NewRegistryFromYAML([]byte(`
+payments:
+  base_url: ` + srv.URL + `
+  timeout: 2s
+  max_retries: 1
+  retry_backoff: 10ms
+  headers:
+    X-Api-Version: "2"
+  breaker:
+    failures: 3
+    open_for: 30s
+  cache:
+    ttl: 1m
+users:
+  timeout: 1s
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	breakers := NewBreakerRegistry(nil)
+	registry.WithBreakers(breakers)
+	defer registry.Close()
+
+	payments := registry.MustGet("payments")
+	if again, _ := registry.Get("payments"); again != payments {
+		t.Error("expected the client to be cached")
+	}
+	if got := payments.options.defaultSettings.Timeout; got != 2*time.Second {
+		t.Errorf("expected timeout from config, got %v", got)
+	}
+	for range 2 {
+		if _, err := payments.Get(context.Background(), "/charges", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("expected the second call to be served from cache, got %d upstream calls", got)
+	}
+	if snapshot := breakers.Snapshot(); len(snapshot) != 1 || snapshot[0].Name != "payments" {
+		t.Errorf("expected the payments breaker to be registered, got %+v", snapshot)
+	}
+
+	if _, err := registry.Get("users"); err == nil {
+		t.Error("expected an error for a client without base_url")
+	}
+	if _, err := registry.Get("unknown"); err == nil {
+		t.Error("expected an error for an unknown client")
+	}
+	if names := registry.Names(); len(names) != 2 || names[0] != "payments" {
+		t.Errorf("unexpected names %v", names)
+	}
+}
+
+func TestRegistryFromAppConfig(t *testing.T) {
+	app := &config.AppConfig{Extras: map[string]any{
+		DefaultRegistryKey: map[string]any{
+			"search": map[string]any{"base_url": "http://search", "timeout": "750ms", "rate_limit": 5},
+		},
+	}}
+	registry, err := NewRegistryFromAppConfig(app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := registry.MustGet("search")
+	if c.options.baseURL != "http://search" || c.options.defaultSettings.Timeout != 750*time.Millisecond {
+		t.Errorf("unexpected client options: %s %v", c.options.baseURL, c.options.defaultSettings.Timeout)
+	}
+
+	if _, err := NewRegistryFromAppConfig(&config.AppConfig{Extras: map[string]any{
+		DefaultRegistryKey: map[string]any{"bad": map[string]any{"timeout": "soon"}},
+	}}); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
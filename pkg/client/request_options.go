@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// RequestOption adjusts a single call made through Get, Post, Put, Patch,
+// Delete or Head, on top of the endpoint settings.
+type RequestOption interface {
+	applyRequest(*requestOptions)
+}
+
+type requestOptionFunc func(*requestOptions)
+
+func (f requestOptionFunc) applyRequest(o *requestOptions) { f(o) }
+
+type requestOptions struct {
+	timeout     time.Duration
+	noRetry     bool
+	cacheBypass bool
+	params      Params
+	query       url.Values
+	jsonBody    any
+	hasJSONBody bool
+	// noTimeout lets long-lived streams outlive the endpoint timeout; they
+	// are bound by the caller's context instead.
+	noTimeout bool
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestTimeout overrides the endpoint timeout for this call.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) { o.timeout = d })
+}
+
+// WithNoRetry sends the request once, whatever the endpoint retry policy.
+func WithNoRetry() RequestOption {
+	return requestOptionFunc(func(o *requestOptions) { o.noRetry = true })
+}
+
+// WithCacheBypass skips the response cache for this call.
+func WithCacheBypass() RequestOption {
+	return requestOptionFunc(func(o *requestOptions) { o.cacheBypass = true })
+}
+
+// JSONBody encodes v as the request body and sets Content-Type to
+// application/json unless the call provides one. It replaces the body
+// argument of Post, Put and Patch:
+//
+//	c.Post(ctx, "/users", nil, nil, client.JSONBody(user))
+func JSONBody(v any) RequestOption {
+	return requestOptionFunc(func(o *requestOptions) {
+		o.jsonBody = v
+		o.hasJSONBody = true
+	})
+}
+
+// WithRequestOptions attaches per-request options to ctx, for callers that
+// build requests themselves and use Do.
+func WithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	ro := &requestOptions{}
+	if existing := requestOptionsFrom(ctx); existing != nil {
+		copied := *existing
+		ro = &copied
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.applyRequest(ro)
+		}
+	}
+	return context.WithValue(ctx, requestOptionsKey{}, ro)
+}
+
+func requestOptionsFrom(ctx context.Context) *requestOptions {
+	ro, _ := ctx.Value(requestOptionsKey{}).(*requestOptions)
+	return ro
+}
+
+// applyRequestOptions overrides cfg, a per-request copy, with the options
+// carried by ctx.
+func applyRequestOptions(ctx context.Context, cfg *EndpointSettings) {
+	ro := requestOptionsFrom(ctx)
+	if ro == nil {
+		return
+	}
+	if ro.timeout > 0 {
+		cfg.Timeout = ro.timeout
+	}
+	if ro.noRetry {
+		cfg.MaxRetries = 0
+		cfg.HedgeAfter = 0
+	}
+	if ro.cacheBypass {
+		cfg.EnableCache = false
+	}
+}
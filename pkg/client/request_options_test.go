@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func TestWithRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+	start := time.Now()
+	if _, err := c.Get(context.Background(), "/slow", nil, WithRequestTimeout(50*time.Millisecond), WithNoRetry()); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("expected per-request timeout to apply, took %v", elapsed)
+	}
+}
+
+func TestWithNoRetry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:         5 * time.Second,
+			MaxRetries:      3,
+			BackoffStrategy: func(int) time.Duration { return time.Millisecond },
+			Headers:         map[string]string{},
+		}),
+	)
+	_, _ = c.Get(context.Background(), "/", nil, WithNoRetry())
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	_, _ = c.Get(context.Background(), "/", nil)
+	if got := atomic.LoadInt32(&calls); got != 4 {
+		t.Errorf("expected endpoint retries without the option, got %d attempts", got)
+	}
+}
+
+func TestWithCacheBypass(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte("fresh"))
+	}))
+	defer srv.Close()
+
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, EnableCache: true, Headers: map[string]string{}}),
+		WithCache(&CacheConfig{Cache: store, DefaultTTL: time.Minute, Methods: []string{http.MethodGet}, StatusCodes: []int{http.StatusOK}}),
+	)
+	ctx := context.Background()
+	_, _ = c.Get(ctx, "/config", nil)
+	_, _ = c.Get(ctx, "/config", nil)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected second call to be served from cache, got %d upstream calls", got)
+	}
+	_, _ = c.Get(ctx, "/config", nil, WithCacheBypass())
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("expected cache bypass to reach upstream, got %d upstream calls", got)
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+const retryBudgetBuckets = 10
+
+// RetryBudgetConfig caps retries client-wide, across all endpoints, so an
+// outage of a dependency does not multiply outbound traffic. Within Window,
+// retries may not exceed Ratio of the requests sent; beyond that, up to
+// MinRetriesPerSecond retries are allowed in each second, which keeps
+// low-traffic clients able to retry without lifting the ratio for the
+// whole window.
+type RetryBudgetConfig struct {
+	// Ratio of retries to requests. Defaults to 0.2.
+	Ratio float64
+	// MinRetriesPerSecond allowed in any second once Ratio is exhausted.
+	// Defaults to 1; set a negative value to disable the floor.
+	MinRetriesPerSecond float64
+	// Window over which requests and retries are counted. Defaults to 10s.
+	Window time.Duration
+}
+
+func (c *RetryBudgetConfig) applyDefaults() {
+	if c.Ratio <= 0 {
+		c.Ratio = 0.2
+	}
+	if c.MinRetriesPerSecond == 0 {
+		c.MinRetriesPerSecond = 1
+	}
+	if c.MinRetriesPerSecond < 0 {
+		c.MinRetriesPerSecond = 0
+	}
+	if c.Window <= 0 {
+		c.Window = 10 * time.Second
+	}
+}
+
+func WithRetryBudget(cfg *RetryBudgetConfig) func(*options) {
+	return func(o *options) { o.retryBudget = newRetryBudget(cfg) }
+}
+
+// retryBudget counts requests and retries in a ring of buckets covering the
+// window, so old traffic ages out gradually.
+type retryBudget struct {
+	config *RetryBudgetConfig
+	width  time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	requests [retryBudgetBuckets]int
+	retries  [retryBudgetBuckets]int
+	epochs   [retryBudgetBuckets]int64
+	// floorSecond and floorUsed count the retries granted by the floor in
+	// the current second.
+	floorSecond int64
+	floorUsed   int
+}
+
+func newRetryBudget(config *RetryBudgetConfig) *retryBudget {
+	if config == nil {
+		config = &RetryBudgetConfig{}
+	}
+	config.applyDefaults()
+	return &retryBudget{
+		config: config,
+		// A Window shorter than retryBudgetBuckets nanoseconds would give
+		// zero-width buckets.
+		width: max(config.Window/retryBudgetBuckets, 1),
+		now:   time.Now,
+	}
+}
+
+// bucket returns the slot for the current time, clearing it if it still
+// holds counts from a previous turn of the ring.
+func (b *retryBudget) bucket() int {
+	epoch := b.now().UnixNano() / int64(b.width)
+	i := int(epoch % retryBudgetBuckets)
+	if b.epochs[i] != epoch {
+		b.epochs[i] = epoch
+		b.requests[i] = 0
+		b.retries[i] = 0
+	}
+	return i
+}
+
+func (b *retryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests[b.bucket()]++
+}
+
+// withdraw reports whether a retry fits the budget and, if so, counts it.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	current := b.bucket()
+	oldest := b.epochs[current] - retryBudgetBuckets + 1
+	requests, retries := 0, 0
+	for i := range retryBudgetBuckets {
+		if b.epochs[i] >= oldest {
+			requests += b.requests[i]
+			retries += b.retries[i]
+		}
+	}
+	if float64(retries+1) > b.config.Ratio*float64(requests) && !b.withdrawFloor() {
+		return false
+	}
+	b.retries[current]++
+	return true
+}
+
+// withdrawFloor grants a retry from the per-second floor.
+func (b *retryBudget) withdrawFloor() bool {
+	second := b.now().Unix()
+	if second != b.floorSecond {
+		b.floorSecond = second
+		b.floorUsed = 0
+	}
+	if float64(b.floorUsed+1) > b.config.MinRetriesPerSecond {
+		return false
+	}
+	b.floorUsed++
+	return true
+}
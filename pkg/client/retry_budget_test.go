@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudgetWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newRetryBudget(&RetryBudgetConfig{Ratio: 0.5, MinRetriesPerSecond: -1, Window: 10 * time.Second})
+	b.now = func() time.Time { return now }
+
+	for range 4 {
+		b.recordRequest()
+	}
+	if !b.withdraw() || !b.withdraw() {
+		t.Fatal("expected two retries for four requests at ratio 0.5")
+	}
+	if b.withdraw() {
+		t.Error("expected the budget to be exhausted")
+	}
+
+	now = now.Add(11 * time.Second)
+	b.recordRequest()
+	b.recordRequest()
+	if !b.withdraw() {
+		t.Error("expected old traffic to age out of the window")
+	}
+	if b.withdraw() {
+		t.Error("expected only one retry for two recent requests")
+	}
+}
+
+func TestRetryBudgetFloor(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newRetryBudget(&RetryBudgetConfig{Ratio: 0.2, MinRetriesPerSecond: 2, Window: 10 * time.Second})
+	b.now = func() time.Time { return now }
+
+	if !b.withdraw() || !b.withdraw() {
+		t.Fatal("expected the floor to allow two retries without traffic")
+	}
+	if b.withdraw() {
+		t.Error("expected the floor to apply per second, not per window")
+	}
+	now = now.Add(time.Second)
+	if !b.withdraw() {
+		t.Error("expected the floor to renew every second")
+	}
+}
+
+func TestRetryBudgetShortWindow(t *testing.T) {
+	b := newRetryBudget(&RetryBudgetConfig{Window: 5 * time.Nanosecond})
+	b.recordRequest()
+	if !b.withdraw() {
+		t.Error("expected a retry from the floor")
+	}
+}
+
+func TestRetryBudgetLimitsRetriesAcrossRequests(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{
+			Timeout:         5 * time.Second,
+			MaxRetries:      3,
+			BackoffStrategy: func(int) time.Duration { return time.Millisecond },
+			Headers:         map[string]string{},
+		}),
+		WithRetryBudget(&RetryBudgetConfig{Ratio: 0.2, MinRetriesPerSecond: -1}),
+	)
+
+	for range 10 {
+		_, _ = c.Get(context.Background(), "/", nil)
+	}
+	// Without a budget 10 requests would cause 40 attempts.
+	if got := atomic.LoadInt32(&hits); got != 12 {
+		t.Errorf("expected 10 requests and 2 retries, got %d attempts", got)
+	}
+}
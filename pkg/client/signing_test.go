@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigningMiddleware(t *testing.T) {
+	secret := []byte("shared-secret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Signature-Key-Id") != "k1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(DefaultHMACCanonicalization(r, body, r.Header.Get("X-Signature-Timestamp"))))
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(r.Header.Get("X-Signature"))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithHMACSigning(&HMACSigningConfig{
+			KeyProvider: func(context.Context) (string, []byte, error) { return "k1", secret, nil },
+		}),
+	)
+
+	resp, err := c.Post(context.Background(), "/orders?page=2", []byte(`{"id":"42"}`), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := io.ReadAll(resp.Body); !strings.Contains(string(b), `"id":"42"`) {
+		t.Errorf("expected body to reach the server intact, got %q", b)
+	}
+}
+
+func TestSigV4Sign(t *testing.T) {
+	// Vectors from the AWS Signature Version 4 test suite.
+	cfg := &SigV4Config{Region: "us-east-1", Service: "service"}
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	at := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		url       string
+		signature string
+	}{
+		{"get-vanilla", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			cfg.sign(req, nil, creds, at)
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization mismatch\n got: %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSigV4SigningMiddlewareSessionToken(t *testing.T) {
+	var auth, token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		token = r.Header.Get("X-Amz-Security-Token")
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithSigV4(&SigV4Config{
+			Region:  "eu-west-1",
+			Service: "execute-api",
+			Credentials: func(context.Context) (AWSCredentials, error) {
+				return AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, nil
+			},
+		}),
+	)
+	if _, err := c.Get(context.Background(), "/items", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "session" {
+		t.Errorf("expected session token header, got %q", token)
+	}
+	if !strings.Contains(auth, "/eu-west-1/execute-api/aws4_request") || !strings.Contains(auth, "x-amz-security-token") {
+		t.Errorf("unexpected Authorization header: %q", auth)
+	}
+}
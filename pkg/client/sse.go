@@ -0,0 +1,168 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+const (
+	defaultSSERetry = 3 * time.Second
+	maxSSERetry     = time.Minute
+)
+
+// Event is a message received from a text/event-stream.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// Stream subscribes to a Server-Sent Events endpoint through the regular
+// middleware chain. Events are delivered on the returned channel, which is
+// closed once ctx is done. Dropped connections are re-established after the
+// server-provided retry delay, with exponential backoff while reconnects keep
+// failing, and resume from the last received event ID. Only the first
+// connection error is returned; later ones are logged.
+func (c *Client) Stream(ctx context.Context, path string, opts ...RequestOption) (<-chan Event, error) {
+	s := &sseStream{
+		client: c,
+		path:   path,
+		opts:   opts,
+		events: make(chan Event),
+		retry:  defaultSSERetry,
+	}
+	first := make(chan error, 1)
+	go s.run(ctx, first)
+	if err := <-first; err != nil {
+		return nil, err
+	}
+	return s.events, nil
+}
+
+type sseStream struct {
+	client      *Client
+	path        string
+	opts        []RequestOption
+	events      chan Event
+	lastEventID string
+	retry       time.Duration
+}
+
+func (s *sseStream) run(ctx context.Context, first chan<- error) {
+	defer close(s.events)
+	failures := 0
+	for {
+		connected := false
+		err := s.connect(ctx, func() {
+			connected = true
+			if first != nil {
+				first <- nil
+				first = nil
+			}
+		})
+		if first != nil {
+			first <- err
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			failures = 0
+		} else {
+			failures++
+		}
+		delay := BackoffExponential(s.retry, maxSSERetry)(failures)
+		if failures == 0 {
+			delay = s.retry
+		}
+		logs.Warn(ctx, "event stream disconnected, reconnecting", "path", s.path, "delay", delay.String(), "error", err)
+		if !sleepContext(ctx, delay) {
+			return
+		}
+	}
+}
+
+func (s *sseStream) connect(ctx context.Context, onConnect func()) error {
+	opts := append([]RequestOption{requestOptionFunc(func(o *requestOptions) { o.noTimeout = true })}, s.opts...)
+	ctx = WithRequestOptions(ctx, opts...)
+	ctx, path, err := withRoute(ctx, s.path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.client.options.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	_, err = s.client.do(ctx, req, func(resp *http.Response) error {
+		onConnect()
+		return s.read(ctx, resp)
+	})
+	return err
+}
+
+// read parses the stream as described by the HTML living standard and
+// dispatches events until the body ends.
+func (s *sseStream) read(ctx context.Context, resp *http.Response) error {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var (
+		eventType string
+		data      strings.Builder
+		hasData   bool
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if hasData {
+				ev := Event{ID: s.lastEventID, Event: eventType, Data: data.String()}
+				if ev.Event == "" {
+					ev.Event = "message"
+				}
+				select {
+				case s.events <- ev:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			eventType, hasData = "", false
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				s.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return scanner.Err()
+}
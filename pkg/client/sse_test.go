@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamReconnectsWithLastEventID(t *testing.T) {
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			fmt.Fprint(w, ": welcome\nretry: 10\nid: 1\ndata: first\n\n")
+		default:
+			if r.Header.Get("Last-Event-ID") != "1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, "id: 2\nevent: update\ndata: line one\ndata: line two\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 50 * time.Millisecond, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.Stream(ctx, "/events")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Event{
+		{ID: "1", Event: "message", Data: "first"},
+		{ID: "2", Event: "update", Data: "line one\nline two"},
+	}
+	for i, w := range want {
+		select {
+		case got := <-events:
+			if got != w {
+				t.Errorf("event %d: expected %+v, got %+v", i, w, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel to close after cancellation")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel not closed after cancellation")
+	}
+}
+
+func TestStreamReturnsInitialError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+	)
+	if _, err := c.Stream(context.Background(), "/missing"); err == nil {
+		t.Fatal("expected error when the first connection fails")
+	}
+}
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// ProxyDirect as EndpointSettings.Proxy sends the endpoint's requests without a proxy.
+const ProxyDirect = "direct"
+
+// WithProxy routes requests through an http, https or socks5 proxy URL.
+func WithProxy(proxyURL string) func(*options) {
+	return func(o *options) {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			o.configErr = fmt.Errorf("invalid proxy URL %q", proxyURL)
+			return
+		}
+		o.proxy = http.ProxyURL(u)
+	}
+}
+
+// WithProxyFromEnvironment uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func WithProxyFromEnvironment() func(*options) {
+	return func(o *options) { o.proxy = http.ProxyFromEnvironment }
+}
+
+// WithTLSConfig sets the TLS configuration of the underlying transport.
+func WithTLSConfig(cfg *tls.Config) func(*options) {
+	return func(o *options) { o.tlsConfig = cfg }
+}
+
+// WithClientCert enables mutual TLS with a PEM certificate/key pair. caFile,
+// when not empty, replaces the system roots used to verify the server. Load
+// errors are reported by every request made with the client.
+func WithClientCert(certFile, keyFile, caFile string) func(*options) {
+	return func(o *options) {
+		cfg, err := loadClientTLS(certFile, keyFile, caFile)
+		if err != nil {
+			o.configErr = err
+			return
+		}
+		o.tlsConfig = cfg
+	}
+}
+
+func loadClientTLS(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// TransportConfig tunes the connection pool of the underlying transport.
+// Start from DefaultTransportConfig, since every field is applied as is.
+type TransportConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+	ForceAttemptHTTP2   bool
+	// PoolMetrics exports connection-pool metrics when set.
+	PoolMetrics *MetricsConfig
+}
+
+// DefaultTransportConfig returns the pool settings of http.DefaultTransport.
+func DefaultTransportConfig() *TransportConfig {
+	t := http.DefaultTransport.(*http.Transport)
+	return &TransportConfig{
+		MaxIdleConns:        t.MaxIdleConns,
+		MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     t.MaxConnsPerHost,
+		IdleConnTimeout:     t.IdleConnTimeout,
+		DisableKeepAlives:   t.DisableKeepAlives,
+		ForceAttemptHTTP2:   t.ForceAttemptHTTP2,
+	}
+}
+
+func WithTransportConfig(cfg *TransportConfig) func(*options) {
+	return func(o *options) { o.transportConfig = cfg }
+}
+
+// HTTPVersion selects the HTTP protocols a transport may use. The zero value
+// leaves the choice to the client, or for an endpoint to the client setting.
+type HTTPVersion int
+
+const (
+	// HTTPVersionAuto negotiates HTTP/2 over TLS and uses HTTP/1.1 otherwise.
+	HTTPVersionAuto HTTPVersion = iota + 1
+	// HTTPVersion1 disables HTTP/2, e.g. for upstreams with broken support.
+	HTTPVersion1
+	// HTTPVersion2 only speaks HTTP/2 over TLS.
+	HTTPVersion2
+	// HTTPVersionH2C speaks HTTP/2 with prior knowledge over cleartext, as
+	// internal gRPC-gateway style services expect, and HTTP/2 over TLS.
+	HTTPVersionH2C
+)
+
+// WithHTTPVersion sets the protocols used by the client. Endpoints can
+// override it with EndpointSettings.HTTPVersion.
+func WithHTTPVersion(v HTTPVersion) func(*options) {
+	return func(o *options) { o.httpVersion = v }
+}
+
+func applyHTTPVersion(t *http.Transport, v HTTPVersion) {
+	var p http.Protocols
+	switch v {
+	case HTTPVersion1:
+		p.SetHTTP1(true)
+		t.ForceAttemptHTTP2 = false
+	case HTTPVersion2:
+		p.SetHTTP2(true)
+		t.ForceAttemptHTTP2 = true
+	case HTTPVersionH2C:
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+		t.ForceAttemptHTTP2 = true
+	default:
+		return
+	}
+	t.Protocols = &p
+}
+
+// versionRouter sends requests of endpoints overriding the HTTP version to a
+// clone of the base transport configured for it.
+type versionRouter struct {
+	base    *http.Transport
+	version HTTPVersion
+
+	mu       sync.Mutex
+	variants map[HTTPVersion]*http.Transport
+}
+
+func newVersionRouter(base *http.Transport, v HTTPVersion) *versionRouter {
+	return &versionRouter{base: base, version: v, variants: map[HTTPVersion]*http.Transport{}}
+}
+
+func (r *versionRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg, ok := req.Context().Value(EndpointConfigKey{}).(*EndpointSettings)
+	if !ok || cfg.HTTPVersion == 0 || cfg.HTTPVersion == r.version {
+		return r.base.RoundTrip(req)
+	}
+	r.mu.Lock()
+	t, ok := r.variants[cfg.HTTPVersion]
+	if !ok {
+		t = r.base.Clone()
+		t.Protocols = nil
+		applyHTTPVersion(t, cfg.HTTPVersion)
+		r.variants[cfg.HTTPVersion] = t
+	}
+	r.mu.Unlock()
+	return t.RoundTrip(req)
+}
+
+func (r *versionRouter) closeIdleConnections() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.variants {
+		t.CloseIdleConnections()
+	}
+}
+
+// newBaseTransport returns the transport the middleware chain wraps. It is a
+// clone of http.DefaultTransport customised by the transport options.
+func newBaseTransport(o *options) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if o.tlsConfig != nil {
+		t.TLSClientConfig = o.tlsConfig.Clone()
+	}
+	if o.proxy != nil {
+		t.Proxy = o.proxy
+	}
+	t.Proxy = endpointProxy(t.Proxy)
+	if o.dnsCache != nil {
+		t.DialContext = o.dnsCache.dial(t.DialContext)
+	}
+	if cfg := o.transportConfig; cfg != nil {
+		t.MaxIdleConns = cfg.MaxIdleConns
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		t.MaxConnsPerHost = cfg.MaxConnsPerHost
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+		t.DisableKeepAlives = cfg.DisableKeepAlives
+		t.ForceAttemptHTTP2 = cfg.ForceAttemptHTTP2
+		if cfg.PoolMetrics != nil {
+			o.poolMetrics = newPoolMetrics(cfg.PoolMetrics)
+			t.DialContext = o.poolMetrics.dial(t.DialContext)
+		}
+	}
+	applyHTTPVersion(t, o.httpVersion)
+	return t
+}
+
+// endpointProxy honours EndpointSettings.Proxy before falling back to the
+// client-wide proxy.
+func endpointProxy(fallback func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if cfg, ok := req.Context().Value(EndpointConfigKey{}).(*EndpointSettings); ok && cfg.Proxy != "" {
+			if cfg.Proxy == ProxyDirect {
+				return nil, nil
+			}
+			u, err := url.Parse(cfg.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid endpoint proxy URL %q: %w", cfg.Proxy, err)
+			}
+			return u, nil
+		}
+		if fallback == nil {
+			return nil, nil
+		}
+		return fallback(req)
+	}
+}
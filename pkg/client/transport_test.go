@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile, cert
+}
+
+func TestWithClientCertMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeClientCert(t, dir)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "client" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(dir, "ca.crt")
+	_ = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600)
+
+	settings := &EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}
+	c := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(settings), WithClientCert(certFile, keyFile, caFile))
+	defer c.Close()
+	resp, err := c.Get(context.Background(), "/secure", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	noCert := NewClient(WithBaseURL(srv.URL), WithDefaultSettings(settings), WithTLSConfig(&tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}))
+	if _, err := noCert.Get(context.Background(), "/secure", nil); err == nil {
+		t.Error("expected handshake failure without a client certificate")
+	}
+}
+
+func TestWithClientCertLoadError(t *testing.T) {
+	c := NewClient(WithBaseURL("https://example.invalid"), WithClientCert("missing.crt", "missing.key", ""))
+	_, err := c.Get(context.Background(), "/", nil)
+	if err == nil {
+		t.Fatal("expected certificate load error to surface on requests")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("direct"))
+	}))
+	defer upstream.Close()
+
+	c := NewClient(
+		WithBaseURL(upstream.URL),
+		WithProxy(proxy.URL),
+		WithEndpointConfig(func(method, path string) *EndpointSettings {
+			s := &EndpointSettings{Timeout: 5 * time.Second, Headers: map[string]string{}}
+			if path == "/health" {
+				s.Proxy = ProxyDirect
+			}
+			return s
+		}),
+	)
+	defer c.Close()
+
+	resp, err := c.Get(context.Background(), "/users", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := io.ReadAll(resp.Body); string(b) != "via proxy" {
+		t.Errorf("expected request to go through the proxy, got %q", b)
+	}
+	if len(proxied) != 1 || proxied[0] != upstream.URL+"/users" {
+		t.Errorf("expected proxy to see the absolute upstream URL, got %v", proxied)
+	}
+
+	resp, err = c.Get(context.Background(), "/health", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := io.ReadAll(resp.Body); string(b) != "direct" {
+		t.Errorf("expected endpoint override to bypass the proxy, got %q", b)
+	}
+}
+
+func TestWithProxyInvalidURL(t *testing.T) {
+	c := NewClient(WithBaseURL("http://example.invalid"), WithProxy("://bad"))
+	if _, err := c.Get(context.Background(), "/", nil); err == nil {
+		t.Fatal("expected invalid proxy URL to surface on requests")
+	}
+}
+
+func TestWithTransportConfigPoolMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := DefaultTransportConfig()
+	cfg.MaxConnsPerHost = 4
+	cfg.PoolMetrics = &MetricsConfig{Namespace: "pool_test"}
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+		WithTransportConfig(cfg),
+	)
+	if c.transport.MaxConnsPerHost != 4 || c.transport.IdleConnTimeout != cfg.IdleConnTimeout {
+		t.Errorf("expected transport config to be applied, got MaxConnsPerHost=%d", c.transport.MaxConnsPerHost)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(context.Background(), "/", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	host := srv.Listener.Addr().String()
+	if got := testutil.ToFloat64(c.options.poolMetrics.acquired.WithLabelValues(host, "false")); got != 1 {
+		t.Errorf("expected 1 new connection, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.options.poolMetrics.acquired.WithLabelValues(host, "true")); got != 2 {
+		t.Errorf("expected 2 reused connections, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.options.poolMetrics.open.WithLabelValues(host)); got != 1 {
+		t.Errorf("expected 1 open connection, got %v", got)
+	}
+	c.Close()
+	if got := testutil.ToFloat64(c.options.poolMetrics.open.WithLabelValues(host)); got != 0 {
+		t.Errorf("expected open connections to drop to 0 after Close, got %v", got)
+	}
+}
+
+func TestHTTPVersionH2C(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	settings := &EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(settings),
+		WithHTTPVersion(HTTPVersionH2C),
+		WithEndpointConfig(func(method, path string) *EndpointSettings {
+			if path == "/legacy" {
+				return &EndpointSettings{Timeout: 5 * time.Second, HTTPVersion: HTTPVersion1}
+			}
+			return nil
+		}),
+	)
+	defer c.Close()
+
+	for path, want := range map[string]string{"/": "HTTP/2.0", "/legacy": "HTTP/1.1"} {
+		resp, err := c.Get(context.Background(), path, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != want {
+			t.Errorf("%s: expected %s, got %s", path, want, body)
+		}
+	}
+}
+
+func TestHTTPVersionDisablesHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	for version, want := range map[HTTPVersion]string{HTTPVersionAuto: "HTTP/2.0", HTTPVersion1: "HTTP/1.1"} {
+		c := NewClient(
+			WithBaseURL(srv.URL),
+			WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}),
+			WithTLSConfig(&tls.Config{RootCAs: roots}),
+			WithHTTPVersion(version),
+		)
+		resp, err := c.Get(context.Background(), "/", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != want {
+			t.Errorf("version %d: expected %s, got %s", version, want, body)
+		}
+		c.Close()
+	}
+}
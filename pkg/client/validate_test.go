@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestValidateResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/1" {
+			_, _ = w.Write([]byte(`{"id":1,"email":"ada@example.com"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":2}`))
+	}))
+	defer srv.Close()
+
+	requireEmail := func(resp *http.Response, body []byte) error {
+		var user struct {
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal(body, &user); err != nil {
+			return err
+		}
+		if user.Email == "" {
+			return errors.New("missing email")
+		}
+		return nil
+	}
+	c := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(&EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}, ValidateResponse: requireEmail}),
+		WithRouteTemplates("/users/{id}"),
+		WithMetrics(&MetricsConfig{Namespace: "validate_test"}),
+	)
+
+	if _, err := c.Get(context.Background(), "/users/1", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := c.Get(context.Background(), "/users/2", nil)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("expected ErrInvalidResponse, got %v", err)
+	}
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Err.Error() != "missing email" {
+		t.Errorf("expected *ValidationError with the cause, got %v", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the response to be returned alongside the error")
+	}
+	if errors.Is(err, ErrServer) || errors.Is(err, ErrClient) {
+		t.Errorf("did not expect a status category for a 200, got %v", err)
+	}
+
+	counter := newValidationErrorsMetric(&MetricsConfig{Namespace: "validate_test"})
+	host := srv.Listener.Addr().String()
+	if got := testutil.ToFloat64(counter.WithLabelValues("GET", host, "/users/{id}")); got != 1 {
+		t.Errorf("expected one validation error, got %v", got)
+	}
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrVCRNoMatch = errors.New("client: no recorded interaction matches the request")
+
+type VCRMode int
+
+const (
+	// VCRReplay serves recorded interactions and fails on unknown requests.
+	VCRReplay VCRMode = iota
+	// VCRRecord sends every request upstream and rewrites the cassette.
+	VCRRecord
+	// VCRReplayOrRecord replays known requests and records new ones.
+	VCRReplayOrRecord
+)
+
+// VCRConfig configures VCRMiddleware.
+type VCRConfig struct {
+	// Path of the JSON cassette holding the interactions.
+	Path string
+	Mode VCRMode
+	// Match decides whether a recorded request answers req. Defaults to
+	// comparing method, URL and body.
+	Match func(req *http.Request, body []byte, recorded *VCRRequest) bool
+	// RedactHeaders are stored as [REDACTED]. Defaults to Authorization,
+	// Proxy-Authorization, Cookie and X-Api-Key.
+	RedactHeaders []string
+}
+
+type VCRRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type VCRResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+type VCRInteraction struct {
+	Request  VCRRequest  `json:"request"`
+	Response VCRResponse `json:"response"`
+}
+
+type vcrCassette struct {
+	Interactions []VCRInteraction `json:"interactions"`
+}
+
+// VCRMiddleware records request/response pairs to a cassette file and
+// replays them, so tests can run against captured upstream behaviour without
+// the network. Recorded interactions are replayed in order; once all matches
+// are used, the last one keeps being served.
+func VCRMiddleware(config *VCRConfig) Middleware {
+	if config == nil {
+		config = &VCRConfig{}
+	}
+	if config.Match == nil {
+		config.Match = defaultVCRMatch
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+	}
+	v := &vcr{config: config, used: map[int]bool{}}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return v.roundTrip(next, req)
+		})
+	}
+}
+
+type vcr struct {
+	config   *VCRConfig
+	loadOnce sync.Once
+	loadErr  error
+
+	mu       sync.Mutex
+	cassette vcrCassette
+	used     map[int]bool
+}
+
+func (v *vcr) load() {
+	if v.config.Mode == VCRRecord {
+		return
+	}
+	data, err := os.ReadFile(v.config.Path)
+	if err != nil {
+		if v.config.Mode == VCRReplayOrRecord && errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		v.loadErr = fmt.Errorf("vcr: read cassette: %w", err)
+		return
+	}
+	if err := json.Unmarshal(data, &v.cassette); err != nil {
+		v.loadErr = fmt.Errorf("vcr: decode cassette: %w", err)
+	}
+}
+
+func (v *vcr) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	v.loadOnce.Do(v.load)
+	if v.loadErr != nil {
+		return nil, v.loadErr
+	}
+	body, err := signableBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.config.Mode != VCRRecord {
+		if rec, ok := v.find(req, body); ok {
+			return rec.toResponse(req), nil
+		}
+		if v.config.Mode == VCRReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrVCRNoMatch, req.Method, req.URL)
+		}
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readAndRestoreBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	v.record(VCRInteraction{
+		Request: VCRRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: v.redact(req.Header),
+			Body:   string(body),
+		},
+		Response: VCRResponse{
+			StatusCode: resp.StatusCode,
+			Header:     v.redact(resp.Header),
+			Body:       string(respBody),
+		},
+	})
+	if err := v.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (v *vcr) find(req *http.Request, body []byte) (*VCRResponse, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	last := -1
+	for i := range v.cassette.Interactions {
+		if !v.config.Match(req, body, &v.cassette.Interactions[i].Request) {
+			continue
+		}
+		last = i
+		if !v.used[i] {
+			v.used[i] = true
+			return &v.cassette.Interactions[i].Response, true
+		}
+	}
+	if last >= 0 {
+		return &v.cassette.Interactions[last].Response, true
+	}
+	return nil, false
+}
+
+func (v *vcr) record(i VCRInteraction) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cassette.Interactions = append(v.cassette.Interactions, i)
+	v.used[len(v.cassette.Interactions)-1] = true
+}
+
+func (v *vcr) save() error {
+	v.mu.Lock()
+	data, err := json.MarshalIndent(v.cassette, "", "  ")
+	v.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("vcr: encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(v.config.Path), 0o755); err != nil {
+		return fmt.Errorf("vcr: create cassette dir: %w", err)
+	}
+	if err := os.WriteFile(v.config.Path, data, 0o644); err != nil {
+		return fmt.Errorf("vcr: write cassette: %w", err)
+	}
+	return nil
+}
+
+func (v *vcr) redact(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range v.config.RedactHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+func (r *VCRResponse) toResponse(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+func defaultVCRMatch(req *http.Request, body []byte, recorded *VCRRequest) bool {
+	return req.Method == recorded.Method && req.URL.String() == recorded.URL && string(body) == recorded.Body
+}
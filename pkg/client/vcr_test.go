@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVCRRecordThenReplay(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "fixtures", "users.json")
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"name":"Ada"}`))
+	}))
+	settings := &EndpointSettings{Timeout: 5 * time.Second, MaxRetries: 0, Headers: map[string]string{}}
+
+	recorder := NewClient(
+		WithBaseURL(srv.URL),
+		WithDefaultSettings(settings),
+		WithVCR(&VCRConfig{Path: cassette, Mode: VCRRecord}),
+	)
+	if _, err := recorder.Get(context.Background(), "/users/1", map[string]string{"Authorization": "Bearer secret"}); err != nil {
+		t.Fatalf("record: unexpected error: %v", err)
+	}
+	baseURL := srv.URL
+	srv.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("expected cassette to be written: %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("expected Authorization to be redacted in the cassette")
+	}
+
+	player := NewClient(
+		WithBaseURL(baseURL),
+		WithDefaultSettings(settings),
+		WithVCR(&VCRConfig{Path: cassette, Mode: VCRReplay}),
+	)
+	resp, err := player.Get(context.Background(), "/users/1", nil)
+	if err != nil {
+		t.Fatalf("replay: unexpected error: %v", err)
+	}
+	if b, _ := io.ReadAll(resp.Body); string(b) != `{"id":1,"name":"Ada"}` || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected replayed response: %q %v", b, resp.Header)
+	}
+	if calls != 1 {
+		t.Errorf("expected replay to skip the network, got %d upstream calls", calls)
+	}
+
+	_, err = player.Get(context.Background(), "/users/2", nil)
+	if !errors.Is(err, ErrVCRNoMatch) {
+		t.Errorf("expected ErrVCRNoMatch for unrecorded request, got %v", err)
+	}
+}
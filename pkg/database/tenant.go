@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoTenant      = errors.New("database: no tenant in context")
+	ErrUnknownTenant = errors.New("database: unknown tenant")
+)
+
+type tenantContextKey struct{}
+
+// WithTenant stores the tenant of a request in ctx. pkg/web's
+// TenantMiddleware does it for inbound requests.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant stored by WithTenant.
+func TenantFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantContextKey{}).(string)
+	return id
+}
+
+// TenantDBs selects a connection pool per tenant, for database-per-tenant
+// deployments. Only tenants accepted by the validator get a pool; pools are
+// opened with Open on first use and kept until Close:
+//
+//	dbs := database.NewTenantDBs(database.AllowTenants("acme", "globex"), func(tenantID string) (database.Config, error) {
+//		cfg := database.DefaultMySqlConfig
+//		cfg.DBName = "app_" + tenantID
+//		return cfg, nil
+//	}, nil)
+//	db, err := dbs.DB(c.Request.Context())
+type TenantDBs struct {
+	allow     func(tenantID string) bool
+	configFor func(tenantID string) (Config, error)
+	opts      *Options
+
+	mu  sync.Mutex
+	dbs map[string]*tenantDB
+}
+
+// tenantDB is a pool being opened or open; ready is closed once db or err
+// is set, so concurrent requests for a tenant share one Open.
+type tenantDB struct {
+	ready chan struct{}
+	db    *gorm.DB
+	err   error
+}
+
+// AllowTenants is a TenantDBs validator accepting a fixed set of tenants.
+func AllowTenants(tenantIDs ...string) func(tenantID string) bool {
+	allowed := make(map[string]bool, len(tenantIDs))
+	for _, id := range tenantIDs {
+		allowed[id] = true
+	}
+	return func(tenantID string) bool { return allowed[tenantID] }
+}
+
+// NewTenantDBs builds the pool of each tenant accepted by allow from the
+// Config returned by configFor; opts are shared by every pool. allow is
+// required, since tenant IDs usually come from request headers or hosts.
+func NewTenantDBs(allow func(tenantID string) bool, configFor func(tenantID string) (Config, error), opts *Options) *TenantDBs {
+	if allow == nil {
+		panic("database: NewTenantDBs requires a tenant validator")
+	}
+	return &TenantDBs{
+		allow:     allow,
+		configFor: configFor,
+		opts:      opts,
+		dbs:       map[string]*tenantDB{},
+	}
+}
+
+// DB returns the pool of the tenant in ctx, bound to ctx. Pools of
+// different tenants are opened concurrently; a failed open is retried on
+// the next call.
+func (t *TenantDBs) DB(ctx context.Context) (*gorm.DB, error) {
+	tenantID := TenantFromContext(ctx)
+	if tenantID == "" {
+		return nil, ErrNoTenant
+	}
+	if !t.allow(tenantID) {
+		return nil, ErrUnknownTenant
+	}
+
+	t.mu.Lock()
+	entry, ok := t.dbs[tenantID]
+	if !ok {
+		entry = &tenantDB{ready: make(chan struct{})}
+		t.dbs[tenantID] = entry
+	}
+	t.mu.Unlock()
+
+	if !ok {
+		entry.db, entry.err = t.open(tenantID)
+		if entry.err != nil {
+			t.mu.Lock()
+			delete(t.dbs, tenantID)
+			t.mu.Unlock()
+		}
+		close(entry.ready)
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry.db.WithContext(ctx), nil
+}
+
+func (t *TenantDBs) open(tenantID string) (*gorm.DB, error) {
+	cfg, err := t.configFor(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return Open(cfg, t.opts)
+}
+
+// Close closes every pool opened so far.
+func (t *TenantDBs) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var errs []error
+	for tenantID, entry := range t.dbs {
+		select {
+		case <-entry.ready:
+		default:
+			continue // still opening
+		}
+		if entry.db != nil {
+			if sqlDB, err := entry.db.DB(); err == nil {
+				errs = append(errs, sqlDB.Close())
+			}
+		}
+		delete(t.dbs, tenantID)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTenantDBs(t *testing.T) {
+	dir := t.TempDir()
+	opened := map[string]int{}
+	dbs := NewTenantDBs(AllowTenants("acme", "globex", "unknown"), func(tenantID string) (Config, error) {
+		if tenantID == "unknown" {
+			return Config{}, errors.New("unknown tenant")
+		}
+		opened[tenantID]++
+		return Config{Enabled: true, Dialect: "sqlite", DSN: filepath.Join(dir, tenantID+".db")}, nil
+	}, nil)
+	defer dbs.Close()
+
+	if _, err := dbs.DB(context.Background()); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("expected ErrNoTenant, got %v", err)
+	}
+	if _, err := dbs.DB(WithTenant(context.Background(), "unknown")); err == nil {
+		t.Error("expected the config error")
+	}
+	if _, err := dbs.DB(WithTenant(context.Background(), "../other")); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("expected ErrUnknownTenant, got %v", err)
+	}
+
+	acme := WithTenant(context.Background(), "acme")
+	db, err := dbs.DB(acme)
+	if err != nil {
+		t.Fatalf("DB failed: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE notes (body TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.DB(acme); err != nil || opened["acme"] != 1 {
+		t.Errorf("expected the pool to be reused, opened %d times (%v)", opened["acme"], err)
+	}
+
+	other, err := dbs.DB(WithTenant(context.Background(), "globex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Migrator().HasTable("notes") {
+		t.Error("expected tenants to use separate databases")
+	}
+}
+
+func TestTenantDBsConcurrentOpen(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	opened := map[string]int{}
+	dbs := NewTenantDBs(AllowTenants("slow", "fast"), func(tenantID string) (Config, error) {
+		mu.Lock()
+		opened[tenantID]++
+		mu.Unlock()
+		if tenantID == "slow" {
+			<-release
+		}
+		return Config{Enabled: true, Dialect: "sqlite", DSN: filepath.Join(dir, tenantID+".db")}, nil
+	}, nil)
+	defer dbs.Close()
+
+	slow := WithTenant(context.Background(), "slow")
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := dbs.DB(slow); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// Another tenant is not held up by the pool being opened.
+	done := make(chan error, 1)
+	go func() {
+		_, err := dbs.DB(WithTenant(context.Background(), "fast"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the fast tenant to open while the slow one is dialing")
+	}
+
+	close(release)
+	wg.Wait()
+	if opened["slow"] != 1 {
+		t.Errorf("expected one open for concurrent requests, got %d", opened["slow"])
+	}
+}
@@ -3,6 +3,7 @@ package logs
 import (
 	"context"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -124,6 +125,8 @@ func (l *Logger) logWithOpts(ctxOld context.Context, level, msg string, fieldsAn
 		}
 	}
 
+	zapFields = appendContextFields(ctx, zapFields)
+
 	if l.appName != "" {
 		msg = "[" + l.appName + "] " + msg
 	}
@@ -143,6 +146,30 @@ func (l *Logger) logWithOpts(ctxOld context.Context, level, msg string, fieldsAn
 	}
 }
 
+// contextFieldNames are the request identifiers that pkg/web and
+// pkg/tokens store in the context, added to every entry logged with it.
+var contextFieldNames = map[ctxKey]string{
+	CtxKeyRequestID: "request_id",
+	CtxKeyTraceID:   "trace_id",
+	CtxKeyUserID:    "user_id",
+}
+
+// appendContextFields adds the identifiers found in ctx, unless the caller
+// already logs them.
+func appendContextFields(ctx context.Context, fields []zap.Field) []zap.Field {
+	for _, key := range []ctxKey{CtxKeyRequestID, CtxKeyTraceID, CtxKeyUserID} {
+		val, ok := ctx.Value(key).(string)
+		if !ok || val == "" {
+			continue
+		}
+		name := contextFieldNames[key]
+		if !slices.ContainsFunc(fields, func(f zap.Field) bool { return f.Key == name }) {
+			fields = append(fields, zap.String(name, val))
+		}
+	}
+	return fields
+}
+
 func (l *Logger) sendNotifications(ctx context.Context, level, msg string, fields []zap.Field) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
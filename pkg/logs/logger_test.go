@@ -115,3 +115,31 @@ func TestLoggerOrphanKey(t *testing.T) {
 		t.Error("expected orphanKey field for lonely string arg")
 	}
 }
+
+func TestLoggerContextFields(t *testing.T) {
+	core, obs := observer.New(zapcore.DebugLevel)
+	l := &Logger{
+		zap: zap.New(core),
+	}
+
+	ctx := context.WithValue(context.Background(), CtxKeyRequestID, "req-1")
+	ctx = context.WithValue(ctx, CtxKeyTraceID, "trace-1")
+	l.Info(ctx, "test msg", "trace_id", "explicit")
+
+	fields := map[string]string{}
+	for _, f := range obs.All()[0].Context {
+		if _, dup := fields[f.Key]; dup {
+			t.Errorf("duplicate field %s", f.Key)
+		}
+		fields[f.Key] = f.String
+	}
+	if fields["request_id"] != "req-1" {
+		t.Errorf("expected request_id from the context, got %q", fields["request_id"])
+	}
+	if fields["trace_id"] != "explicit" {
+		t.Errorf("expected the explicit trace_id to win, got %q", fields["trace_id"])
+	}
+	if _, ok := fields["user_id"]; ok {
+		t.Error("expected no user_id without one in the context")
+	}
+}
@@ -0,0 +1,31 @@
+package tokens
+
+import "net/http"
+
+// Adapters for routers other than gin. They share the validation of the gin
+// middlewares and store the caller in the request context, read with
+// UserIDFromContext and ClaimsFromContext.
+//
+// chi middlewares are plain net/http middlewares:
+//
+//	r := chi.NewRouter()
+//	r.Use(tokens.ChiCachedAuthMiddleware(svc, cm))
+//
+// Echo needs no adapter of its own: echo.WrapMiddleware runs a net/http
+// middleware and hands the request it forwards to the next handler through
+// c.SetRequest, so the caller stored in the request context reaches Echo
+// handlers, and rejected requests are answered by the middleware itself:
+//
+//	e.Use(echo.WrapMiddleware(tokens.HTTPMiddleware(svc, cm)))
+//	...
+//	userID, _ := tokens.UserIDFromContext(c.Request().Context())
+
+// ChiAuthMiddleware is AuthMiddleware for chi routers.
+func ChiAuthMiddleware(svc Service, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return HTTPMiddleware(svc, nil, opts...)
+}
+
+// ChiCachedAuthMiddleware is CachedAuthMiddleware for chi routers.
+func ChiCachedAuthMiddleware(svc Service, cacheMgr CacheManager, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return HTTPMiddleware(svc, cacheMgr, opts...)
+}
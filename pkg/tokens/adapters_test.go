@@ -0,0 +1,76 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+// chiRouter mimics how chi applies Use middlewares: the stack wraps the
+// router, so it runs before route matching and path parameters.
+type chiRouter struct {
+	mux         *http.ServeMux
+	middlewares []func(http.Handler) http.Handler
+}
+
+func (r *chiRouter) Use(mws ...func(http.Handler) http.Handler) {
+	r.middlewares = append(r.middlewares, mws...)
+}
+
+func (r *chiRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var h http.Handler = r.mux
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+	h.ServeHTTP(w, req)
+}
+
+func TestChiAuthMiddleware(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc := newTestService(t)
+
+	cachedToken, exp, _ := svc.GenerateToken("user123", "", nil)
+	_ = cm.AddToken(context.Background(), cachedToken, "user123", exp)
+	uncachedToken, _, _ := svc.GenerateToken("user456", "", nil)
+
+	tests := []struct {
+		name   string
+		mw     func(http.Handler) http.Handler
+		token  string
+		status int
+		body   string
+	}{
+		{"valid token", ChiAuthMiddleware(svc), uncachedToken, http.StatusOK, "user456:42"},
+		{"missing token", ChiAuthMiddleware(svc), "", http.StatusUnauthorized, ""},
+		{"cached token", ChiCachedAuthMiddleware(svc, cm), cachedToken, http.StatusOK, "user123:42"},
+		{"token missing from the cache", ChiCachedAuthMiddleware(svc, cm), uncachedToken, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &chiRouter{mux: http.NewServeMux()}
+			r.Use(tt.mw)
+			r.mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, req *http.Request) {
+				userID, _ := UserIDFromContext(req.Context())
+				_, _ = w.Write([]byte(userID + ":" + req.PathValue("id")))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expected %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
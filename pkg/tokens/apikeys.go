@@ -0,0 +1,270 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyExpired  = errors.New("API key has expired")
+	ErrAPIKeyRevoked  = errors.New("API key has been revoked")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// KeyAPIKey is the gin context key holding the *APIKey of the request.
+const KeyAPIKey = "api_key"
+
+// APIKey is the stored record of a key. The secret itself is never stored,
+// only its SHA-256 hash.
+type APIKey struct {
+	ID        string     `json:"id" gorm:"primaryKey;size:32"`
+	OwnerID   string     `json:"owner_id" gorm:"index;size:255"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash" gorm:"size:64"`
+	Scopes    []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIKeyStore persists API key records.
+type APIKeyStore interface {
+	Save(ctx context.Context, key *APIKey) error
+	Get(ctx context.Context, id string) (*APIKey, error)
+}
+
+// APIKeyParams describes a key to generate.
+type APIKeyParams struct {
+	OwnerID string
+	Name    string
+	Scopes  []string
+	// TTL of the key; zero means it never expires.
+	TTL time.Duration
+}
+
+type APIKeyService interface {
+	Generate(ctx context.Context, params APIKeyParams) (string, *APIKey, error)
+	Validate(ctx context.Context, key string) (*APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type apiKeyService struct {
+	store  APIKeyStore
+	prefix string
+}
+
+// NewAPIKeyService creates an API key service. Keys look like
+// "<prefix><id>_<secret>"; prefix defaults to "sk_live_".
+func NewAPIKeyService(store APIKeyStore, prefix string) APIKeyService {
+	if prefix == "" {
+		prefix = "sk_live_"
+	}
+	return &apiKeyService{
+		store:  store,
+		prefix: prefix,
+	}
+}
+
+// Generate creates a key and returns it in plain text together with its
+// record. The plain key cannot be recovered later.
+func (s *apiKeyService) Generate(ctx context.Context, params APIKeyParams) (string, *APIKey, error) {
+	if params.OwnerID == "" {
+		return "", nil, errors.New("owner ID is required")
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	record := &APIKey{
+		ID:        id,
+		OwnerID:   params.OwnerID,
+		Name:      params.Name,
+		Hash:      hashSecret(secret),
+		Scopes:    params.Scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if params.TTL > 0 {
+		exp := record.CreatedAt.Add(params.TTL)
+		record.ExpiresAt = &exp
+	}
+	if err := s.store.Save(ctx, record); err != nil {
+		return "", nil, fmt.Errorf("save API key: %w", err)
+	}
+	return s.prefix + id + "_" + secret, record, nil
+}
+
+// Validate returns the record of a valid key, or ErrInvalidAPIKey,
+// ErrAPIKeyExpired or ErrAPIKeyRevoked.
+func (s *apiKeyService) Validate(ctx context.Context, key string) (*APIKey, error) {
+	rest, ok := strings.CutPrefix(key, s.prefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	record, err := s.store.Get(ctx, id)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get API key: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(record.Hash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if record.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+	return record, nil
+}
+
+// Revoke disables a key immediately.
+func (s *apiKeyService) Revoke(ctx context.Context, id string) error {
+	record, err := s.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	record.RevokedAt = &now
+	return s.store.Save(ctx, record)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type cacheAPIKeyStore struct {
+	cache cache.Cache
+}
+
+// NewCacheAPIKeyStore stores API keys in a cache, such as Redis. Records
+// expire with their keys.
+func NewCacheAPIKeyStore(c cache.Cache) APIKeyStore {
+	return &cacheAPIKeyStore{cache: c}
+}
+
+func (s *cacheAPIKeyStore) Save(ctx context.Context, key *APIKey) error {
+	var ttl time.Duration
+	if key.ExpiresAt != nil {
+		ttl = time.Until(*key.ExpiresAt)
+		if ttl <= 0 {
+			return s.cache.Delete(ctx, fmt.Sprintf("apikey:%s", key.ID))
+		}
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key: %w", err)
+	}
+	return s.cache.Set(ctx, fmt.Sprintf("apikey:%s", key.ID), string(data), ttl)
+}
+
+func (s *cacheAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	data, err := s.cache.Get(ctx, fmt.Sprintf("apikey:%s", id))
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key APIKey
+	if err := json.Unmarshal([]byte(data), &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API key: %w", err)
+	}
+	return &key, nil
+}
+
+type gormAPIKeyStore struct {
+	db *gorm.DB
+}
+
+// NewGormAPIKeyStore stores API keys in the api_keys table; create it with
+// db.AutoMigrate(&tokens.APIKey{}).
+func NewGormAPIKeyStore(db *gorm.DB) APIKeyStore {
+	return &gormAPIKeyStore{db: db}
+}
+
+func (s *gormAPIKeyStore) Save(ctx context.Context, key *APIKey) error {
+	return s.db.WithContext(ctx).Save(key).Error
+}
+
+func (s *gormAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	err := s.db.WithContext(ctx).First(&key, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// APIKeyMiddleware authenticates requests with a key sent in the X-API-Key
+// header or as a bearer token. The owner and scopes are exposed as the sub
+// and scope claims, so RequireScope and Require work as with tokens; the
+// record is stored under KeyAPIKey.
+func APIKeyMiddleware(svc APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), bearerPrefix)
+		}
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing API key"})
+			c.Abort()
+			return
+		}
+
+		record, err := svc.Validate(c.Request.Context(), key)
+		if err != nil {
+			logs.Info(c.Request.Context(), "[APIKeyMiddleware] API key validation failed", "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired API key"})
+			c.Abort()
+			return
+		}
+
+		c.Set(KeyAPIKey, record)
+		c.Set(KeyUserID, record.OwnerID)
+		c.Set(KeyClaims, jwt.MapClaims{
+			"sub":      record.OwnerID,
+			ClaimScope: strings.Join(record.Scopes, " "),
+		})
+		c.Next()
+	}
+}
@@ -0,0 +1,125 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&APIKey{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]APIKeyStore{
+		"cache": NewCacheAPIKeyStore(c),
+		"gorm":  NewGormAPIKeyStore(db),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := NewAPIKeyService(store, "")
+
+			key, record, err := svc.Generate(ctx, APIKeyParams{OwnerID: "user123", Scopes: []string{"read:orders"}})
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if !strings.HasPrefix(key, "sk_live_") {
+				t.Errorf("expected sk_live_ prefix, got %q", key)
+			}
+			if strings.Contains(record.Hash, key[strings.LastIndex(key, "_")+1:]) {
+				t.Error("expected the secret not to be stored")
+			}
+
+			got, err := svc.Validate(ctx, key)
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			if got.OwnerID != "user123" || len(got.Scopes) != 1 || got.Scopes[0] != "read:orders" {
+				t.Errorf("unexpected record %+v", got)
+			}
+
+			if _, err := svc.Validate(ctx, key[:len(key)-1]+"x"); !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("expected ErrInvalidAPIKey for a wrong secret, got %v", err)
+			}
+
+			if err := svc.Revoke(ctx, record.ID); err != nil {
+				t.Fatalf("Revoke failed: %v", err)
+			}
+			if _, err := svc.Validate(ctx, key); !errors.Is(err, ErrAPIKeyRevoked) {
+				t.Errorf("expected ErrAPIKeyRevoked, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyExpiry(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.AutoMigrate(&APIKey{})
+	svc := NewAPIKeyService(NewGormAPIKeyStore(db), "sk_test_")
+	ctx := context.Background()
+
+	key, _, err := svc.Generate(ctx, APIKeyParams{OwnerID: "user123", TTL: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := svc.Validate(ctx, key); !errors.Is(err, ErrAPIKeyExpired) {
+		t.Errorf("expected ErrAPIKeyExpired, got %v", err)
+	}
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	svc := NewAPIKeyService(NewCacheAPIKeyStore(c), "")
+	key, _, _ := svc.Generate(context.Background(), APIKeyParams{OwnerID: "svc-billing", Scopes: []string{"read:orders"}})
+
+	r := gin.New()
+	r.Use(APIKeyMiddleware(svc))
+	r.GET("/orders", RequireScope("read:orders"), func(c *gin.Context) { c.String(http.StatusOK, c.GetString(KeyUserID)) })
+	r.POST("/orders", RequireScope("write:orders"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	call := func(method, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/orders", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodGet, "X-API-Key", key); w.Code != http.StatusOK || w.Body.String() != "svc-billing" {
+		t.Errorf("expected 200 for svc-billing, got %d %q", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodGet, "Authorization", "Bearer "+key); w.Code != http.StatusOK {
+		t.Errorf("expected bearer API key to be accepted, got %d", w.Code)
+	}
+	if w := call(http.MethodPost, "X-API-Key", key); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without scope, got %d", w.Code)
+	}
+	if w := call(http.MethodGet, "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without key, got %d", w.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package tokens
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAudienceService(t *testing.T, audience ...string) Service {
+	t.Helper()
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+			Audience:       audience,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestAudienceValidation(t *testing.T) {
+	issuer := newAudienceService(t, "orders", "billing")
+	token, _, _ := issuer.GenerateToken("user123", "", map[string]any{"aud": "spoofed"})
+
+	claims, err := issuer.ValidateTokenAndGetClaims(token)
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	aud, _ := claims.GetAudience()
+	if len(aud) != 2 || aud[0] != "orders" || aud[1] != "billing" {
+		t.Errorf("expected aud [orders billing], got %v", aud)
+	}
+
+	if !newAudienceService(t, "billing").IsTokenValid(token) {
+		t.Error("expected token to be valid for one of its audiences")
+	}
+	if newAudienceService(t, "shipping").IsTokenValid(token) {
+		t.Error("expected token for other audiences to be rejected")
+	}
+	noAud, _, _ := newTestService(t).GenerateToken("user123", "", nil)
+	if issuer.IsTokenValid(noAud) {
+		t.Error("expected token without aud to be rejected when an audience is configured")
+	}
+}
+
+func TestRequireAudienceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newAudienceService(t, "orders", "billing")
+	token, _, _ := svc.GenerateToken("user123", "", nil)
+
+	for _, tc := range []struct {
+		audience string
+		want     int
+	}{
+		{"billing", http.StatusOK},
+		{"shipping", http.StatusUnauthorized},
+	} {
+		r := gin.New()
+		r.Use(AuthMiddleware(svc, RequireAudience(tc.audience)))
+		r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("RequireAudience(%q): expected %d, got %d", tc.audience, tc.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tokens
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newTestService(t)
+
+	// httptest requests come from 192.0.2.1.
+	bound, _, _ := svc.GenerateToken("user123", "", map[string]any{
+		ClaimConfirmation: ClientBinding("192.0.2.1", "app/1.0", BindIP|BindUserAgent),
+	})
+	unbound, _, _ := svc.GenerateToken("user123", "", nil)
+
+	call := func(policy BindingPolicy, token, userAgent string) int {
+		r := gin.New()
+		r.Use(AuthMiddleware(svc, WithClientBinding(BindIP|BindUserAgent, policy)))
+		r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name   string
+		policy BindingPolicy
+		token  string
+		ua     string
+		want   int
+	}{
+		{"same client", BindingEnforce, bound, "app/1.0", http.StatusOK},
+		{"replayed", BindingEnforce, bound, "curl/8.0", http.StatusUnauthorized},
+		{"replayed, report only", BindingReport, bound, "curl/8.0", http.StatusOK},
+		{"unbound, enforce", BindingEnforce, unbound, "curl/8.0", http.StatusOK},
+		{"unbound, require", BindingRequire, unbound, "app/1.0", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := call(tt.policy, tt.token, tt.ua); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientBindingHTTPMiddleware(t *testing.T) {
+	svc := newTestService(t)
+	token, _, _ := svc.GenerateToken("user123", "", map[string]any{
+		ClaimConfirmation: ClientBinding("192.0.2.1", "", BindIP),
+	})
+
+	mw := HTTPMiddleware(svc, nil, WithClientBinding(BindIP, BindingEnforce))
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for addr, want := range map[string]int{"192.0.2.1:1234": http.StatusOK, "198.51.100.7:1234": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.RemoteAddr = addr
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", addr, want, w.Code)
+		}
+	}
+}
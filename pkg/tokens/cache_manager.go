@@ -1,14 +1,17 @@
 package tokens
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/fsandov/go-sdk/pkg/cache"
 	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type CacheManager interface {
@@ -18,21 +21,107 @@ type CacheManager interface {
 	InvalidateAllUserTokens(ctx context.Context, userID string) error
 }
 
+// SessionCacheManager is implemented by cache managers that group tokens by
+// login session, needed for MaxActiveTokens. The CacheManager returned by
+// NewCacheManager implements it.
+type SessionCacheManager interface {
+	CacheManager
+	// AddSessionToken is AddToken for a token of a login session, e.g. the
+	// access and refresh token issued together, so TrimUserTokens evicts
+	// them together.
+	AddSessionToken(ctx context.Context, token, userID, session string, expiresAt time.Time) error
+	// TrimUserTokens keeps at most limit live sessions for the user, removing
+	// every token of the oldest ones, and returns how many tokens were
+	// removed. Tokens added without a session count as one session each.
+	TrimUserTokens(ctx context.Context, userID string, limit int) (int, error)
+}
+
+// MarkerCacheManager is implemented by cache managers that can claim tokens
+// atomically and mark them, needed for refresh token reuse detection and
+// WithGracePeriod. The CacheManager returned by NewCacheManager implements
+// it.
+type MarkerCacheManager interface {
+	CacheManager
+	// ConsumeToken atomically removes a cached token and reports whether it
+	// was cached, so only one of concurrent callers gets true.
+	ConsumeToken(ctx context.Context, token string) (bool, error)
+	// MarkToken records marker, e.g. "rotated", for token until the given
+	// time. Markers are kept apart from the cached tokens, so they are not
+	// valid tokens and do not count towards TrimUserTokens; with a userID
+	// they are dropped by InvalidateAllUserTokens.
+	MarkToken(ctx context.Context, marker, token, userID string, until time.Time) error
+	// TokenMarked reports whether token carries marker.
+	TokenMarked(ctx context.Context, marker, token string) (bool, error)
+}
+
+// ExtendingCacheManager is implemented by cache managers that can move the
+// expiry of a cached token, needed for the IdleTimeout of
+// WithSlidingExpiration. The CacheManager returned by NewCacheManager
+// implements it.
+type ExtendingCacheManager interface {
+	CacheManager
+	// ExtendToken moves the cache expiry of a token to expiresAt.
+	ExtendToken(ctx context.Context, token string, expiresAt time.Time) error
+}
+
+// ClaimIndexCacheManager is implemented by cache managers that index tokens
+// by claim. The CacheManager returned by NewCacheManager implements it.
+type ClaimIndexCacheManager interface {
+	CacheManager
+	// IndexToken indexes a cached token by its verified claims. It fails
+	// when claims is nil and the token would have to be indexed.
+	IndexToken(ctx context.Context, token string, claims jwt.MapClaims, expiresAt time.Time) error
+	// InvalidateByClaim removes every cached token whose claim has value,
+	// e.g. all tokens of a tenant. The claim must be indexed with
+	// WithClaimIndex.
+	InvalidateByClaim(ctx context.Context, claim, value string) error
+}
+
+// CacheManagerOption configures a CacheManager.
+type CacheManagerOption func(*cacheManager)
+
+// WithClaimIndex indexes cached tokens by the given claims, holding a string
+// or a list of strings, so they can be revoked together with
+// InvalidateByClaim:
+//
+//	cm := tokens.NewCacheManager(c, tokens.WithClaimIndex("tenant", tokens.ClaimRoles))
+//	err := cm.(tokens.ClaimIndexCacheManager).InvalidateByClaim(ctx, "tenant", "acme")
+//
+// Tokens are indexed when cached by a Service, from the claims it verified,
+// so encrypted JWE and PASETO tokens are indexed too. Tokens added directly
+// with AddToken are not indexed.
+func WithClaimIndex(claims ...string) CacheManagerOption {
+	return func(cm *cacheManager) {
+		cm.indexedClaims = append(cm.indexedClaims, claims...)
+	}
+}
+
 type cacheManager struct {
-	cache cache.Cache
+	cache         cache.Cache
+	indexedClaims []string
 }
 
 type tokenData struct {
-	UserID string `json:"user_id"`
+	UserID   string `json:"user_id"`
+	Session  string `json:"session,omitempty"`
+	CachedAt int64  `json:"cached_at,omitempty"`
 }
 
-func NewCacheManager(cache cache.Cache) CacheManager {
-	return &cacheManager{
+func NewCacheManager(cache cache.Cache, opts ...CacheManagerOption) CacheManager {
+	cm := &cacheManager{
 		cache: cache,
 	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	return cm
 }
 
 func (cm *cacheManager) AddToken(ctx context.Context, token, userID string, expiresAt time.Time) error {
+	return cm.AddSessionToken(ctx, token, userID, "", expiresAt)
+}
+
+func (cm *cacheManager) AddSessionToken(ctx context.Context, token, userID, session string, expiresAt time.Time) error {
 	if token == "" || userID == "" {
 		return fmt.Errorf("token and userID cannot be empty")
 	}
@@ -44,7 +133,9 @@ func (cm *cacheManager) AddToken(ctx context.Context, token, userID string, expi
 
 	tokenKey := fmt.Sprintf("token:%s", token)
 	tokenData := tokenData{
-		UserID: userID,
+		UserID:   userID,
+		Session:  session,
+		CachedAt: time.Now().UnixNano(),
 	}
 
 	tokenDataJSON, err := json.Marshal(tokenData)
@@ -69,6 +160,31 @@ func (cm *cacheManager) AddToken(ctx context.Context, token, userID string, expi
 	return nil
 }
 
+// IndexToken adds token to the index of each configured claim it carries.
+func (cm *cacheManager) IndexToken(ctx context.Context, token string, claims jwt.MapClaims, expiresAt time.Time) error {
+	if len(cm.indexedClaims) == 0 {
+		return nil
+	}
+	if claims == nil {
+		return errors.New("token claims are required to index it")
+	}
+	ttl := time.Until(expiresAt)
+	for _, claim := range cm.indexedClaims {
+		for _, value := range claimValues(claims, claim) {
+			indexKey := claimIndexKey(claim, value)
+			if err := cm.cache.ZAdd(ctx, indexKey, float64(expiresAt.Unix()), token); err != nil {
+				return fmt.Errorf("failed to index token by %s: %w", claim, err)
+			}
+			_, _ = cm.cache.Expire(ctx, indexKey, ttl+time.Hour*24)
+		}
+	}
+	return nil
+}
+
+func claimIndexKey(claim, value string) string {
+	return fmt.Sprintf("claim_tokens:%s:%s", claim, value)
+}
+
 func (cm *cacheManager) RemoveToken(ctx context.Context, token string) error {
 	if token == "" {
 		return nil
@@ -124,6 +240,69 @@ func (cm *cacheManager) TokenExists(ctx context.Context, token string) (bool, er
 	return true, nil
 }
 
+func (cm *cacheManager) ConsumeToken(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	dataStr, err := cm.cache.GetDel(ctx, fmt.Sprintf("token:%s", token))
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	var data tokenData
+	if err := json.Unmarshal([]byte(dataStr), &data); err != nil {
+		return true, fmt.Errorf("failed to unmarshal token data: %w", err)
+	}
+	if err := cm.cache.ZRem(ctx, fmt.Sprintf("user_tokens:%s", data.UserID), token); err != nil {
+		logs.Warn(ctx, "failed to remove token from user set", "error", err)
+	}
+
+	return true, nil
+}
+
+func markerKey(marker, token string) string {
+	return fmt.Sprintf("marker:%s:%s", marker, token)
+}
+
+func (cm *cacheManager) MarkToken(ctx context.Context, marker, token, userID string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	key := markerKey(marker, token)
+	if err := cm.cache.Set(ctx, key, "1", ttl); err != nil {
+		return fmt.Errorf("failed to mark token: %w", err)
+	}
+	if userID == "" {
+		return nil
+	}
+
+	userMarkersKey := fmt.Sprintf("user_markers:%s", userID)
+	if err := cm.cache.ZAdd(ctx, userMarkersKey, float64(until.Unix()), key); err != nil {
+		_ = cm.cache.Delete(ctx, key)
+		return fmt.Errorf("failed to add marker to user set: %w", err)
+	}
+	_, _ = cm.cache.Expire(ctx, userMarkersKey, ttl+time.Hour*24)
+
+	return nil
+}
+
+func (cm *cacheManager) TokenMarked(ctx context.Context, marker, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	exists, err := cm.cache.Exists(ctx, markerKey(marker, token))
+	if err != nil {
+		return false, fmt.Errorf("failed to check token marker: %w", err)
+	}
+	return exists, nil
+}
+
 func (cm *cacheManager) InvalidateAllUserTokens(ctx context.Context, userID string) error {
 	if userID == "" {
 		return fmt.Errorf("userID cannot be empty")
@@ -145,5 +324,166 @@ func (cm *cacheManager) InvalidateAllUserTokens(ctx context.Context, userID stri
 		_ = cm.cache.ZRem(ctx, userTokensKey, token)
 	}
 
+	userMarkersKey := fmt.Sprintf("user_markers:%s", userID)
+	markers, err := cm.cache.ZRange(ctx, userMarkersKey, 0, -1)
+	if err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		return fmt.Errorf("failed to get user markers: %w", err)
+	}
+	for _, key := range markers {
+		_ = cm.cache.Delete(ctx, key)
+		_ = cm.cache.ZRem(ctx, userMarkersKey, key)
+	}
+
+	return nil
+}
+
+func (cm *cacheManager) TrimUserTokens(ctx context.Context, userID string, limit int) (int, error) {
+	if userID == "" {
+		return 0, fmt.Errorf("userID cannot be empty")
+	}
+
+	userTokensKey := fmt.Sprintf("user_tokens:%s", userID)
+
+	tokens, err := cm.cache.ZRange(ctx, userTokensKey, 0, -1)
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get user tokens: %w", err)
+	}
+
+	// Group the live tokens by session; members whose token already expired
+	// are dropped so they don't count.
+	type userSession struct {
+		tokens   []string
+		cachedAt int64
+	}
+	var sessions []*userSession
+	byKey := map[string]*userSession{}
+	for _, token := range tokens {
+		dataStr, err := cm.cache.Get(ctx, fmt.Sprintf("token:%s", token))
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			_ = cm.cache.ZRem(ctx, userTokensKey, token)
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to check token: %w", err)
+		}
+		var data tokenData
+		if err := json.Unmarshal([]byte(dataStr), &data); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal token data: %w", err)
+		}
+
+		key := data.Session
+		if key == "" {
+			key = "token:" + token
+		}
+		sess, ok := byKey[key]
+		if !ok {
+			sess = &userSession{cachedAt: data.CachedAt}
+			byKey[key] = sess
+			sessions = append(sessions, sess)
+		}
+		sess.tokens = append(sess.tokens, token)
+		sess.cachedAt = min(sess.cachedAt, data.CachedAt)
+	}
+
+	// Oldest sessions first, by when their first token was cached.
+	slices.SortStableFunc(sessions, func(a, b *userSession) int {
+		return cmp.Compare(a.cachedAt, b.cachedAt)
+	})
+
+	evicted := 0
+	for _, sess := range sessions[:max(len(sessions)-limit, 0)] {
+		for _, token := range sess.tokens {
+			if err := cm.RemoveToken(ctx, token); err != nil {
+				return evicted, err
+			}
+			evicted++
+		}
+	}
+
+	return evicted, nil
+}
+
+func (cm *cacheManager) ExtendToken(ctx context.Context, token string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("token already expired")
+	}
+
+	tokenKey := fmt.Sprintf("token:%s", token)
+
+	dataStr, err := cm.cache.Get(ctx, tokenKey)
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return ErrTokenRevoked
+		}
+		return fmt.Errorf("failed to get token data: %w", err)
+	}
+
+	var data tokenData
+	if err := json.Unmarshal([]byte(dataStr), &data); err != nil {
+		return fmt.Errorf("failed to unmarshal token data: %w", err)
+	}
+
+	if _, err := cm.cache.Expire(ctx, tokenKey, ttl); err != nil {
+		return fmt.Errorf("failed to extend token: %w", err)
+	}
+
+	userTokensKey := fmt.Sprintf("user_tokens:%s", data.UserID)
+	if err := cm.cache.ZAdd(ctx, userTokensKey, float64(expiresAt.Unix()), token); err != nil {
+		logs.Warn(ctx, "failed to update token in user set", "error", err)
+	}
+	_, _ = cm.cache.Expire(ctx, userTokensKey, ttl+time.Hour*24)
+
+	return nil
+}
+
+func (cm *cacheManager) InvalidateByClaim(ctx context.Context, claim, value string) error {
+	if claim == "" || value == "" {
+		return fmt.Errorf("claim and value cannot be empty")
+	}
+
+	indexKey := claimIndexKey(claim, value)
+
+	tokens, err := cm.cache.ZRange(ctx, indexKey, 0, -1)
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get tokens by claim: %w", err)
+	}
+
+	for _, token := range tokens {
+		if err := cm.RemoveToken(ctx, token); err != nil {
+			return err
+		}
+		_ = cm.cache.ZRem(ctx, indexKey, token)
+	}
+
+	return nil
+}
+
+// cacheToken caches token under session when cacheMgr groups tokens by
+// session, and with AddToken otherwise, then indexes it by the verified
+// claims. A token that cannot be indexed is removed again, so it cannot
+// escape InvalidateByClaim.
+func cacheToken(ctx context.Context, cacheMgr CacheManager, token, userID, session string, claims jwt.MapClaims, expiresAt time.Time) error {
+	var err error
+	if sessions, ok := cacheMgr.(SessionCacheManager); ok {
+		err = sessions.AddSessionToken(ctx, token, userID, session, expiresAt)
+	} else {
+		err = cacheMgr.AddToken(ctx, token, userID, expiresAt)
+	}
+	if err != nil {
+		return err
+	}
+	if index, ok := cacheMgr.(ClaimIndexCacheManager); ok {
+		if err := index.IndexToken(ctx, token, claims, expiresAt); err != nil {
+			_ = cacheMgr.RemoveToken(ctx, token)
+			return err
+		}
+	}
 	return nil
 }
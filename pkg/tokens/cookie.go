@@ -0,0 +1,125 @@
+package tokens
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// CookieConfig describes the cookies used by CookieAuthMiddleware.
+type CookieConfig struct {
+	// Name of the httpOnly cookie holding the access token.
+	Name string
+	// CSRFName is the cookie holding the CSRF token. It is readable from
+	// JavaScript, which sends it back in CSRFHeader.
+	CSRFName   string
+	CSRFHeader string
+	Domain     string
+	Path       string
+	// Secure should only be disabled for local development over plain HTTP.
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// DefaultCookieConfig returns secure, SameSite=Lax cookie settings.
+func DefaultCookieConfig() CookieConfig {
+	return CookieConfig{
+		Name:       "access_token",
+		CSRFName:   "csrf_token",
+		CSRFHeader: "X-CSRF-Token",
+		Path:       "/",
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+	}
+}
+
+// SetAuthCookie stores token in an httpOnly cookie expiring with it, along
+// with a new CSRF token in a cookie the client can read. The CSRF token is
+// also returned, for clients that prefer taking it from the login response.
+func SetAuthCookie(c *gin.Context, cfg CookieConfig, token string, expiresAt time.Time) string {
+	csrfToken := newTokenID()
+	http.SetCookie(c.Writer, cfg.cookie(cfg.Name, token, expiresAt, true))
+	http.SetCookie(c.Writer, cfg.cookie(cfg.CSRFName, csrfToken, expiresAt, false))
+	return csrfToken
+}
+
+// ClearAuthCookie removes the auth and CSRF cookies, e.g. on logout.
+func ClearAuthCookie(c *gin.Context, cfg CookieConfig) {
+	http.SetCookie(c.Writer, cfg.cookie(cfg.Name, "", time.Unix(0, 0), true))
+	http.SetCookie(c.Writer, cfg.cookie(cfg.CSRFName, "", time.Unix(0, 0), false))
+}
+
+func (cfg CookieConfig) cookie(name, value string, expiresAt time.Time, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   cfg.Domain,
+		Path:     cfg.Path,
+		Expires:  expiresAt,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: cfg.SameSite,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+// CookieAuthMiddleware authenticates browser clients with the access token
+// cookie set by SetAuthCookie instead of the Authorization header. Requests
+// with unsafe methods must also send the CSRF cookie value in the CSRF
+// header (double-submit), which a cross-site page cannot read.
+//
+// With a cacheMgr, tokens are checked against the cache like
+// CachedAuthMiddleware, so RemoveTokenFromCache and InvalidateAllUserTokens
+// sign cookie sessions out; pass nil to only check the signature and claims.
+// Tokens renewed by WithSlidingExpiration replace the auth cookie.
+func CookieAuthMiddleware(svc Service, cacheMgr CacheManager, cfg CookieConfig, opts ...MiddlewareOption) gin.HandlerFunc {
+	mwCfg := newMiddlewareConfig(opts)
+	return func(c *gin.Context) {
+		tokenString, err := c.Cookie(cfg.Name)
+		if err != nil || tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing auth cookie"})
+			c.Abort()
+			return
+		}
+
+		if !isSafeMethod(c.Request.Method) && !validCSRF(c, cfg) {
+			logs.Info(c.Request.Context(), "[CookieAuthMiddleware] CSRF token mismatch", "path", c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid CSRF token"})
+			c.Abort()
+			return
+		}
+
+		result, authErr := authenticate(c.Request.Context(), svc, cacheMgr, mwCfg, bearerPrefix+tokenString, ginClient(c))
+		if authErr != nil {
+			abortWithAuthError(c, authErr)
+			return
+		}
+
+		if renewed, exp := acceptToken(c, svc, cacheMgr, mwCfg, result); renewed != "" {
+			http.SetCookie(c.Writer, cfg.cookie(cfg.Name, renewed, exp, true))
+		}
+	}
+}
+
+func validCSRF(c *gin.Context, cfg CookieConfig) bool {
+	cookie, err := c.Cookie(cfg.CSRFName)
+	header := c.GetHeader(cfg.CSRFHeader)
+	if err != nil || cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
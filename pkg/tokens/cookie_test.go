@@ -0,0 +1,117 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCookieAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	cm := NewCacheManager(store)
+	svc := newTestService(t)
+	cfg := DefaultCookieConfig()
+
+	var token string
+	r := gin.New()
+	r.POST("/login", func(c *gin.Context) {
+		var exp time.Time
+		token, exp, _ = svc.GenerateToken("user123", "", nil)
+		_ = cm.AddToken(c.Request.Context(), token, "user123", exp)
+		c.JSON(http.StatusOK, gin.H{"csrf_token": SetAuthCookie(c, cfg, token, exp)})
+	})
+	r.POST("/logout", func(c *gin.Context) { ClearAuthCookie(c, cfg) })
+	auth := r.Group("/", CookieAuthMiddleware(svc, cm, cfg))
+	auth.GET("/me", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(KeyUserID)) })
+	auth.POST("/orders", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 2 || !cookies[0].HttpOnly || cookies[1].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("unexpected cookies %+v", cookies)
+	}
+	csrf := cookies[1].Value
+
+	call := func(method, path, csrfHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if csrfHeader != "" {
+			req.Header.Set(cfg.CSRFHeader, csrfHeader)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodGet, "/me", ""); w.Code != http.StatusOK || w.Body.String() != "user123" {
+		t.Errorf("expected 200 for user123, got %d %q", w.Code, w.Body.String())
+	}
+	if w := call(http.MethodPost, "/orders", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without CSRF header, got %d", w.Code)
+	}
+	if w := call(http.MethodPost, "/orders", "forged"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a wrong CSRF token, got %d", w.Code)
+	}
+	if w := call(http.MethodPost, "/orders", csrf); w.Code != http.StatusCreated {
+		t.Errorf("expected 201 with CSRF header, got %d", w.Code)
+	}
+
+	_ = cm.RemoveToken(context.Background(), token)
+	if w := call(http.MethodGet, "/me", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked cookie token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/logout", nil))
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Value != "" || cookie.MaxAge >= 0 {
+			t.Errorf("expected cookie %s to be cleared, got %+v", cookie.Name, cookie)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without cookie, got %d", w.Code)
+	}
+}
+
+func TestCookieAuthMiddlewareSlidingRenewal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	cm := NewCacheManager(store)
+	svc := newTestService(t)
+	cfg := DefaultCookieConfig()
+
+	token, exp, _ := svc.GenerateToken("user123", "", nil)
+	_ = cm.AddToken(context.Background(), token, "user123", exp)
+
+	r := gin.New()
+	// The test service issues 1h tokens, so a 2h window always renews.
+	r.Use(CookieAuthMiddleware(svc, cm, cfg, WithSlidingExpiration(SlidingExpiration{RenewBefore: 2 * time.Hour})))
+	r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(&http.Cookie{Name: cfg.Name, Value: token})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	renewed := w.Header().Get(RefreshedTokenHeader)
+	cookies := w.Result().Cookies()
+	if renewed == "" || len(cookies) != 1 || cookies[0].Name != cfg.Name || cookies[0].Value != renewed || !cookies[0].HttpOnly {
+		t.Errorf("expected the auth cookie to carry the renewed token, got %+v", cookies)
+	}
+}
@@ -0,0 +1,67 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDenylistAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	denylist := NewDenylist(c)
+	svc := newTestService(t)
+
+	r := gin.New()
+	r.Use(DenylistAuthMiddleware(svc, denylist))
+	r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	revoked, exp, _ := svc.GenerateToken("user123", "", nil)
+	kept, _, _ := svc.GenerateToken("user123", "", nil)
+	if code := call(revoked); code != http.StatusOK {
+		t.Fatalf("expected 200 before revocation, got %d", code)
+	}
+
+	claims, _ := svc.ValidateTokenAndGetClaims(revoked)
+	jti, err := GetStringClaim(claims, "jti")
+	if err != nil || jti == "" {
+		t.Fatalf("expected token to carry a jti, got %q (err=%v)", jti, err)
+	}
+	if err := denylist.Revoke(context.Background(), jti, exp); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	if code := call(revoked); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for revoked token, got %d", code)
+	}
+	if code := call(kept); code != http.StatusOK {
+		t.Errorf("expected other tokens to stay valid, got %d", code)
+	}
+}
+
+func TestDenylistSkipsExpiredTokens(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	denylist := NewDenylist(c)
+	ctx := context.Background()
+
+	if err := denylist.Revoke(ctx, "old", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, "old"); revoked {
+		t.Error("expected expired token not to be stored")
+	}
+}
@@ -0,0 +1,124 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func TestExchangeToken(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	subject, subjectExp, _ := svc.GenerateToken("user123", "user@example.com", map[string]any{
+		ClaimScope: []string{"orders:read", "invoices:read"},
+		ClaimRoles: []string{"customer"},
+	})
+
+	token, exp, err := svc.(TokenExchanger).ExchangeToken(ctx, subject, ExchangeOptions{
+		Audience: []string{"billing"},
+		Scopes:   []string{"invoices:read"},
+		Actor:    "orders-service",
+		TTL:      24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("ExchangeToken failed: %v", err)
+	}
+	if exp.After(subjectExp) {
+		t.Errorf("expected exp capped at the subject token expiry, got %v > %v", exp, subjectExp)
+	}
+
+	claims, err := svc.ValidateTokenAndGetClaims(token)
+	if err != nil {
+		t.Fatalf("exchanged token invalid: %v", err)
+	}
+	if sub, _ := claims.GetSubject(); sub != "user123" {
+		t.Errorf("expected subject user123, got %q", sub)
+	}
+	if aud, _ := claims.GetAudience(); len(aud) != 1 || aud[0] != "billing" {
+		t.Errorf("expected audience billing, got %v", aud)
+	}
+	if scopes := Scopes(claims); len(scopes) != 1 || scopes[0] != "invoices:read" {
+		t.Errorf("expected narrowed scopes, got %v", scopes)
+	}
+	if roles := claimValues(claims, ClaimRoles); len(roles) != 1 {
+		t.Errorf("expected custom claims to carry over, got %v", roles)
+	}
+	act, _ := claims[ClaimActor].(map[string]any)
+	if act["sub"] != "orders-service" {
+		t.Errorf("expected act.sub orders-service, got %v", claims[ClaimActor])
+	}
+
+	chained, _, err := svc.(TokenExchanger).ExchangeToken(ctx, token, ExchangeOptions{Actor: "billing-service"})
+	if err != nil {
+		t.Fatalf("chained ExchangeToken failed: %v", err)
+	}
+	claims, _ = svc.ValidateTokenAndGetClaims(chained)
+	act, _ = claims[ClaimActor].(map[string]any)
+	prev, _ := act[ClaimActor].(map[string]any)
+	if act["sub"] != "billing-service" || prev["sub"] != "orders-service" {
+		t.Errorf("expected nested act claim, got %v", claims[ClaimActor])
+	}
+}
+
+func TestExchangeTokenRejectsBroaderScopes(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	subject, _, _ := svc.GenerateToken("user123", "", map[string]any{ClaimScope: "orders:read"})
+	if _, _, err := svc.(TokenExchanger).ExchangeToken(ctx, subject, ExchangeOptions{Scopes: []string{"orders:write"}}); !errors.Is(err, ErrScopeNotGranted) {
+		t.Errorf("expected ErrScopeNotGranted, got %v", err)
+	}
+
+	_, refresh, _, _ := svc.GenerateTokens("user123", "", nil)
+	if _, _, err := svc.(TokenExchanger).ExchangeToken(ctx, refresh, ExchangeOptions{}); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("expected ErrInvalidTokenType for a refresh token, got %v", err)
+	}
+}
+
+func TestExchangeTokenRejectsRevokedSubject(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+	}, WithCache(NewCacheManager(c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchanger := svc.(TokenExchanger)
+	ctx := context.Background()
+
+	subject, subjectExp, _ := svc.GenerateToken("user123", "", nil)
+	if _, _, err := exchanger.ExchangeToken(ctx, subject, ExchangeOptions{}); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked for an uncached subject token, got %v", err)
+	}
+
+	if err := svc.AddTokenToCache(ctx, subject, "user123", subjectExp); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := exchanger.ExchangeToken(ctx, subject, ExchangeOptions{})
+	if err != nil {
+		t.Fatalf("ExchangeToken failed: %v", err)
+	}
+	if exists, _ := svc.TokenExistsInCache(ctx, token); !exists {
+		t.Fatal("expected the exchanged token to be cached")
+	}
+
+	denylist := NewDenylist(c)
+	_ = denylist.Revoke(ctx, mustClaims(t, svc, subject)["jti"].(string), subjectExp)
+	if _, _, err := exchanger.ExchangeToken(ctx, subject, ExchangeOptions{Denylist: denylist}); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked for a denied subject token, got %v", err)
+	}
+
+	_ = svc.InvalidateAllUserTokens(ctx, "user123")
+	if exists, _ := svc.TokenExistsInCache(ctx, token); exists {
+		t.Error("expected the exchanged token to be revoked with the user tokens")
+	}
+}
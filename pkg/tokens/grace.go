@@ -0,0 +1,56 @@
+package tokens
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyGracePeriod is set to true in the gin context when the request token
+// was revoked or rotated within the grace period.
+const KeyGracePeriod = "grace_period"
+
+const graceMarker = "grace"
+
+type graceContextKey struct{}
+
+// WithGracePeriod keeps accepting tokens for d after they are rotated by
+// RefreshTokens or revoked with RemoveTokenFromCache, so parallel requests
+// racing a rotation do not log the user out. Within the window a rotated
+// refresh token yields a new pair instead of triggering reuse detection,
+// and CachedAuthMiddleware accepts a removed access token, flagging it with
+// KeyGracePeriod. InvalidateAllUserTokens has no grace period. The cache
+// manager must implement MarkerCacheManager.
+func WithGracePeriod(d time.Duration) ServiceOption {
+	return func(s *jwtService) {
+		s.gracePeriod = d
+	}
+}
+
+// markGrace records that token was just rotated or revoked.
+func (s *jwtService) markGrace(ctx context.Context, token string, claims jwt.MapClaims) {
+	markers, ok := s.cacheMgr.(MarkerCacheManager)
+	if s.gracePeriod <= 0 || !ok {
+		return
+	}
+	userID, _ := claims.GetSubject()
+	until := time.Now().Add(s.gracePeriod)
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		until = minTime(until, exp.Time)
+	}
+	if userID == "" || !until.After(time.Now()) {
+		return
+	}
+	if err := markers.MarkToken(ctx, graceMarker, token, userID, until); err != nil {
+		logs.Warn(ctx, "[GracePeriod] failed to record grace period", "user_id", userID, "error", err)
+	}
+}
+
+// InGracePeriod reports whether the request token was accepted within its
+// grace period, for handlers outside gin.
+func InGracePeriod(ctx context.Context) bool {
+	inGrace, _ := ctx.Value(graceContextKey{}).(bool)
+	return inGrace
+}
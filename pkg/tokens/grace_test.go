@@ -0,0 +1,120 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+const testGracePeriod = 100 * time.Millisecond
+
+func newGraceService(t *testing.T, c cache.Cache) (Service, CacheManager) {
+	t.Helper()
+	cm := NewCacheManager(c)
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+		RefreshTokenExp: 24 * time.Hour,
+	}, WithCache(cm), WithGracePeriod(testGracePeriod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, cm
+}
+
+func TestGracePeriodRefreshRotation(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	svc, _ := newGraceService(t, c)
+	ctx := context.Background()
+
+	_, refresh, refreshExp, _ := svc.GenerateTokens("user123", "", nil)
+	_ = svc.AddTokenToCache(ctx, refresh, "user123", refreshExp)
+
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); err != nil {
+		t.Fatalf("RefreshTokens failed: %v", err)
+	}
+	_, parallel, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh)
+	if err != nil {
+		t.Fatalf("expected the rotated token to be accepted within the grace period, got %v", err)
+	}
+
+	time.Sleep(testGracePeriod + 50*time.Millisecond)
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused after the grace period, got %v", err)
+	}
+	if ok, _ := svc.TokenExistsInCache(ctx, parallel); ok {
+		t.Error("expected reuse detection to revoke the pair issued within the grace period")
+	}
+}
+
+func TestGracePeriodRevokedAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	svc, cm := newGraceService(t, c)
+	ctx := context.Background()
+
+	token, exp, _ := svc.GenerateToken("user123", "", nil)
+	_ = svc.AddTokenToCache(ctx, token, "user123", exp)
+	if err := svc.RemoveTokenFromCache(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(CachedAuthMiddleware(svc, cm))
+	r.GET("/me", func(c *gin.Context) {
+		if c.GetBool(KeyGracePeriod) && InGracePeriod(c.Request.Context()) {
+			c.String(http.StatusOK, "grace")
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(); w.Code != http.StatusOK || w.Body.String() != "grace" {
+		t.Errorf("expected the revoked token to be accepted and flagged, got %d %q", w.Code, w.Body.String())
+	}
+	time.Sleep(testGracePeriod + 50*time.Millisecond)
+	if w := call(); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after the grace period, got %d", w.Code)
+	}
+}
+
+func TestGracePeriodMarkersOutsideUserIndex(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	svc, _ := newGraceService(t, c)
+	ctx := context.Background()
+
+	token, exp, _ := svc.GenerateToken("user123", "", nil)
+	_ = svc.AddTokenToCache(ctx, token, "user123", exp)
+	if err := svc.RemoveTokenFromCache(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if members, _ := c.ZRange(ctx, "user_tokens:user123", 0, -1); len(members) != 0 {
+		t.Errorf("expected the grace marker to stay out of the user index, got %v", members)
+	}
+
+	if err := svc.InvalidateAllUserTokens(ctx, "user123"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := svc.(*jwtService).cacheMgr.(MarkerCacheManager).TokenMarked(ctx, graceMarker, token); ok {
+		t.Error("expected InvalidateAllUserTokens to end the grace period")
+	}
+}
@@ -0,0 +1,75 @@
+package tokens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type claimsContextKey struct{}
+
+// HTTPMiddleware is AuthMiddleware for net/http handlers and routers built
+// on them, such as chi. With a cacheMgr it behaves like
+// CachedAuthMiddleware and rejects tokens missing from the cache; pass nil
+// to only validate the token. Handlers read the caller with
+// UserIDFromContext and ClaimsFromContext. The client IP used by
+// WithClientBinding is the request RemoteAddr; behind a proxy, rewrite it
+// first, e.g. with chi's middleware.RealIP:
+//
+//	mux.Handle("/orders", tokens.HTTPMiddleware(svc, cm)(ordersHandler))
+func HTTPMiddleware(svc Service, cacheMgr CacheManager, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := newMiddlewareConfig(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			result, authErr := authenticate(ctx, svc, cacheMgr, cfg, r.Header.Get("Authorization"), httpClient(r))
+			if authErr == nil {
+				_, authErr = checkSubject(ctx, result.claims)
+			}
+			if authErr != nil {
+				writeAuthError(w, authErr)
+				return
+			}
+
+			if result.inGrace {
+				ctx = context.WithValue(ctx, graceContextKey{}, true)
+			} else {
+				slideExpiration(ctx, w.Header(), svc, cacheMgr, cfg, result)
+			}
+			next.ServeHTTP(w, r.WithContext(withAuth(ctx, result.claims, result.authHeader)))
+		})
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, err *authError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.message})
+}
+
+// withAuth stores the authenticated caller in ctx, for the context accessors
+// and for propagating the Authorization header to downstream calls.
+func withAuth(ctx context.Context, claims jwt.MapClaims, authHeader string) context.Context {
+	ctx = context.WithValue(ctx, AuthContextKey, authHeader)
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the token authenticated by any of
+// the auth middlewares. It works with the request context of gin handlers
+// too.
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(jwt.MapClaims)
+	return claims, ok
+}
+
+// UserIDFromContext returns the subject of the authenticated token.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	userID, err := GetStringClaim(claims, "sub")
+	return userID, err == nil && userID != ""
+}
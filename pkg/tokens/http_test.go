@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	access, refresh, _, _ := svc.GenerateTokens("user123", "", nil)
+	token, exp, _ := svc.GenerateToken("user123", "", nil)
+	_ = cm.AddToken(ctx, token, "user123", exp)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := UserIDFromContext(r.Context())
+		_, _ = w.Write([]byte(userID))
+	})
+
+	call := func(mw func(http.Handler) http.Handler, authHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		w := httptest.NewRecorder()
+		mw(handler).ServeHTTP(w, req)
+		return w
+	}
+
+	plain := HTTPMiddleware(svc, nil)
+	if w := call(plain, "Bearer "+access); w.Code != http.StatusOK || w.Body.String() != "user123" {
+		t.Errorf("expected 200 for user123, got %d %q", w.Code, w.Body.String())
+	}
+	w := call(plain, "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "missing or malformed Authorization header") {
+		t.Errorf("expected 401 JSON error, got %d %q", w.Code, w.Body.String())
+	}
+	if w := call(plain, "Bearer "+refresh); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a refresh token, got %d", w.Code)
+	}
+
+	cached := HTTPMiddleware(svc, cm)
+	if w := call(cached, "Bearer "+token); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a cached token, got %d", w.Code)
+	}
+	if w := call(cached, "Bearer "+access); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token missing from the cache, got %d", w.Code)
+	}
+}
+
+func TestClaimsFromGinRequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newTestService(t)
+	token, _, _ := svc.GenerateToken("user123", "", nil)
+
+	r := gin.New()
+	r.Use(AuthMiddleware(svc))
+	r.GET("/me", func(c *gin.Context) {
+		userID, ok := UserIDFromContext(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, userID)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "user123" {
+		t.Errorf("expected user123 in the request context, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,89 @@
+package tokens
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IntrospectionResponse is the RFC 7662 introspection response. Only
+// Active is set for inactive tokens.
+type IntrospectionResponse struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Nbf       int64    `json:"nbf,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+}
+
+// IntrospectionHandler implements RFC 7662 token introspection, so services
+// that cannot validate tokens themselves can ask whether one is active. The
+// token is read from the form-encoded token parameter. With a cacheMgr,
+// tokens missing from the cache (revoked or logged out) are inactive.
+//
+// RFC 7662 requires the endpoint to be protected, e.g. with
+// APIKeyMiddleware:
+//
+//	r.POST("/oauth/introspect", tokens.APIKeyMiddleware(keys), tokens.IntrospectionHandler(svc, cm))
+func IntrospectionHandler(svc Service, cacheMgr CacheManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+
+		token := strings.TrimSpace(c.PostForm("token"))
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "missing token parameter"})
+			return
+		}
+
+		claims, err := svc.ValidateTokenAndGetClaims(token)
+		if err != nil {
+			c.JSON(http.StatusOK, IntrospectionResponse{Active: false})
+			return
+		}
+
+		if cacheMgr != nil {
+			exists, err := cacheMgr.TokenExists(c.Request.Context(), token)
+			if err != nil {
+				logs.Error(c.Request.Context(), "[Introspection] error checking token in cache", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+				return
+			}
+			if !exists {
+				c.JSON(http.StatusOK, IntrospectionResponse{Active: false})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, introspect(claims))
+	}
+}
+
+func introspect(claims jwt.MapClaims) IntrospectionResponse {
+	resp := IntrospectionResponse{Active: true}
+	resp.Sub, _ = claims.GetSubject()
+	resp.Iss, _ = claims.GetIssuer()
+	resp.Aud, _ = claims.GetAudience()
+	resp.Jti, _ = GetStringClaim(claims, "jti")
+	resp.Username, _ = GetStringClaim(claims, "email")
+	resp.TokenType, _ = GetStringClaim(claims, "typ")
+	resp.Scope = strings.Join(Scopes(claims), " ")
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		resp.Exp = exp.Unix()
+	}
+	if iat, _ := claims.GetIssuedAt(); iat != nil {
+		resp.Iat = iat.Unix()
+	}
+	if nbf, _ := claims.GetNotBefore(); nbf != nil {
+		resp.Nbf = nbf.Unix()
+	}
+	return resp
+}
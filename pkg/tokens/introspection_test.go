@@ -0,0 +1,61 @@
+package tokens
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIntrospectionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	token, exp, _ := svc.GenerateToken("user123", "user@example.com", map[string]any{ClaimScope: []string{"read", "write"}})
+	_ = cm.AddToken(ctx, token, "user123", exp)
+
+	r := gin.New()
+	r.POST("/introspect", IntrospectionHandler(svc, cm))
+
+	introspect := func(token string) (int, IntrospectionResponse) {
+		form := url.Values{"token": {token}}
+		req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp IntrospectionResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := introspect(token)
+	if code != http.StatusOK || !resp.Active {
+		t.Fatalf("expected active token, got %d %+v", code, resp)
+	}
+	if resp.Sub != "user123" || resp.Scope != "read write" || resp.Exp != exp.Unix() || resp.Iss != "test-issuer" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if _, resp := introspect("not-a-token"); resp.Active {
+		t.Error("expected invalid token to be inactive")
+	}
+
+	_ = cm.RemoveToken(ctx, token)
+	if _, resp := introspect(token); resp.Active {
+		t.Error("expected revoked token to be inactive")
+	}
+
+	if code, _ := introspect(""); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without token, got %d", code)
+	}
+}
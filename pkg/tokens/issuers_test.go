@@ -0,0 +1,61 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMultiIssuerValidator(t *testing.T) {
+	newIssuer := func(issuer, secret string, audience ...string) Service {
+		t.Helper()
+		svc, err := NewLongLivedService(&LongLivedTokenConfig{TokenConfig: TokenConfig{
+			SecretKey:      secret,
+			Issuer:         issuer,
+			AccessTokenExp: time.Hour,
+			Audience:       audience,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return svc
+	}
+	tenantA := newIssuer("tenant-a", "secret-for-tenant-a-minimum-length", "api")
+	tenantB := newIssuer("tenant-b", "secret-for-tenant-b-minimum-length")
+
+	svc, err := NewMultiIssuerValidator(tenantA, tenantB)
+	if err != nil {
+		t.Fatalf("NewMultiIssuerValidator failed: %v", err)
+	}
+
+	for name, issuer := range map[string]Service{"tenant-a": tenantA, "tenant-b": tenantB} {
+		token, _, _ := issuer.GenerateToken("user123", "", nil)
+		claims, err := svc.ValidateTokenAndGetClaims(token)
+		if err != nil {
+			t.Errorf("%s: expected token to validate, got %v", name, err)
+			continue
+		}
+		if iss, _ := claims.GetIssuer(); iss != name {
+			t.Errorf("expected issuer %s, got %s", name, iss)
+		}
+	}
+
+	// Signed with the secret of tenant B but claiming to be tenant A.
+	forger := newIssuer("tenant-a", "secret-for-tenant-b-minimum-length", "api")
+	forged, _, _ := forger.GenerateToken("user123", "", nil)
+	if svc.IsTokenValid(forged) {
+		t.Error("expected token signed with another issuer's key to be rejected")
+	}
+
+	unknown, _, _ := newIssuer("tenant-c", "secret-for-tenant-c-minimum-length").GenerateToken("user123", "", nil)
+	if svc.IsTokenValid(unknown) {
+		t.Error("expected token from an unregistered issuer to be rejected")
+	}
+
+	if _, _, err := svc.GenerateToken("user123", "", nil); err == nil {
+		t.Error("expected the multi-issuer validator not to issue tokens")
+	}
+
+	if _, err := NewMultiIssuerValidator(tenantA, tenantA); err == nil {
+		t.Error("expected duplicate issuers to be rejected")
+	}
+}
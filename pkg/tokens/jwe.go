@@ -0,0 +1,137 @@
+package tokens
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WithEncryption wraps the JWTs issued by the service in compact JWE
+// (alg "dir", enc A128GCM, A192GCM or A256GCM for 16, 24 or 32-byte keys),
+// so clients cannot read the claims. ValidateTokenAndGetClaims, and so the
+// auth middlewares, decrypt before validating; plain signed tokens are
+// still accepted to allow a gradual rollout unless RequireEncryption is set.
+// The service constructor fails if the key is not 16, 24 or 32 bytes, and
+// the PASETO constructors reject it.
+func WithEncryption(key []byte) ServiceOption {
+	return func(s *jwtService) {
+		s.encKey = append([]byte(nil), key...)
+	}
+}
+
+// RequireEncryption rejects plain signed tokens once every client holds an
+// encrypted one. It requires WithEncryption.
+func RequireEncryption() ServiceOption {
+	return func(s *jwtService) {
+		s.requireJWE = true
+	}
+}
+
+// checkEncryption validates the encryption options after they are applied.
+func (s *jwtService) checkEncryption() error {
+	if s.encKey == nil {
+		if s.requireJWE {
+			return errors.New("tokens: RequireEncryption needs WithEncryption")
+		}
+		return nil
+	}
+	switch len(s.encKey) {
+	case 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("tokens: encryption key must be 16, 24 or 32 bytes, got %d", len(s.encKey))
+}
+
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+}
+
+func (s *jwtService) jweCipher() (cipher.AEAD, string, error) {
+	block, err := aes.NewCipher(s.encKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("tokens: encryption key: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, "", err
+	}
+	return gcm, fmt.Sprintf("A%dGCM", len(s.encKey)*8), nil
+}
+
+func (s *jwtService) encrypt(token string) (string, error) {
+	gcm, enc, err := s.jweCipher()
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(jweHeader{Alg: "dir", Enc: enc, Cty: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(header)
+
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, []byte(token), []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		protected,
+		"",
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+func (s *jwtService) decrypt(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 || parts[1] != "" {
+		return "", errors.New("malformed JWE")
+	}
+	gcm, enc, err := s.jweCipher()
+	if err != nil {
+		return "", err
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", err
+	}
+	var header jweHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return "", err
+	}
+	if header.Alg != "dir" || header.Enc != enc {
+		return "", fmt.Errorf("unexpected JWE algorithm %s/%s", header.Alg, header.Enc)
+	}
+
+	var segments [3][]byte
+	for i, part := range parts[2:] {
+		if segments[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return "", err
+		}
+	}
+	iv, ciphertext, tag := segments[0], segments[1], segments[2]
+	if len(iv) != gcm.NonceSize() || len(tag) != gcm.Overhead() {
+		return "", errors.New("malformed JWE")
+	}
+	plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// isJWE tells compact JWE (five segments) from JWS (three).
+func isJWE(token string) bool {
+	return strings.Count(token, ".") == 4
+}
@@ -0,0 +1,130 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newEncryptedService(t *testing.T, key []byte) Service {
+	t.Helper()
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+	}, WithEncryption(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestEncryptedTokens(t *testing.T) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	svc := newEncryptedService(t, key)
+
+	token, _, err := svc.GenerateToken("user123", "user@test.com", map[string]any{"tenant": "acme"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		t.Fatalf("expected compact JWE, got %d segments", len(parts))
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if string(header) != `{"alg":"dir","enc":"A256GCM","cty":"JWT"}` {
+		t.Errorf("unexpected JWE header %s", header)
+	}
+	if strings.Contains(token, "user@test.com") {
+		t.Error("expected claims not to be readable")
+	}
+
+	claims, err := svc.ValidateTokenAndGetClaims(token)
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if tenant, _ := GetStringClaim(claims, "tenant"); tenant != "acme" {
+		t.Errorf("expected tenant=acme, got %q", tenant)
+	}
+
+	otherKey := make([]byte, 32)
+	_, _ = rand.Read(otherKey)
+	if newEncryptedService(t, otherKey).IsTokenValid(token) {
+		t.Error("expected token encrypted with another key to be rejected")
+	}
+	if newTestService(t).IsTokenValid(token) {
+		t.Error("expected service without the key to reject encrypted tokens")
+	}
+
+	plain, _, _ := newTestService(t).GenerateToken("user123", "", nil)
+	if !svc.IsTokenValid(plain) {
+		t.Error("expected plain signed tokens to stay valid during rollout")
+	}
+}
+
+func TestEncryptedTokensWithAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	svc := newEncryptedService(t, key)
+	token, _, _ := svc.GenerateToken("user123", "", nil)
+
+	r := gin.New()
+	r.Use(AuthMiddleware(svc))
+	r.GET("/me", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(KeyUserID)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "user123" {
+		t.Errorf("expected 200 for user123, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRequireEncryption(t *testing.T) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey: "test-secret-key-minimum-length",
+			Issuer:    "test-issuer",
+		},
+	}, WithEncryption(key), RequireEncryption())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, _, _ := svc.GenerateToken("user123", "", nil)
+	if !svc.IsTokenValid(encrypted) {
+		t.Error("expected encrypted token to be valid")
+	}
+	plain, _, _ := newTestService(t).GenerateToken("user123", "", nil)
+	if svc.IsTokenValid(plain) {
+		t.Error("expected plain signed token to be rejected")
+	}
+}
+
+func TestEncryptionInvalidKey(t *testing.T) {
+	cfg := &ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey: "test-secret-key-minimum-length",
+			Issuer:    "test-issuer",
+		},
+	}
+	if _, err := NewService(cfg, WithEncryption([]byte("short"))); err == nil {
+		t.Error("expected an error for an invalid AES key")
+	}
+	if _, err := NewService(cfg, RequireEncryption()); err == nil {
+		t.Error("expected an error for RequireEncryption without a key")
+	}
+}
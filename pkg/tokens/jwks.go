@@ -0,0 +1,351 @@
+package tokens
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKSConfig configures NewJWKSValidator.
+type JWKSConfig struct {
+	// URL of the key set, e.g. https://tenant.auth0.com/.well-known/jwks.json.
+	URL string
+	// Issuer is checked against the iss claim when set.
+	Issuer string
+	// Audience, when set, must contain one of the aud values of the token.
+	Audience []string
+	// RefreshInterval between fetches of the key set. Defaults to 1h.
+	RefreshInterval time.Duration
+	// MinRefreshInterval throttles refetches triggered by unknown kids and
+	// failed fetches. Defaults to 30s.
+	MinRefreshInterval time.Duration
+	// HTTPClient defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+func (c *JWKSConfig) applyDefaults() {
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = time.Hour
+	}
+	if c.MinRefreshInterval <= 0 {
+		c.MinRefreshInterval = 30 * time.Second
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+}
+
+// NewJWKSValidator creates a validation-only service for tokens issued by an
+// external identity provider (Auth0, Keycloak, Cognito...). Keys are fetched
+// from the JWKS URL on first use, refreshed every RefreshInterval, and
+// refetched when a token names an unknown kid. It works with AuthMiddleware
+// like any other Service; tokens without a typ claim are accepted as access
+// tokens. Generating tokens fails with ErrNoSigningKey.
+func NewJWKSValidator(cfg *JWKSConfig, opts ...ServiceOption) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("tokens: config is nil")
+	}
+	if cfg.URL == "" {
+		return nil, errors.New("tokens: JWKS URL is required")
+	}
+	cfgCopy := *cfg
+	cfgCopy.applyDefaults()
+
+	svc := &jwtService{
+		tokenCfg: LongLivedTokenConfig{TokenConfig: TokenConfig{Issuer: cfg.Issuer, Audience: cfg.Audience}},
+		keys: &keySet{
+			keys:   map[string]*jwtKey{},
+			remote: &jwksSource{config: &cfgCopy, now: time.Now},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if err := svc.checkEncryption(); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+// jwksSource holds the keys fetched from a JWKS URL.
+type jwksSource struct {
+	config *JWKSConfig
+	now    func() time.Time
+
+	fetchMu   sync.Mutex
+	attempted time.Time
+
+	mu      sync.RWMutex
+	keys    map[string]*jwtKey
+	fetched time.Time
+}
+
+func (j *jwksSource) lookup(kid string) (*jwtKey, bool) {
+	j.mu.RLock()
+	key, ok := j.find(kid)
+	stale := j.now().Sub(j.fetched) >= j.config.RefreshInterval
+	j.mu.RUnlock()
+	if ok && !stale {
+		return key, true
+	}
+
+	j.refresh(!ok)
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.find(kid)
+}
+
+// find must be called with mu held. Tokens without a kid match a key set
+// holding a single key.
+func (j *jwksSource) find(kid string) (*jwtKey, bool) {
+	if key, ok := j.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (j *jwksSource) refresh(miss bool) {
+	j.fetchMu.Lock()
+	defer j.fetchMu.Unlock()
+
+	now := j.now()
+	if now.Sub(j.attempted) < j.config.MinRefreshInterval {
+		return
+	}
+	j.mu.RLock()
+	fresh := now.Sub(j.fetched) < j.config.RefreshInterval
+	j.mu.RUnlock()
+	if fresh && !miss {
+		return
+	}
+	j.attempted = now
+
+	keys, err := j.fetch()
+	if err != nil {
+		logs.Warn(context.Background(), "[JWKS] failed to refresh key set", "url", j.config.URL, "error", err)
+		return
+	}
+	j.mu.Lock()
+	j.keys = keys
+	j.fetched = now
+	j.mu.Unlock()
+}
+
+func (j *jwksSource) fetch() (map[string]*jwtKey, error) {
+	resp, err := j.config.HTTPClient.Get(j.config.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode key set: %w", err)
+	}
+
+	keys := make(map[string]*jwtKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.verificationKey()
+		if err != nil {
+			logs.Debug(context.Background(), "[JWKS] skipping key", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// verificationKey decodes the public key and the algorithm it validates,
+// taken from alg when present.
+func (k JWK) verificationKey() (*jwtKey, error) {
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	method, err := signingMethodFor(pub)
+	if err != nil {
+		return nil, err
+	}
+	if k.Alg != "" {
+		if method = jwt.GetSigningMethod(k.Alg); method == nil {
+			return nil, fmt.Errorf("%w: alg %s", ErrUnsupportedKey, k.Alg)
+		}
+	}
+	return &jwtKey{method: method, verify: pub}, nil
+}
+
+// PublicKey decodes the key as *rsa.PublicKey, *ecdsa.PublicKey or
+// ed25519.PublicKey.
+func (k JWK) PublicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedKey, k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) > size || len(y) > size {
+			return nil, errors.New("invalid EC point")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		copy(point[1+size-len(x):], x)
+		copy(point[1+2*size-len(y):], y)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedKey, k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("%w: kty %s", ErrUnsupportedKey, k.Kty)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// JWKSPath is the well-known path JWKSHandler is usually mounted on.
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler serves the public keys of svc, so other services can validate
+// its tokens with NewJWKSValidator. HMAC secrets are never published.
+//
+//	app.GetEngine().GET(tokens.JWKSPath, tokens.JWKSHandler(svc))
+func JWKSHandler(svc Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, publicJWKS(svc))
+	}
+}
+
+func publicJWKS(svc Service) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	s, ok := svc.(*jwtService)
+	if !ok {
+		return set
+	}
+	s.keys.mu.RLock()
+	defer s.keys.mu.RUnlock()
+	for kid, key := range s.keys.keys {
+		jwk, err := NewJWK(kid, key.verify)
+		if err != nil {
+			continue
+		}
+		jwk.Alg = key.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// NewJWK encodes an RSA, ECDSA or Ed25519 public key as a signing JWK.
+func NewJWK(kid string, pub any) (JWK, error) {
+	jwk := JWK{Kid: kid, Use: "sig"}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		point, err := k.Bytes()
+		if err != nil {
+			return JWK{}, err
+		}
+		size := (len(point) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	default:
+		return JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, pub)
+	}
+	return jwk, nil
+}
@@ -0,0 +1,210 @@
+package tokens
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type fakeIdP struct {
+	mu      sync.Mutex
+	keys    []JWK
+	fetches atomic.Int32
+	server  *httptest.Server
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	idp := &fakeIdP{}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		idp.fetches.Add(1)
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(JWKS{Keys: idp.keys})
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (f *fakeIdP) publish(jwk JWK) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = append(f.keys, jwk)
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func signExternal(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub": "auth0|123",
+		"iss": "https://idp.example.com/",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestJWKSValidator(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecPoint, _ := ecKey.PublicKey.Bytes()
+
+	idp := newFakeIdP(t)
+	idp.publish(JWK{Kty: "RSA", Kid: "rsa", Alg: "RS256", Use: "sig", N: b64(rsaKey.N.Bytes()), E: b64(big.NewInt(int64(rsaKey.E)).Bytes())})
+	idp.publish(JWK{Kty: "EC", Kid: "ec", Crv: "P-384", X: b64(ecPoint[1:49]), Y: b64(ecPoint[49:])})
+	idp.publish(JWK{Kty: "OKP", Kid: "ed", Crv: "Ed25519", X: b64(edPub)})
+
+	svc, err := NewJWKSValidator(&JWKSConfig{URL: idp.server.URL, Issuer: "https://idp.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{
+		signExternal(t, jwt.SigningMethodRS256, "rsa", rsaKey),
+		signExternal(t, jwt.SigningMethodES384, "ec", ecKey),
+		signExternal(t, jwt.SigningMethodEdDSA, "ed", edKey),
+	} {
+		claims, err := svc.ValidateTokenAndGetClaims(token)
+		if err != nil {
+			t.Fatalf("failed to validate token: %v", err)
+		}
+		if sub, _ := GetStringClaim(claims, "sub"); sub != "auth0|123" {
+			t.Errorf("expected sub=auth0|123, got %q", sub)
+		}
+	}
+	if n := idp.fetches.Load(); n != 1 {
+		t.Errorf("expected key set to be fetched once, got %d", n)
+	}
+
+	if svc.IsTokenValid(signExternal(t, jwt.SigningMethodRS256, "ec", rsaKey)) {
+		t.Error("expected token with mismatched key to be rejected")
+	}
+	if _, _, err := svc.GenerateToken("user123", "", nil); err == nil {
+		t.Error("expected validator to refuse to sign tokens")
+	}
+}
+
+func TestJWKSValidatorRefetchesOnKidMiss(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwkOf := func(kid string, k *ecdsa.PrivateKey) JWK {
+		point, _ := k.PublicKey.Bytes()
+		return JWK{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(point[1:33]), Y: b64(point[33:])}
+	}
+
+	idp := newFakeIdP(t)
+	idp.publish(jwkOf("old", oldKey))
+	svc, _ := NewJWKSValidator(&JWKSConfig{URL: idp.server.URL, MinRefreshInterval: time.Millisecond})
+	remote := svc.(*jwtService).keys.remote
+
+	if !svc.IsTokenValid(signExternal(t, jwt.SigningMethodES256, "old", oldKey)) {
+		t.Fatal("expected token to validate")
+	}
+
+	idp.publish(jwkOf("new", newKey))
+	time.Sleep(2 * time.Millisecond)
+	if !svc.IsTokenValid(signExternal(t, jwt.SigningMethodES256, "new", newKey)) {
+		t.Fatal("expected unknown kid to trigger a refetch")
+	}
+	if n := idp.fetches.Load(); n != 2 {
+		t.Errorf("expected 2 fetches, got %d", n)
+	}
+
+	remote.config.MinRefreshInterval = time.Hour
+	svc.IsTokenValid(signExternal(t, jwt.SigningMethodES256, "unknown", newKey))
+	svc.IsTokenValid(signExternal(t, jwt.SigningMethodES256, "unknown", newKey))
+	if n := idp.fetches.Load(); n != 2 {
+		t.Errorf("expected kid-miss refetches to be throttled, got %d fetches", n)
+	}
+}
+
+func TestJWKSValidatorWithAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	point, _ := key.PublicKey.Bytes()
+	idp := newFakeIdP(t)
+	idp.publish(JWK{Kty: "EC", Kid: "k1", Crv: "P-256", X: b64(point[1:33]), Y: b64(point[33:])})
+	svc, _ := NewJWKSValidator(&JWKSConfig{URL: idp.server.URL})
+
+	r := gin.New()
+	r.Use(AuthMiddleware(svc))
+	r.GET("/me", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(KeyUserID)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+signExternal(t, jwt.SigningMethodES256, "k1", key))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "auth0|123" {
+		t.Errorf("expected 200 for auth0|123, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestJWKSHandlerRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	cfg := testKeyConfig()
+	cfg.KeyID = "rsa-1"
+	issuer, err := NewServiceWithKeys(cfg, KeyConfig{PrivateKey: rsaKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.(KeyRotator).AddKey("ed-2", edKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.(KeyRotator).AddKey("hmac", "never-published-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.(KeyRotator).AddKey("ed-3", edKey); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET(JWKSPath, JWKSHandler(issuer))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + JWKSPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set JWKS
+	_ = json.NewDecoder(resp.Body).Decode(&set)
+	resp.Body.Close()
+	var kids []string
+	for _, k := range set.Keys {
+		kids = append(kids, k.Kid+"/"+k.Alg)
+	}
+	if len(kids) != 3 || kids[0] != "ed-2/EdDSA" || kids[1] != "ed-3/EdDSA" || kids[2] != "rsa-1/RS256" {
+		t.Errorf("unexpected published keys %v", kids)
+	}
+
+	verifier, _ := NewJWKSValidator(&JWKSConfig{URL: server.URL + JWKSPath, Issuer: "test-issuer"})
+	token, _, _ := issuer.GenerateToken("user123", "", nil)
+	if !verifier.IsTokenValid(token) {
+		t.Error("expected token to validate against the published key set")
+	}
+}
@@ -0,0 +1,160 @@
+package tokens
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+const keyProviderTimeout = 10 * time.Second
+
+// KeyProvider supplies the HMAC secret of a token service, set in
+// TokenConfig.KeyProvider.
+type KeyProvider interface {
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function to KeyProvider, e.g. to read the secret
+// from AWS KMS, Secrets Manager or Vault with their SDKs:
+//
+//	cfg.KeyProvider = tokens.KeyProviderFunc(func(ctx context.Context) ([]byte, error) {
+//		secret, err := client.KVv2("secret").Get(ctx, "tokens")
+//		if err != nil {
+//			return nil, err
+//		}
+//		return []byte(secret.Data["key"].(string)), nil
+//	})
+//	cfg.KeyRefreshInterval = 10 * time.Minute
+type KeyProviderFunc func(ctx context.Context) ([]byte, error)
+
+func (f KeyProviderFunc) Key(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// StaticKey returns a provider for a fixed secret.
+func StaticKey(secret []byte) KeyProvider {
+	secret = append([]byte(nil), secret...)
+	return KeyProviderFunc(func(context.Context) ([]byte, error) {
+		return secret, nil
+	})
+}
+
+// EnvKey returns a provider reading the secret from an environment
+// variable each time it is loaded.
+func EnvKey(name string) KeyProvider {
+	return KeyProviderFunc(func(context.Context) ([]byte, error) {
+		secret := os.Getenv(name)
+		if secret == "" {
+			return nil, fmt.Errorf("tokens: environment variable %s is empty", name)
+		}
+		return []byte(secret), nil
+	})
+}
+
+// configKeySet builds the key set of a service from SecretKey, or from
+// KeyProvider when set.
+func configKeySet(cfg TokenConfig) *keySet {
+	if cfg.KeyProvider == nil {
+		return newKeySet(cfg.KeyID, hmacKey(cfg.SecretKey))
+	}
+	return &keySet{
+		keys:   map[string]*jwtKey{},
+		source: &providedKey{provider: cfg.KeyProvider, interval: cfg.KeyRefreshInterval},
+	}
+}
+
+// providedKey tracks the secret loaded from a KeyProvider. Each secret is
+// registered under a kid derived from it with HMAC, so every instance agrees
+// on it without publishing a hash of the secret; after a change the
+// previous one keeps validating until the next change, so outstanding
+// tokens survive a rotation.
+type providedKey struct {
+	provider KeyProvider
+	interval time.Duration
+
+	// loaded is the UnixNano time of the last load, zero until the first
+	// one succeeds; it is read without locking on every sign and validation.
+	loaded     atomic.Int64
+	refreshing atomic.Bool
+
+	mu       sync.Mutex
+	kid      string
+	previous string
+}
+
+// loadProvided loads the provider secret on first use, blocking until it is
+// available. Later refreshes run in the background while the current secret
+// keeps being used, so a slow provider never stalls authentication;
+// refresh failures are logged and the current secret kept.
+func (ks *keySet) loadProvided() error {
+	p := ks.source
+	if p == nil {
+		return nil
+	}
+	loaded := p.loaded.Load()
+	if loaded != 0 {
+		if p.interval > 0 && time.Since(time.Unix(0, loaded)) >= p.interval && p.refreshing.CompareAndSwap(false, true) {
+			go func() {
+				defer p.refreshing.Store(false)
+				_ = ks.refreshProvided()
+			}()
+		}
+		return nil
+	}
+	return ks.refreshProvided()
+}
+
+// refreshProvided loads the secret from the provider and registers it when
+// it changed.
+func (ks *keySet) refreshProvided() error {
+	p := ks.source
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	first := p.loaded.Load() == 0
+	if !first && (p.interval <= 0 || time.Since(time.Unix(0, p.loaded.Load())) < p.interval) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), keyProviderTimeout)
+	defer cancel()
+	secret, err := p.provider.Key(ctx)
+	if err == nil && len(secret) == 0 {
+		err = ErrNoSecret
+	}
+	if err != nil {
+		if first {
+			return fmt.Errorf("tokens: load key: %w", err)
+		}
+		logs.Warn(ctx, "[KeyProvider] failed to refresh key, keeping the current one", "error", err)
+		p.loaded.Store(time.Now().UnixNano())
+		return nil
+	}
+
+	kid := providedKeyID(secret)
+	if kid != p.kid {
+		ks.add(kid, hmacKey(string(secret)))
+		if p.previous != "" {
+			_ = ks.retire(p.previous)
+		}
+		p.previous, p.kid = p.kid, kid
+	}
+	p.loaded.Store(time.Now().UnixNano())
+	return nil
+}
+
+// providedKeyID derives the kid of a provider secret with an HMAC keyed by
+// it, which every instance computes alike without publishing a digest of
+// the secret itself.
+func providedKeyID(secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("go-sdk/tokens key id"))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
@@ -0,0 +1,144 @@
+package tokens
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyProviderRefresh(t *testing.T) {
+	var mu sync.Mutex
+	secret, loads := "first-secret-minimum-length", 0
+	provider := KeyProviderFunc(func(context.Context) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads++
+		return []byte(secret), nil
+	})
+	var svc Service
+	var err error
+	rotate := func(s string) {
+		mu.Lock()
+		secret = s
+		mu.Unlock()
+		time.Sleep(60 * time.Millisecond)
+		// The stale key triggers a background refresh; wait for it.
+		before := currentKeyID(svc)
+		_, _, _ = svc.GenerateToken("user123", "", nil)
+		for deadline := time.Now().Add(time.Second); currentKeyID(svc) == before && time.Now().Before(deadline); {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	svc, err = NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			Issuer:             "test-issuer",
+			AccessTokenExp:     time.Hour,
+			KeyProvider:        provider,
+			KeyRefreshInterval: 50 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	if loads != 0 {
+		t.Fatalf("expected the key to be loaded lazily, got %d loads", loads)
+	}
+
+	first, _, err := svc.GenerateToken("user123", "", nil)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	rotate("second-secret-minimum-length")
+	second, _, _ := svc.GenerateToken("user123", "", nil)
+	if !svc.IsTokenValid(first) || !svc.IsTokenValid(second) {
+		t.Fatal("expected tokens signed before and after the rotation to validate")
+	}
+
+	rotate("third-secret-minimum-length")
+	_, _, _ = svc.GenerateToken("user123", "", nil)
+	if svc.IsTokenValid(first) {
+		t.Error("expected the key from two rotations ago to be retired")
+	}
+	if !svc.IsTokenValid(second) {
+		t.Error("expected the previous key to keep validating")
+	}
+}
+
+func currentKeyID(svc Service) string {
+	p := svc.(*jwtService).keys.source
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.kid
+}
+
+func TestKeyProviderSlowRefresh(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	provider := KeyProviderFunc(func(ctx context.Context) ([]byte, error) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		return []byte("slow-secret-minimum-length"), nil
+	})
+	svc, err := NewLongLivedService(&LongLivedTokenConfig{TokenConfig: TokenConfig{
+		Issuer:             "test-issuer",
+		KeyProvider:        provider,
+		KeyRefreshInterval: 10 * time.Millisecond,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(release)
+
+	token, _, err := svc.GenerateToken("user123", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 10 {
+			if !svc.IsTokenValid(token) {
+				t.Error("expected the current key to keep validating during a refresh")
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("validation blocked on a slow key refresh")
+	}
+	if kid := currentKeyID(svc); strings.Contains(kid, "slow") || len(kid) != 16 {
+		t.Errorf("unexpected kid %q", kid)
+	}
+}
+
+func TestEnvKeyProvider(t *testing.T) {
+	t.Setenv("TEST_TOKEN_KEY", "env-secret-minimum-length")
+	svc, err := NewLongLivedService(&LongLivedTokenConfig{TokenConfig: TokenConfig{
+		Issuer:      "test-issuer",
+		KeyProvider: EnvKey("TEST_TOKEN_KEY"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := svc.GenerateToken("user123", "", nil)
+	if err != nil || !svc.IsTokenValid(token) {
+		t.Fatalf("expected a valid token, got err=%v", err)
+	}
+
+	empty, _ := NewLongLivedService(&LongLivedTokenConfig{TokenConfig: TokenConfig{
+		Issuer:      "test-issuer",
+		KeyProvider: EnvKey("TEST_TOKEN_KEY_MISSING"),
+	}})
+	if _, _, err := empty.GenerateToken("user123", "", nil); err == nil {
+		t.Error("expected an error when the key cannot be loaded")
+	}
+}
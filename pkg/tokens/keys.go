@@ -0,0 +1,218 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey   = errors.New("signing key is required")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrKeyNotFound    = errors.New("key not found")
+	ErrKeyInUse       = errors.New("cannot retire the current signing key")
+)
+
+// KeyConfig holds the asymmetric keys used by NewServiceWithKeys.
+type KeyConfig struct {
+	// PrivateKey signs tokens: *rsa.PrivateKey (RS256), *ecdsa.PrivateKey
+	// (ES256, ES384 or ES512 depending on the curve) or ed25519.PrivateKey
+	// (EdDSA). Leave it nil on resource servers that only validate tokens.
+	PrivateKey crypto.Signer
+	// PublicKey validates tokens. Defaults to the public half of PrivateKey.
+	PublicKey crypto.PublicKey
+}
+
+// NewServiceWithKeys creates a token service that signs with a private key
+// and validates with the matching public key, so resource servers can check
+// tokens without holding the signing secret. SecretKey is ignored.
+func NewServiceWithKeys(cfg *ShortLivedTokenConfig, keys KeyConfig, opts ...ServiceOption) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("tokens: config is nil")
+	}
+	if cfg.Issuer == "" {
+		return nil, ErrNoIssuer
+	}
+	if keys.PublicKey == nil {
+		if keys.PrivateKey == nil {
+			return nil, errors.New("tokens: a private or public key is required")
+		}
+		keys.PublicKey = keys.PrivateKey.Public()
+	}
+	method, err := signingMethodFor(keys.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AccessTokenExp == 0 {
+		cfg.AccessTokenExp = 4 * time.Hour
+	}
+	if cfg.RefreshTokenExp == 0 {
+		cfg.RefreshTokenExp = 24 * time.Hour
+	}
+
+	key := &jwtKey{method: method, verify: keys.PublicKey}
+	if keys.PrivateKey != nil {
+		key.sign = keys.PrivateKey
+	}
+	cfgCopy := *cfg
+	svc := &jwtService{
+		tokenCfg:      cfgCopy,
+		shortLivedCfg: &cfgCopy,
+		keys:          newKeySet(cfg.KeyID, key),
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if err := svc.checkEncryption(); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+// AddKey registers key under kid. key is an HMAC secret (string or []byte),
+// a private key or a public key, as accepted by KeyConfig. A key that can
+// sign becomes the current signing key; the previous ones keep validating
+// outstanding tokens until they are retired.
+func (s *jwtService) AddKey(kid string, key any) error {
+	if kid == "" {
+		return errors.New("tokens: key id is required")
+	}
+	if s.paseto != nil {
+		return errors.New("tokens: key rotation is not supported for PASETO tokens")
+	}
+	k, err := newJWTKey(key)
+	if err != nil {
+		return err
+	}
+	s.keys.add(kid, k)
+	return nil
+}
+
+// RetireKey stops accepting tokens signed with kid. The current signing key
+// cannot be retired; add its replacement first.
+func (s *jwtService) RetireKey(kid string) error {
+	return s.keys.retire(kid)
+}
+
+type jwtKey struct {
+	method jwt.SigningMethod
+	sign   any // nil for keys that only validate
+	verify any
+}
+
+func hmacKey(secret string) *jwtKey {
+	return &jwtKey{method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)}
+}
+
+func newJWTKey(key any) (*jwtKey, error) {
+	switch k := key.(type) {
+	case string:
+		if k == "" {
+			return nil, ErrNoSecret
+		}
+		return hmacKey(k), nil
+	case []byte:
+		if len(k) == 0 {
+			return nil, ErrNoSecret
+		}
+		return hmacKey(string(k)), nil
+	case crypto.Signer:
+		method, err := signingMethodFor(k.Public())
+		if err != nil {
+			return nil, err
+		}
+		return &jwtKey{method: method, sign: k, verify: k.Public()}, nil
+	}
+	method, err := signingMethodFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return &jwtKey{method: method, verify: key}, nil
+}
+
+// signingMethodFor picks the JWT algorithm matching a public key.
+func signingMethodFor(pub crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("%w: ecdsa curve %s", ErrUnsupportedKey, k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, pub)
+}
+
+// keySet holds the validation keys by kid and the current signing key.
+// Tokens without a kid header are checked against the key with an empty kid.
+// Keys missing locally are looked up in the remote JWKS, if any.
+type keySet struct {
+	mu      sync.RWMutex
+	keys    map[string]*jwtKey
+	current string
+	signer  *jwtKey
+	remote  *jwksSource
+	source  *providedKey
+}
+
+func newKeySet(kid string, key *jwtKey) *keySet {
+	ks := &keySet{keys: map[string]*jwtKey{}}
+	ks.add(kid, key)
+	return ks
+}
+
+func (ks *keySet) add(kid string, key *jwtKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[kid] = key
+	if key.sign != nil {
+		ks.current, ks.signer = kid, key
+	}
+}
+
+func (ks *keySet) retire(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[kid]; !ok {
+		return ErrKeyNotFound
+	}
+	if ks.signer != nil && ks.current == kid {
+		return ErrKeyInUse
+	}
+	delete(ks.keys, kid)
+	return nil
+}
+
+func (ks *keySet) signing() (string, *jwtKey) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.current, ks.signer
+}
+
+func (ks *keySet) lookup(kid string) (*jwtKey, bool) {
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+	if !ok && ks.remote != nil {
+		return ks.remote.lookup(kid)
+	}
+	return key, ok
+}
@@ -0,0 +1,180 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testKeyConfig() *ShortLivedTokenConfig {
+	return &ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+	}
+}
+
+func TestServiceWithKeysSignsAndValidates(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{"rsa", rsaKey, "RS256"},
+		{"ecdsa", ecKey, "ES256"},
+		{"eddsa", edKey, "EdDSA"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := NewServiceWithKeys(testKeyConfig(), KeyConfig{PrivateKey: tc.key})
+			if err != nil {
+				t.Fatalf("failed to create signer: %v", err)
+			}
+			verifier, err := NewServiceWithKeys(testKeyConfig(), KeyConfig{PublicKey: tc.key.Public()})
+			if err != nil {
+				t.Fatalf("failed to create verifier: %v", err)
+			}
+
+			token, _, err := signer.GenerateToken("user123", "user@test.com", nil)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Method.Alg() != tc.alg {
+				t.Errorf("expected alg %s, got %s", tc.alg, parsed.Method.Alg())
+			}
+
+			claims, err := verifier.ValidateTokenAndGetClaims(token)
+			if err != nil {
+				t.Fatalf("failed to validate token: %v", err)
+			}
+			if sub, _ := GetStringClaim(claims, "sub"); sub != "user123" {
+				t.Errorf("expected sub=user123, got %q", sub)
+			}
+
+			if _, _, err := verifier.GenerateToken("user123", "", nil); !errors.Is(err, ErrNoSigningKey) {
+				t.Errorf("expected ErrNoSigningKey from verifier, got %v", err)
+			}
+		})
+	}
+}
+
+func TestServiceWithKeysRejectsOtherKeys(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	signer, err := NewServiceWithKeys(testKeyConfig(), KeyConfig{PrivateKey: other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewServiceWithKeys(testKeyConfig(), KeyConfig{PublicKey: key.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, _ := signer.GenerateToken("user123", "", nil)
+	if verifier.IsTokenValid(token) {
+		t.Error("expected token signed by another key to be rejected")
+	}
+
+	hmac := newTestService(t)
+	token, _, _ = hmac.GenerateToken("user123", "", nil)
+	if verifier.IsTokenValid(token) {
+		t.Error("expected HS256 token to be rejected")
+	}
+}
+
+func TestServiceWithKeysUnsupportedKey(t *testing.T) {
+	_, err := NewServiceWithKeys(testKeyConfig(), KeyConfig{PublicKey: []byte("secret")})
+	if !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("expected ErrUnsupportedKey, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	cfg := &ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+			KeyID:          "v1",
+		},
+	}
+	svc, err := NewService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _, _ := svc.GenerateToken("user123", "", nil)
+
+	if err := svc.(KeyRotator).AddKey("v2", "rotated-secret-key-minimum-length"); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	newToken, _, _ := svc.GenerateToken("user123", "", nil)
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if kid := parsed.Header["kid"]; kid != "v2" {
+		t.Errorf("expected new tokens signed with kid v2, got %v", kid)
+	}
+
+	if !svc.IsTokenValid(oldToken) || !svc.IsTokenValid(newToken) {
+		t.Fatal("expected tokens signed by both keys to validate during rotation")
+	}
+	if err := svc.(KeyRotator).RetireKey("v2"); !errors.Is(err, ErrKeyInUse) {
+		t.Errorf("expected ErrKeyInUse when retiring the signing key, got %v", err)
+	}
+	if err := svc.(KeyRotator).RetireKey("v1"); err != nil {
+		t.Fatalf("RetireKey failed: %v", err)
+	}
+	if svc.IsTokenValid(oldToken) {
+		t.Error("expected token signed with retired key to be rejected")
+	}
+	if !svc.IsTokenValid(newToken) {
+		t.Error("expected token signed with current key to stay valid")
+	}
+	if err := svc.(KeyRotator).RetireKey("v1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestKeyRotationPublicKeyOnly(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewServiceWithKeys(testKeyConfig(), KeyConfig{PrivateKey: key})
+	if err := signer.(KeyRotator).AddKey("k2", key); err != nil {
+		t.Fatal(err)
+	}
+	token, _, _ := signer.GenerateToken("user123", "", nil)
+
+	verifier, _ := NewServiceWithKeys(testKeyConfig(), KeyConfig{PublicKey: key.Public()})
+	if verifier.IsTokenValid(token) {
+		t.Error("expected unknown kid to be rejected")
+	}
+	if err := verifier.(KeyRotator).AddKey("k2", key.Public()); err != nil {
+		t.Fatal(err)
+	}
+	if !verifier.IsTokenValid(token) {
+		t.Error("expected token to validate once its kid is known")
+	}
+}
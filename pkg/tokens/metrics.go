@@ -0,0 +1,193 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Validation failure reasons reported by WithMetrics.
+const (
+	reasonExpired      = "expired"
+	reasonNotYetValid  = "not_yet_valid"
+	reasonBadSignature = "bad_signature"
+	reasonMalformed    = "malformed"
+	reasonUnknownKey   = "unknown_key"
+	reasonClaims       = "invalid_claims"
+	reasonRevoked      = "revoked"
+	reasonInvalid      = "invalid"
+)
+
+// invalidTokenError carries the failure reason of an invalid token to the
+// metrics; callers only see ErrInvalidToken.
+type invalidTokenError struct {
+	reason string
+}
+
+func (e *invalidTokenError) Error() string { return ErrInvalidToken.Error() }
+
+func invalidToken(reason string) error {
+	return &invalidTokenError{reason: reason}
+}
+
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return reasonExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return reasonNotYetValid
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return reasonBadSignature
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return reasonMalformed
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		return reasonUnknownKey
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer), errors.Is(err, jwt.ErrTokenInvalidAudience),
+		errors.Is(err, jwt.ErrTokenInvalidClaims), errors.Is(err, ErrInvalidClaims):
+		return reasonClaims
+	}
+	return reasonInvalid
+}
+
+// MetricsConfig names the Prometheus metrics of WithMetrics.
+type MetricsConfig struct {
+	Namespace string
+	Subsystem string
+}
+
+// WithMetrics registers Prometheus metrics for the service: tokens issued
+// by type, validations by result (valid or the failure reason: expired,
+// bad_signature, revoked...), validation latency, and cache lookups of
+// CachedAuthMiddleware by result (hit, miss, error).
+func WithMetrics(config *MetricsConfig) ServiceOption {
+	return func(s *jwtService) {
+		if config == nil {
+			config = &MetricsConfig{}
+		}
+		s.metrics = newTokenMetrics(config)
+	}
+}
+
+type tokenMetrics struct {
+	issuedTotal      *prometheus.CounterVec
+	validationsTotal *prometheus.CounterVec
+	validationTime   prometheus.Histogram
+	cacheLookups     *prometheus.CounterVec
+}
+
+func newTokenMetrics(config *MetricsConfig) *tokenMetrics {
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = "tokens"
+	}
+	m := &tokenMetrics{
+		issuedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "issued_total",
+				Help:      "Tokens issued, by type",
+			},
+			[]string{"type"},
+		),
+		validationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "validations_total",
+				Help:      "Token validations, by result: valid or the failure reason",
+			},
+			[]string{"result"},
+		),
+		validationTime: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "validation_duration_seconds",
+				Help:      "Time spent validating tokens",
+				Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+			},
+		),
+		cacheLookups: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: config.Subsystem,
+				Name:      "cache_lookups_total",
+				Help:      "Token cache lookups of CachedAuthMiddleware, by result: hit, miss or error",
+			},
+			[]string{"result"},
+		),
+	}
+	m.issuedTotal = registerOrReuse(m.issuedTotal).(*prometheus.CounterVec)
+	m.validationsTotal = registerOrReuse(m.validationsTotal).(*prometheus.CounterVec)
+	m.validationTime = registerOrReuse(m.validationTime).(prometheus.Histogram)
+	m.cacheLookups = registerOrReuse(m.cacheLookups).(*prometheus.CounterVec)
+	return m
+}
+
+func registerOrReuse(c prometheus.Collector) prometheus.Collector {
+	err := prometheus.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		return are.ExistingCollector
+	}
+	logs.Error(context.Background(), "failed to register prometheus collector, metrics may be unavailable", "error", err)
+	return c
+}
+
+func (m *tokenMetrics) issued(claims jwt.MapClaims) {
+	if m == nil {
+		return
+	}
+	typ, _ := GetStringClaim(claims, "typ")
+	m.issuedTotal.WithLabelValues(typ).Inc()
+}
+
+func (m *tokenMetrics) observeValidation(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.validationTime.Observe(time.Since(start).Seconds())
+	result := "valid"
+	if err != nil {
+		var invalid *invalidTokenError
+		if errors.As(err, &invalid) {
+			result = invalid.reason
+		} else {
+			result = failureReason(err)
+		}
+	}
+	m.validationsTotal.WithLabelValues(result).Inc()
+}
+
+// cacheLookup records a CachedAuthMiddleware lookup; misses are also
+// counted as validations failed for revocation.
+func (m *tokenMetrics) cacheLookup(exists bool, err error) {
+	if m == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		m.cacheLookups.WithLabelValues("error").Inc()
+	case exists:
+		m.cacheLookups.WithLabelValues("hit").Inc()
+	default:
+		m.cacheLookups.WithLabelValues("miss").Inc()
+		m.validationsTotal.WithLabelValues(reasonRevoked).Inc()
+	}
+}
+
+// serviceMetrics returns the metrics of svc, if enabled.
+func serviceMetrics(svc Service) *tokenMetrics {
+	if s, ok := svc.(*jwtService); ok {
+		return s.metrics
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	newService := func(secret string, exp time.Duration) Service {
+		svc, err := NewService(&ShortLivedTokenConfig{
+			TokenConfig: TokenConfig{
+				SecretKey:      secret,
+				Issuer:         "test-issuer",
+				AccessTokenExp: exp,
+			},
+		}, WithMetrics(&MetricsConfig{Namespace: "test_tokens"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return svc
+	}
+	svc := newService("test-secret-key-minimum-length", time.Hour)
+	m := serviceMetrics(svc)
+
+	_, _, _, _ = svc.GenerateTokens("user123", "", nil)
+	token, exp, _ := svc.GenerateToken("user123", "", nil)
+	if got := testutil.ToFloat64(m.issuedTotal.WithLabelValues("access")); got != 2 {
+		t.Errorf("expected 2 access tokens issued, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.issuedTotal.WithLabelValues("refresh")); got != 1 {
+		t.Errorf("expected 1 refresh token issued, got %v", got)
+	}
+
+	svc.IsTokenValid(token)
+	forged, _, _ := newService("another-secret-minimum-length", time.Hour).GenerateToken("user123", "", nil)
+	svc.IsTokenValid(forged)
+	expired, _, _ := newService("test-secret-key-minimum-length", -time.Minute).GenerateToken("user123", "", nil)
+	svc.IsTokenValid(expired)
+	svc.IsTokenValid("garbage")
+
+	for result, want := range map[string]float64{"valid": 1, "bad_signature": 1, "expired": 1, "malformed": 1} {
+		if got := testutil.ToFloat64(m.validationsTotal.WithLabelValues(result)); got != want {
+			t.Errorf("validations{result=%s}: expected %v, got %v", result, want, got)
+		}
+	}
+
+	_ = cm.AddToken(context.Background(), token, "user123", exp)
+	r := gin.New()
+	r.Use(CachedAuthMiddleware(svc, cm))
+	r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, tok := range []string{token, token, forged} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+tok)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	_ = cm.RemoveToken(context.Background(), token)
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := testutil.ToFloat64(m.cacheLookups.WithLabelValues("hit")); got != 2 {
+		t.Errorf("expected 2 cache hits, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.cacheLookups.WithLabelValues("miss")); got != 1 {
+		t.Errorf("expected 1 cache miss, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.validationsTotal.WithLabelValues("revoked")); got != 1 {
+		t.Errorf("expected 1 revoked validation, got %v", got)
+	}
+}
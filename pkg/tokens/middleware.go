@@ -3,7 +3,9 @@ package tokens
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/fsandov/go-sdk/pkg/logs"
 	"github.com/gin-gonic/gin"
@@ -23,11 +25,49 @@ const (
 	accessTokenType = "access"
 )
 
+// MiddlewareOption configures a single auth middleware instance.
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	audiences     []string
+	sliding       *SlidingExpiration
+	bindingMode   BindingMode
+	bindingPolicy BindingPolicy
+	validators    []ClaimValidator
+}
+
+func newMiddlewareConfig(opts []MiddlewareOption) *middlewareConfig {
+	cfg := &middlewareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// RequireAudience only lets through tokens whose aud claim names one of
+// audiences, for services sharing a token issuer.
+func RequireAudience(audiences ...string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.audiences = append(cfg.audiences, audiences...)
+	}
+}
+
+// RequireClaims runs validate on the claims of every token accepted by the
+// middleware, for business rules such as a plan allowing the endpoint.
+// Failures are rejected with 403; see WithClaimValidator for rules that
+// apply to every use of a token.
+func RequireClaims(validate ClaimValidator) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.validators = append(cfg.validators, validate)
+	}
+}
+
 // tokenValidationResult holds the result of token validation
 type tokenValidationResult struct {
 	tokenString string
 	claims      jwt.MapClaims
 	authHeader  string
+	inGrace     bool
 }
 
 // CachedAuthMiddleware creates a new Gin middleware that validates tokens using a cache.
@@ -40,101 +80,240 @@ type tokenValidationResult struct {
 //
 // Returns:
 // CachedAuthMiddleware is a middleware that checks if the token is valid and exists in cache
-func CachedAuthMiddleware(svc Service, cacheMgr CacheManager) gin.HandlerFunc {
+func CachedAuthMiddleware(svc Service, cacheMgr CacheManager, opts ...MiddlewareOption) gin.HandlerFunc {
+	cfg := newMiddlewareConfig(opts)
 	return func(c *gin.Context) {
-		result, ok := validateTokenFromHeader(c, svc)
-		if !ok {
+		result, authErr := authenticate(c.Request.Context(), svc, cacheMgr, cfg, c.GetHeader("Authorization"), ginClient(c))
+		if authErr != nil {
+			abortWithAuthError(c, authErr)
 			return
 		}
 
-		if !validateTokenType(c, result.claims) {
-			return
-		}
-
-		exists, err := cacheMgr.TokenExists(c.Request.Context(), result.tokenString)
-		if err != nil {
-			logs.Warn(c.Request.Context(), "[CachedAuthMiddleware] error checking token in cache", "error", err)
-			// Continue execution even if cache check fails (graceful degradation)
-		} else if !exists {
-			logs.Info(c.Request.Context(), "[CachedAuthMiddleware] token not found in cache or revoked")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked or expired"})
-			c.Abort()
-			return
-		}
+		acceptToken(c, svc, cacheMgr, cfg, result)
+	}
+}
 
-		setUserContext(c, result.claims, result.authHeader)
+// acceptToken flags grace period tokens or slides the expiration of the
+// others, and stores the caller in the context. It returns the renewed
+// token and its expiry, if any.
+func acceptToken(c *gin.Context, svc Service, cacheMgr CacheManager, cfg *middlewareConfig, result *tokenValidationResult) (string, time.Time) {
+	var renewed string
+	var renewedExp time.Time
+	if result.inGrace {
+		c.Set(KeyGracePeriod, true)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), graceContextKey{}, true))
+	} else {
+		renewed, renewedExp = slideExpiration(c.Request.Context(), c.Writer.Header(), svc, cacheMgr, cfg, result)
 	}
+	setUserContext(c, result.claims, result.authHeader)
+	return renewed, renewedExp
 }
 
 // AuthMiddleware creates a new Gin middleware that validates JWT tokens without caching.
 // This is the original implementation that validates the token on every request.
 // For better performance, consider using CachedAuthMiddleware instead.
-func AuthMiddleware(tokenSvc Service) gin.HandlerFunc {
+func AuthMiddleware(tokenSvc Service, opts ...MiddlewareOption) gin.HandlerFunc {
+	cfg := newMiddlewareConfig(opts)
 	return func(c *gin.Context) {
-		result, ok := validateTokenFromHeader(c, tokenSvc)
-		if !ok {
-			return
-		}
-
-		if !validateTokenType(c, result.claims) {
+		result, authErr := authenticate(c.Request.Context(), tokenSvc, nil, cfg, c.GetHeader("Authorization"), ginClient(c))
+		if authErr != nil {
+			abortWithAuthError(c, authErr)
 			return
 		}
 
+		slideExpiration(c.Request.Context(), c.Writer.Header(), tokenSvc, nil, cfg, result)
 		setUserContext(c, result.claims, result.authHeader)
 	}
 }
 
-// validateTokenFromHeader extracts and validates the JWT token from the Authorization header
-func validateTokenFromHeader(c *gin.Context, svc Service) (*tokenValidationResult, bool) {
-	authHeader := c.GetHeader("Authorization")
+// authError is an authentication failure, rendered as {"error": message}
+// by each framework adapter.
+type authError struct {
+	status  int
+	message string
+}
+
+func (e *authError) Error() string { return e.message }
+
+func unauthorized(message string) *authError {
+	return &authError{status: http.StatusUnauthorized, message: message}
+}
+
+// authenticate runs the checks shared by every auth middleware, whatever the
+// framework: bearer token, signature and claims, token type and audience,
+// client binding and, when cacheMgr is set, presence in the cache.
+func authenticate(ctx context.Context, svc Service, cacheMgr CacheManager, cfg *middlewareConfig, authHeader string, client clientInfo) (*tokenValidationResult, *authError) {
+	tokenString, authErr := bearerToken(authHeader)
+	if authErr != nil {
+		return nil, authErr
+	}
 
+	result, authErr := checkToken(ctx, svc, tokenString, authHeader)
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	if authErr := checkClaims(ctx, svc, cfg, result.claims, client); authErr != nil {
+		return nil, authErr
+	}
+
+	if cacheMgr != nil {
+		exists, err := cacheMgr.TokenExists(ctx, tokenString)
+		serviceMetrics(svc).cacheLookup(exists, err)
+		if err != nil {
+			logs.Warn(ctx, "[CachedAuthMiddleware] error checking token in cache", "error", err)
+			// Continue execution even if cache check fails (graceful degradation)
+		} else if !exists {
+			if markers, ok := cacheMgr.(MarkerCacheManager); ok {
+				if inGrace, _ := markers.TokenMarked(ctx, graceMarker, tokenString); inGrace {
+					logs.Info(ctx, "[CachedAuthMiddleware] token accepted within the grace period")
+					result.inGrace = true
+					return result, nil
+				}
+			}
+			logs.Info(ctx, "[CachedAuthMiddleware] token not found in cache or revoked")
+			return nil, unauthorized("token has been revoked or expired")
+		}
+	}
+
+	return result, nil
+}
+
+func bearerToken(authHeader string) (string, *authError) {
 	if len(authHeader) <= len(bearerPrefix) || !strings.HasPrefix(authHeader, bearerPrefix) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or malformed Authorization header"})
-		c.Abort()
-		return nil, false
+		return "", unauthorized("missing or malformed Authorization header")
 	}
 
 	tokenString := strings.TrimSpace(authHeader[len(bearerPrefix):])
 	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "token is empty"})
-		c.Abort()
-		return nil, false
+		return "", unauthorized("token is empty")
 	}
+	return tokenString, nil
+}
 
+func checkToken(ctx context.Context, svc Service, tokenString, authHeader string) (*tokenValidationResult, *authError) {
 	claims, err := svc.ValidateTokenAndGetClaims(tokenString)
 	if err != nil {
-		logs.Info(c.Request.Context(), "[TokenValidation] token validation failed", "error", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
-		c.Abort()
-		return nil, false
+		logs.Info(ctx, "[TokenValidation] token validation failed", "error", err)
+		return nil, unauthorized("invalid or expired token")
 	}
 
 	return &tokenValidationResult{
 		tokenString: tokenString,
 		claims:      claims,
 		authHeader:  authHeader,
-	}, true
+	}, nil
+}
+
+// checkClaims runs the per-middleware checks on validated claims.
+func checkClaims(ctx context.Context, svc Service, cfg *middlewareConfig, claims jwt.MapClaims, client clientInfo) *authError {
+	if authErr := checkTokenType(ctx, svc, claims); authErr != nil {
+		return authErr
+	}
+	if authErr := checkAudience(ctx, cfg, claims); authErr != nil {
+		return authErr
+	}
+	if authErr := checkBinding(ctx, cfg, claims, client); authErr != nil {
+		return authErr
+	}
+	for _, validate := range cfg.validators {
+		if err := validate(claims); err != nil {
+			sub, _ := claims.GetSubject()
+			logs.Info(ctx, "[TokenValidation] claim validation failed", "user_id", sub, "error", err)
+			return &authError{status: http.StatusForbidden, message: "access denied"}
+		}
+	}
+	return nil
 }
 
-func validateTokenType(c *gin.Context, claims jwt.MapClaims) bool {
+func checkTokenType(ctx context.Context, svc Service, claims jwt.MapClaims) *authError {
 	typ, _ := GetStringClaim(claims, "typ")
+	if typ == "" && acceptsUntypedTokens(svc, claims) {
+		return nil
+	}
 	if typ != accessTokenType {
-		logs.Info(c.Request.Context(), "[TokenValidation] invalid token type", "type", typ)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token type"})
-		c.Abort()
+		logs.Info(ctx, "[TokenValidation] invalid token type", "type", typ)
+		return unauthorized("invalid token type")
+	}
+	return nil
+}
+
+func checkAudience(ctx context.Context, cfg *middlewareConfig, claims jwt.MapClaims) *authError {
+	if len(cfg.audiences) == 0 {
+		return nil
+	}
+	aud, _ := claims.GetAudience()
+	for _, a := range aud {
+		if slices.Contains(cfg.audiences, a) {
+			return nil
+		}
+	}
+	logs.Info(ctx, "[TokenValidation] token not issued for this audience", "aud", []string(aud))
+	return unauthorized("invalid token audience")
+}
+
+func checkSubject(ctx context.Context, claims jwt.MapClaims) (string, *authError) {
+	userID, _ := GetStringClaim(claims, "sub")
+	if userID == "" {
+		logs.Warn(ctx, "[AuthMiddleware] missing 'sub' in claims")
+		return "", unauthorized("invalid token: no subject")
+	}
+	return userID, nil
+}
+
+func abortWithAuthError(c *gin.Context, err *authError) {
+	c.JSON(err.status, gin.H{"error": err.message})
+	c.Abort()
+}
+
+// validateTokenFromHeader extracts and validates the JWT token from the Authorization header
+func validateTokenFromHeader(c *gin.Context, svc Service) (*tokenValidationResult, bool) {
+	authHeader := c.GetHeader("Authorization")
+	tokenString, authErr := bearerToken(authHeader)
+	if authErr != nil {
+		abortWithAuthError(c, authErr)
+		return nil, false
+	}
+
+	return validateToken(c, svc, tokenString, authHeader)
+}
+
+// validateToken validates tokenString, aborting with 401 when it is invalid
+func validateToken(c *gin.Context, svc Service, tokenString, authHeader string) (*tokenValidationResult, bool) {
+	result, authErr := checkToken(c.Request.Context(), svc, tokenString, authHeader)
+	if authErr != nil {
+		abortWithAuthError(c, authErr)
+		return nil, false
+	}
+	return result, true
+}
+
+// validateClaims runs checkClaims, aborting the request on failure
+func validateClaims(c *gin.Context, svc Service, cfg *middlewareConfig, claims jwt.MapClaims) bool {
+	if authErr := checkClaims(c.Request.Context(), svc, cfg, claims, ginClient(c)); authErr != nil {
+		abortWithAuthError(c, authErr)
 		return false
 	}
 	return true
 }
 
+// acceptsUntypedTokens reports whether svc validates tokens from an external
+// issuer, which carry no typ claim.
+func acceptsUntypedTokens(svc Service, claims jwt.MapClaims) bool {
+	s, ok := svc.(*jwtService)
+	if ok && s.issuers != nil {
+		iss, _ := claims.GetIssuer()
+		s, ok = s.issuers[iss]
+	}
+	return ok && s.keys.remote != nil
+}
+
 // setUserContext sets the user-related values in the Gin context.
 // It extracts the user ID from the claims and sets the Authorization header, user ID, and claims in the context.
 func setUserContext(c *gin.Context, claims jwt.MapClaims, authHeader string) {
-	userID, _ := GetStringClaim(claims, "sub")
-	if userID == "" {
-		logs.Warn(c.Request.Context(), "[AuthMiddleware] missing 'sub' in claims")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token: no subject"})
-		c.Abort()
+	userID, authErr := checkSubject(c.Request.Context(), claims)
+	if authErr != nil {
+		abortWithAuthError(c, authErr)
 		return
 	}
 
@@ -150,8 +329,7 @@ func setUserContext(c *gin.Context, claims jwt.MapClaims, authHeader string) {
 		c.Set(KeyEmail, email)
 	}
 
-	ctx := context.WithValue(c.Request.Context(), AuthContextKey, authHeader)
-	c.Request = c.Request.WithContext(ctx)
+	c.Request = c.Request.WithContext(withAuth(c.Request.Context(), claims, authHeader))
 
 	c.Next()
 }
@@ -0,0 +1,90 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+var ErrInvalidOneTimeToken = errors.New("invalid or expired one-time token")
+
+// Common one-time token purposes.
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+)
+
+const defaultOneTimeTokenTTL = 15 * time.Minute
+
+// OneTimeTokens issues opaque tokens for links sent by email, such as email
+// verification and password reset. A token is bound to a purpose, expires
+// after its TTL and can be consumed once:
+//
+//	token, _ := otts.GenerateOneTimeToken(ctx, tokens.PurposePasswordReset, userID, 30*time.Minute)
+//	// ... later, from the reset link
+//	userID, err := otts.ConsumeOneTimeToken(ctx, tokens.PurposePasswordReset, token)
+type OneTimeTokens interface {
+	GenerateOneTimeToken(ctx context.Context, purpose, userID string, ttl time.Duration) (string, error)
+	ConsumeOneTimeToken(ctx context.Context, purpose, token string) (userID string, err error)
+}
+
+type oneTimeTokens struct {
+	cache cache.Cache
+}
+
+// NewOneTimeTokens stores one-time tokens in cache. Only a hash of each
+// token is stored.
+func NewOneTimeTokens(cache cache.Cache) OneTimeTokens {
+	return &oneTimeTokens{
+		cache: cache,
+	}
+}
+
+// GenerateOneTimeToken issues a token for userID; ttl defaults to 15 minutes.
+func (o *oneTimeTokens) GenerateOneTimeToken(ctx context.Context, purpose, userID string, ttl time.Duration) (string, error) {
+	if purpose == "" || userID == "" {
+		return "", fmt.Errorf("purpose and userID cannot be empty")
+	}
+	if ttl <= 0 {
+		ttl = defaultOneTimeTokenTTL
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := o.cache.Set(ctx, oneTimeTokenKey(purpose, token), userID, ttl); err != nil {
+		return "", fmt.Errorf("failed to store one-time token: %w", err)
+	}
+	return token, nil
+}
+
+// ConsumeOneTimeToken returns the user the token was issued for and
+// invalidates it. Unknown, expired, already used or other-purpose tokens
+// return ErrInvalidOneTimeToken.
+func (o *oneTimeTokens) ConsumeOneTimeToken(ctx context.Context, purpose, token string) (string, error) {
+	if purpose == "" || token == "" {
+		return "", ErrInvalidOneTimeToken
+	}
+	userID, err := o.cache.GetDel(ctx, oneTimeTokenKey(purpose, token))
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return "", ErrInvalidOneTimeToken
+		}
+		return "", fmt.Errorf("failed to consume one-time token: %w", err)
+	}
+	return userID, nil
+}
+
+func oneTimeTokenKey(purpose, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("one_time:%s:%s", purpose, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,65 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func TestOneTimeTokens(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	otts := NewOneTimeTokens(c)
+	ctx := context.Background()
+
+	token, err := otts.GenerateOneTimeToken(ctx, PurposePasswordReset, "user123", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateOneTimeToken failed: %v", err)
+	}
+
+	if _, err := otts.ConsumeOneTimeToken(ctx, PurposeEmailVerification, token); !errors.Is(err, ErrInvalidOneTimeToken) {
+		t.Errorf("expected ErrInvalidOneTimeToken for another purpose, got %v", err)
+	}
+
+	userID, err := otts.ConsumeOneTimeToken(ctx, PurposePasswordReset, token)
+	if err != nil || userID != "user123" {
+		t.Fatalf("expected user123, got %q (%v)", userID, err)
+	}
+
+	if _, err := otts.ConsumeOneTimeToken(ctx, PurposePasswordReset, token); !errors.Is(err, ErrInvalidOneTimeToken) {
+		t.Errorf("expected the token to be single-use, got %v", err)
+	}
+}
+
+func TestOneTimeTokenConcurrentConsume(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	otts := NewOneTimeTokens(c)
+	ctx := context.Background()
+
+	token, _ := otts.GenerateOneTimeToken(ctx, PurposeEmailVerification, "user123", 0)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	consumed := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := otts.ConsumeOneTimeToken(ctx, PurposeEmailVerification, token); err == nil {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if consumed != 1 {
+		t.Errorf("expected exactly one consumer, got %d", consumed)
+	}
+}
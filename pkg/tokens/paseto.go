@@ -0,0 +1,245 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// NewPASETOLocalService creates a token service issuing PASETO v4.local
+// tokens: claims are encrypted and authenticated with a 32-byte symmetric
+// key, so clients cannot read them. PASETO has no algorithm negotiation,
+// which rules out the algorithm-confusion attacks JWT is prone to.
+// SecretKey is ignored; key rotation is not supported.
+func NewPASETOLocalService(cfg *ShortLivedTokenConfig, key []byte, opts ...ServiceOption) (Service, error) {
+	if len(key) != chacha20.KeySize {
+		return nil, errors.New("tokens: PASETO local key must be 32 bytes")
+	}
+	codec := &pasetoCodec{purpose: "local", localKey: append([]byte(nil), key...)}
+	return newPASETOService(cfg, codec, opts)
+}
+
+// NewPASETOPublicService creates a token service issuing PASETO v4.public
+// tokens, signed with Ed25519. As with NewServiceWithKeys, resource servers
+// only need PublicKey.
+func NewPASETOPublicService(cfg *ShortLivedTokenConfig, keys KeyConfig, opts ...ServiceOption) (Service, error) {
+	codec := &pasetoCodec{purpose: "public"}
+	if keys.PrivateKey != nil {
+		sk, ok := keys.PrivateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("tokens: PASETO v4.public requires an ed25519.PrivateKey")
+		}
+		codec.signKey = sk
+		if keys.PublicKey == nil {
+			keys.PublicKey = sk.Public()
+		}
+	}
+	pk, ok := keys.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("tokens: PASETO v4.public requires an ed25519 key")
+	}
+	codec.verifyKey = pk
+	return newPASETOService(cfg, codec, opts)
+}
+
+func newPASETOService(cfg *ShortLivedTokenConfig, codec *pasetoCodec, opts []ServiceOption) (Service, error) {
+	if cfg == nil {
+		return nil, errors.New("tokens: config is nil")
+	}
+	if cfg.Issuer == "" {
+		return nil, ErrNoIssuer
+	}
+	if cfg.AccessTokenExp == 0 {
+		cfg.AccessTokenExp = 4 * time.Hour
+	}
+	if cfg.RefreshTokenExp == 0 {
+		cfg.RefreshTokenExp = 24 * time.Hour
+	}
+
+	cfgCopy := *cfg
+	svc := &jwtService{
+		tokenCfg:      cfgCopy,
+		shortLivedCfg: &cfgCopy,
+		keys:          &keySet{keys: map[string]*jwtKey{}},
+		paseto:        codec,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if svc.encKey != nil || svc.requireJWE {
+		return nil, errors.New("tokens: WithEncryption and RequireEncryption do not apply to PASETO; use NewPASETOLocalService to encrypt tokens")
+	}
+
+	return svc, nil
+}
+
+func (s *jwtService) validatePASETO(tokenString string, checkClaims bool) (jwt.MapClaims, error) {
+	claims, err := s.paseto.decode(tokenString)
+	if err != nil {
+		return nil, invalidToken(reasonBadSignature)
+	}
+	if !checkClaims {
+		return claims, nil
+	}
+	tokenCfg := s.getTokenConfig()
+	validator := jwt.NewValidator(jwt.WithIssuer(tokenCfg.Issuer), jwt.WithAudience(tokenCfg.Audience...))
+	if err := validator.Validate(claims); err != nil {
+		return nil, invalidToken(failureReason(err))
+	}
+	return claims, nil
+}
+
+// pasetoCodec implements PASETO v4. Tokens are issued without footer or
+// implicit assertion; footers are accepted when decoding.
+type pasetoCodec struct {
+	purpose   string
+	localKey  []byte
+	signKey   ed25519.PrivateKey
+	verifyKey ed25519.PublicKey
+}
+
+// Registered time claims are RFC 3339 strings in PASETO and numbers in JWT;
+// they are converted so claims read the same with both formats.
+var pasetoTimeClaims = []string{"exp", "iat", "nbf"}
+
+func (p *pasetoCodec) encode(claims jwt.MapClaims) (string, error) {
+	payload := make(map[string]any, len(claims))
+	for k, v := range claims {
+		payload[k] = v
+	}
+	for _, k := range pasetoTimeClaims {
+		if unix, ok := payload[k].(int64); ok {
+			payload[k] = time.Unix(unix, 0).UTC().Format(time.RFC3339)
+		}
+	}
+	m, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	header := "v4." + p.purpose + "."
+	var body []byte
+	if p.purpose == "local" {
+		n := make([]byte, 32)
+		if _, err := rand.Read(n); err != nil {
+			return "", err
+		}
+		c, err := p.xor(n, m)
+		if err != nil {
+			return "", err
+		}
+		t := p.tag(header, n, c, nil)
+		body = append(append(n, c...), t...)
+	} else {
+		if p.signKey == nil {
+			return "", ErrNoSigningKey
+		}
+		sig := ed25519.Sign(p.signKey, pae([]byte(header), m, nil, nil))
+		body = append(m, sig...)
+	}
+	return header + base64.RawURLEncoding.EncodeToString(body), nil
+}
+
+func (p *pasetoCodec) decode(token string) (jwt.MapClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != "v4" || parts[1] != p.purpose {
+		return nil, errors.New("not a PASETO v4." + p.purpose + " token")
+	}
+	header := "v4." + p.purpose + "."
+	body, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	var footer []byte
+	if len(parts) == 4 {
+		if footer, err = base64.RawURLEncoding.DecodeString(parts[3]); err != nil {
+			return nil, err
+		}
+	}
+
+	var m []byte
+	if p.purpose == "local" {
+		if len(body) < 64 {
+			return nil, errors.New("token too short")
+		}
+		n, c, t := body[:32], body[32:len(body)-32], body[len(body)-32:]
+		if !hmac.Equal(t, p.tag(header, n, c, footer)) {
+			return nil, errors.New("invalid authentication tag")
+		}
+		if m, err = p.xor(n, c); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(body) < ed25519.SignatureSize {
+			return nil, errors.New("token too short")
+		}
+		split := len(body) - ed25519.SignatureSize
+		m = body[:split]
+		if !ed25519.Verify(p.verifyKey, pae([]byte(header), m, footer, nil), body[split:]) {
+			return nil, errors.New("invalid signature")
+		}
+	}
+
+	var claims jwt.MapClaims
+	if err := json.Unmarshal(m, &claims); err != nil {
+		return nil, err
+	}
+	for _, k := range pasetoTimeClaims {
+		if s, ok := claims[k].(string); ok {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, err
+			}
+			claims[k] = float64(t.Unix())
+		}
+	}
+	return claims, nil
+}
+
+// xor runs XChaCha20 with the encryption key and nonce derived from n.
+func (p *pasetoCodec) xor(n, in []byte) ([]byte, error) {
+	mac, _ := blake2b.New(56, p.localKey)
+	mac.Write([]byte("paseto-encryption-key"))
+	mac.Write(n)
+	tmp := mac.Sum(nil)
+	cipher, err := chacha20.NewUnauthenticatedCipher(tmp[:32], tmp[32:])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.XORKeyStream(out, in)
+	return out, nil
+}
+
+// tag is the BLAKE2b-MAC over the pre-authentication encoding.
+func (p *pasetoCodec) tag(header string, n, c, footer []byte) []byte {
+	mac, _ := blake2b.New256(p.localKey)
+	mac.Write([]byte("paseto-auth-key-for-aead"))
+	mac.Write(n)
+	ak := mac.Sum(nil)
+
+	t, _ := blake2b.New256(ak)
+	t.Write(pae([]byte(header), n, c, footer, nil))
+	return t.Sum(nil)
+}
+
+// pae is the PASETO pre-authentication encoding.
+func pae(pieces ...[]byte) []byte {
+	out := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces)))
+	for _, piece := range pieces {
+		out = binary.LittleEndian.AppendUint64(out, uint64(len(piece)))
+		out = append(out, piece...)
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPASETOLocalService(t *testing.T) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	svc, err := NewPASETOLocalService(testKeyConfig(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, exp, err := svc.GenerateToken("user123", "user@test.com", map[string]any{"plan": "pro"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if !strings.HasPrefix(token, "v4.local.") {
+		t.Fatalf("expected a v4.local token, got %q", token)
+	}
+	if strings.Contains(token, "eyJ") {
+		t.Error("expected claims to be encrypted")
+	}
+
+	claims, err := svc.ValidateTokenAndGetClaims(token)
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if plan, _ := GetStringClaim(claims, "plan"); plan != "pro" {
+		t.Errorf("expected plan=pro, got %q", plan)
+	}
+	if got, _ := claims.GetExpirationTime(); got == nil || got.Unix() != exp.Unix() {
+		t.Errorf("expected exp %v, got %v", exp, got)
+	}
+
+	tampered := token[:len(token)-2] + "AA"
+	if svc.IsTokenValid(tampered) {
+		t.Error("expected tampered token to be rejected")
+	}
+	other := make([]byte, 32)
+	_, _ = rand.Read(other)
+	otherSvc, _ := NewPASETOLocalService(testKeyConfig(), other)
+	if otherSvc.IsTokenValid(token) {
+		t.Error("expected token encrypted with another key to be rejected")
+	}
+}
+
+func TestPASETOPublicService(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := NewPASETOPublicService(testKeyConfig(), KeyConfig{PrivateKey: priv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewPASETOPublicService(testKeyConfig(), KeyConfig{PublicKey: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access, refresh, _, err := signer.GenerateTokens("user123", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := verifier.ValidateTokenAndGetClaims(access)
+	if err != nil {
+		t.Fatalf("failed to validate token: %v", err)
+	}
+	if typ, _ := GetStringClaim(claims, "typ"); typ != "access" {
+		t.Errorf("expected typ=access, got %q", typ)
+	}
+	if typ, _ := GetStringClaim(mustClaims(t, verifier, refresh), "typ"); typ != "refresh" {
+		t.Errorf("expected typ=refresh, got %q", typ)
+	}
+
+	jwtToken, _, _ := newTestService(t).GenerateToken("user123", "", nil)
+	if verifier.IsTokenValid(jwtToken) {
+		t.Error("expected JWT to be rejected by a PASETO service")
+	}
+	if _, _, err := verifier.GenerateToken("user123", "", nil); err == nil {
+		t.Error("expected verifier to refuse to sign tokens")
+	}
+}
+
+func TestPASETORejectsEncryptionOptions(t *testing.T) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	for name, opt := range map[string]ServiceOption{
+		"WithEncryption":    WithEncryption(key),
+		"RequireEncryption": RequireEncryption(),
+	} {
+		if _, err := NewPASETOLocalService(testKeyConfig(), key, opt); err == nil {
+			t.Errorf("expected NewPASETOLocalService to reject %s", name)
+		}
+		if _, err := NewPASETOPublicService(testKeyConfig(), KeyConfig{PublicKey: pub}, opt); err == nil {
+			t.Errorf("expected NewPASETOPublicService to reject %s", name)
+		}
+	}
+}
+
+func TestPASETOPublicVector(t *testing.T) {
+	// Test vector 4-S-1 from the PASETO specification.
+	pk, _ := hex.DecodeString("1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	codec := &pasetoCodec{purpose: "public", verifyKey: pk}
+	token := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	claims, err := codec.decode(token)
+	if err != nil {
+		t.Fatalf("failed to decode test vector: %v", err)
+	}
+	if data, _ := GetStringClaim(claims, "data"); data != "this is a signed message" {
+		t.Errorf("unexpected data %q", data)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp == nil || !exp.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected exp %v", exp)
+	}
+}
+
+func TestPASETOLocalVectors(t *testing.T) {
+	// Test vectors 4-E-1 and 4-E-2 from the PASETO specification; both use
+	// an all-zero nonce.
+	key, _ := hex.DecodeString("707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f")
+	codec := &pasetoCodec{purpose: "local", localKey: key}
+	vectors := []struct {
+		name, token, payload, data string
+	}{
+		{
+			name:    "4-E-1",
+			token:   "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg",
+			payload: `{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`,
+			data:    "this is a secret message",
+		},
+		{
+			name:    "4-E-2",
+			token:   "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvS2csCgglvpk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XIemu9chy3WVKvRBfg6t8wwYHK0ArLxxfZP73W_vfwt5A",
+			payload: `{"data":"this is a hidden message","exp":"2022-01-01T00:00:00+00:00"}`,
+			data:    "this is a hidden message",
+		},
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			claims, err := codec.decode(v.token)
+			if err != nil {
+				t.Fatalf("failed to decode test vector: %v", err)
+			}
+			if data, _ := GetStringClaim(claims, "data"); data != v.data {
+				t.Errorf("unexpected data %q", data)
+			}
+			if exp, _ := claims.GetExpirationTime(); exp == nil || !exp.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected exp %v", exp)
+			}
+
+			// Sealing the payload with the vector's nonce must reproduce the
+			// token byte for byte.
+			n := make([]byte, 32)
+			c, err := codec.xor(n, []byte(v.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := append(append(n, c...), codec.tag("v4.local.", n, c, nil)...)
+			if got := "v4.local." + base64.RawURLEncoding.EncodeToString(body); got != v.token {
+				t.Errorf("encrypted token mismatch:\n got %s\nwant %s", got, v.token)
+			}
+
+			if _, err := codec.decode(v.token + ".e30"); err == nil {
+				t.Error("expected a token with an unauthenticated footer to be rejected")
+			}
+		})
+	}
+}
+
+func mustClaims(t *testing.T, svc Service, token string) map[string]any {
+	t.Helper()
+	claims, err := svc.ValidateTokenAndGetClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
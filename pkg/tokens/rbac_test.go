@@ -0,0 +1,57 @@
+package tokens
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRBACMiddlewares(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newTestService(t)
+	token, _, _ := svc.GenerateToken("user123", "", map[string]any{
+		ClaimRoles:       []string{"support"},
+		ClaimPermissions: []string{"orders:read", "orders:write"},
+	})
+
+	cases := []struct {
+		name  string
+		guard gin.HandlerFunc
+		want  int
+	}{
+		{"role", RequireRoles("support"), http.StatusOK},
+		{"missing role", RequireRoles("support", "admin"), http.StatusForbidden},
+		{"permissions", RequirePermissions("orders:read", "orders:write"), http.StatusOK},
+		{"missing permission", RequirePermissions("orders:delete"), http.StatusForbidden},
+		{"any of", Require(AnyOf(HasRole("admin"), HasPermission("orders:write"))), http.StatusOK},
+		{"all of", Require(AllOf(HasRole("admin"), HasPermission("orders:write"))), http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/orders", AuthMiddleware(svc), tc.guard, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireWithoutAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/orders", RequireRoles("admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without claims, got %d", w.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidTokenType   = errors.New("invalid token type")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+const (
+	refreshTokenType = "refresh"
+	rotatedMarker    = "rotated"
+)
+
+// RefreshTokens exchanges a refresh token for a new access and refresh token
+// pair, carrying over the email and custom claims. The old refresh token is
+// rotated out of the cache, so refresh tokens must be cached with
+// AddTokenToCache when issued; the pairs returned here are cached already.
+// The cache manager must implement MarkerCacheManager.
+//
+// Presenting a refresh token that was already rotated means it leaked: every
+// token of the user is invalidated, an alert is logged and
+// ErrRefreshTokenReused is returned, unless WithGracePeriod is set and the
+// token was rotated within it.
+func (s *jwtService) RefreshTokens(ctx context.Context, refreshToken string) (string, string, time.Time, error) {
+	if !s.isShortLived() {
+		return "", "", time.Time{}, errors.New("RefreshTokens can only be used with short-lived token configuration")
+	}
+	if s.cacheMgr == nil {
+		return "", "", time.Time{}, errors.New("cache manager not configured")
+	}
+	markers, ok := s.cacheMgr.(MarkerCacheManager)
+	if !ok {
+		return "", "", time.Time{}, errors.New("RefreshTokens requires a MarkerCacheManager")
+	}
+
+	claims, err := s.ValidateTokenAndGetClaims(refreshToken)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	if typ, _ := GetStringClaim(claims, "typ"); typ != refreshTokenType {
+		return "", "", time.Time{}, ErrInvalidTokenType
+	}
+	userID, err := GetStringClaim(claims, "sub")
+	if err != nil || userID == "" {
+		return "", "", time.Time{}, ErrInvalidClaims
+	}
+
+	inGrace, err := markers.TokenMarked(ctx, graceMarker, refreshToken)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("check refresh token: %w", err)
+	}
+	if inGrace {
+		logs.Info(ctx, "[RefreshTokens] refresh token rotated within the grace period", "user_id", userID)
+		return s.issueRefreshedPair(ctx, userID, claims)
+	}
+
+	reused, err := markers.TokenMarked(ctx, rotatedMarker, refreshToken)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("check refresh token: %w", err)
+	}
+	if reused {
+		logs.Warn(ctx, "[RefreshTokens] refresh token reuse detected, revoking all user tokens", "user_id", userID, logs.WithNotifier())
+		if err := s.cacheMgr.InvalidateAllUserTokens(ctx, userID); err != nil {
+			logs.Error(ctx, "[RefreshTokens] failed to revoke user tokens", "user_id", userID, "error", err)
+		}
+		return "", "", time.Time{}, ErrRefreshTokenReused
+	}
+
+	// Claiming the token atomically lets only one of concurrent refreshes
+	// rotate it.
+	consumed, err := markers.ConsumeToken(ctx, refreshToken)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("rotate refresh token: %w", err)
+	}
+	if !consumed {
+		return "", "", time.Time{}, ErrTokenRevoked
+	}
+
+	s.markGrace(ctx, refreshToken, claims)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		if err := markers.MarkToken(ctx, rotatedMarker, refreshToken, "", exp.Time); err != nil {
+			logs.Warn(ctx, "[RefreshTokens] failed to mark refresh token as rotated", "user_id", userID, "error", err)
+		}
+	}
+
+	return s.issueRefreshedPair(ctx, userID, claims)
+}
+
+// issueRefreshedPair generates and caches the pair replacing the refresh
+// token with claims.
+func (s *jwtService) issueRefreshedPair(ctx context.Context, userID string, claims jwt.MapClaims) (string, string, time.Time, error) {
+	email, _ := GetStringClaim(claims, "email")
+	accessToken, newRefreshToken, refreshExp, err := s.GenerateTokens(userID, email, customClaims(claims))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	accessExp := time.Now().Add(s.getTokenConfig().AccessTokenExp)
+	if err := s.AddTokenToCache(ctx, accessToken, userID, accessExp); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("cache access token: %w", err)
+	}
+	if err := s.AddTokenToCache(ctx, newRefreshToken, userID, refreshExp); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("cache refresh token: %w", err)
+	}
+
+	return accessToken, newRefreshToken, refreshExp, nil
+}
+
+// customClaims returns the claims set by the caller when the token was
+// issued, without the registered ones.
+func customClaims(claims map[string]any) map[string]any {
+	out := make(map[string]any, len(claims))
+	for k, v := range claims {
+		switch k {
+		case "sub", "iss", "iat", "nbf", "exp", "typ", "jti", "aud", "email":
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
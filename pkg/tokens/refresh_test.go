@@ -0,0 +1,155 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+)
+
+func TestRefreshTokensRotationAndReuse(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	ctx := context.Background()
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+		RefreshTokenExp: 24 * time.Hour,
+	}, WithCache(NewCacheManager(c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access, refresh, refreshExp, err := svc.GenerateTokens("user123", "user@test.com", map[string]any{"role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddTokenToCache(ctx, refresh, "user123", refreshExp); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, access); !errors.Is(err, ErrInvalidTokenType) {
+		t.Errorf("expected ErrInvalidTokenType for an access token, got %v", err)
+	}
+
+	newAccess, newRefresh, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh)
+	if err != nil {
+		t.Fatalf("RefreshTokens failed: %v", err)
+	}
+	if newRefresh == refresh {
+		t.Fatal("expected a new refresh token")
+	}
+	claims, err := svc.ValidateTokenAndGetClaims(newAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email, _ := GetStringClaim(claims, "email"); email != "user@test.com" {
+		t.Errorf("expected email to be carried over, got %q", email)
+	}
+	if role, _ := GetStringClaim(claims, "role"); role != "admin" {
+		t.Errorf("expected custom claims to be carried over, got %q", role)
+	}
+	if ok, _ := svc.TokenExistsInCache(ctx, newAccess); !ok {
+		t.Error("expected new access token to be cached")
+	}
+
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, newRefresh); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected reuse to revoke the rotated pair, got %v", err)
+	}
+	if ok, _ := svc.TokenExistsInCache(ctx, newAccess); ok {
+		t.Error("expected reuse to revoke the new access token")
+	}
+}
+
+func newRefreshService(t *testing.T, c cache.Cache) Service {
+	t.Helper()
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+		RefreshTokenExp: 24 * time.Hour,
+	}, WithCache(NewCacheManager(c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestRefreshTokensConcurrent(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	ctx := context.Background()
+	svc := newRefreshService(t, c)
+
+	_, refresh, refreshExp, _ := svc.GenerateTokens("user123", "", nil)
+	if err := svc.AddTokenToCache(ctx, refresh, "user123", refreshExp); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := succeeded.Load(); n != 1 {
+		t.Errorf("expected exactly one refresh to succeed, got %d", n)
+	}
+}
+
+func TestRefreshTokensMarkersOutsideUserIndex(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	ctx := context.Background()
+	svc := newRefreshService(t, c)
+
+	_, refresh, refreshExp, _ := svc.GenerateTokens("user123", "", nil)
+	_ = svc.AddTokenToCache(ctx, refresh, "user123", refreshExp)
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); err != nil {
+		t.Fatal(err)
+	}
+
+	members, _ := c.ZRange(ctx, "user_tokens:user123", 0, -1)
+	if len(members) != 2 {
+		t.Errorf("expected only the new pair in the user index, got %d members", len(members))
+	}
+	for _, m := range members {
+		if strings.HasPrefix(m, rotatedMarker) || m == refresh {
+			t.Errorf("unexpected member %q in the user index", m)
+		}
+	}
+
+	if err := svc.InvalidateAllUserTokens(ctx, "user123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected reuse to be detected after logout, got %v", err)
+	}
+}
+
+func TestRefreshTokensRequiresCache(t *testing.T) {
+	svc := newTestService(t)
+	_, refresh, _, _ := svc.GenerateTokens("user123", "", nil)
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(context.Background(), refresh); err == nil {
+		t.Error("expected an error without a cache manager")
+	}
+}
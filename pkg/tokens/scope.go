@@ -0,0 +1,38 @@
+package tokens
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimScope holds OAuth 2.0 scopes as a space-delimited string (RFC 8693).
+// GenerateToken also accepts a []string under this claim and joins it.
+const ClaimScope = "scope"
+
+// Scopes returns the scopes granted to a token, read from the scope claim
+// or from the scp list used by some identity providers.
+func Scopes(claims jwt.MapClaims) []string {
+	if scope, err := GetStringClaim(claims, ClaimScope); err == nil {
+		return strings.Fields(scope)
+	}
+	return claimValues(claims, "scp")
+}
+
+// HasScope matches tokens granted scope.
+func HasScope(scope string) Rule {
+	return func(claims jwt.MapClaims) bool {
+		return slices.Contains(Scopes(claims), scope)
+	}
+}
+
+// RequireScope requires every listed scope.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	rules := make([]Rule, len(scopes))
+	for i, scope := range scopes {
+		rules[i] = HasScope(scope)
+	}
+	return Require(AllOf(rules...))
+}
@@ -0,0 +1,57 @@
+package tokens
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestScopesAreEmittedSpaceDelimited(t *testing.T) {
+	svc := newTestService(t)
+	token, _, _ := svc.GenerateToken("user123", "", map[string]any{ClaimScope: []string{"read:users", "write:users"}})
+
+	claims, err := svc.ValidateTokenAndGetClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scope, _ := GetStringClaim(claims, ClaimScope); scope != "read:users write:users" {
+		t.Errorf("expected space-delimited scope, got %q", scope)
+	}
+}
+
+func TestScopesFromIdPClaims(t *testing.T) {
+	if got := Scopes(jwt.MapClaims{"scp": []any{"read:users"}}); len(got) != 1 || got[0] != "read:users" {
+		t.Errorf("expected scopes from scp, got %v", got)
+	}
+	if got := Scopes(jwt.MapClaims{"scope": "openid  read:users"}); len(got) != 2 || got[1] != "read:users" {
+		t.Errorf("expected scopes from scope, got %v", got)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newTestService(t)
+	token, _, _ := svc.GenerateToken("user123", "", map[string]any{ClaimScope: "openid read:users"})
+
+	for _, tc := range []struct {
+		scopes []string
+		want   int
+	}{
+		{[]string{"read:users"}, http.StatusOK},
+		{[]string{"read:users", "write:users"}, http.StatusForbidden},
+	} {
+		r := gin.New()
+		r.GET("/users", AuthMiddleware(svc), RequireScope(tc.scopes...), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("RequireScope(%v): expected %d, got %d", tc.scopes, tc.want, w.Code)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package tokens
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/fsandov/go-sdk/pkg/logs"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -15,6 +19,23 @@ type TokenConfig struct {
 	SecretKey      string
 	Issuer         string
 	AccessTokenExp time.Duration
+	// KeyID is set as the kid header of signed tokens, so the key can be
+	// rotated later with AddKey and RetireKey.
+	KeyID string
+	// Audience is emitted as the aud claim. When set, validated tokens must
+	// name at least one of these audiences.
+	Audience []string
+	// MaxActiveTokens caps the login sessions cached per user, to discourage
+	// credential sharing: beyond it AddTokenToCache evicts every token of the
+	// sessions cached first. Tokens belong to the session in their sid claim
+	// or, without one, to the tokens issued in the same second, so an access
+	// and refresh pair counts once. Zero means no limit.
+	MaxActiveTokens int
+	// KeyProvider loads the HMAC secret instead of SecretKey, lazily on
+	// first use and again every KeyRefreshInterval, so it can come from a
+	// secret manager and be rotated without a restart.
+	KeyProvider        KeyProvider
+	KeyRefreshInterval time.Duration
 }
 
 // ShortLivedTokenConfig contains configuration for short-lived access tokens with refresh tokens
@@ -71,6 +92,27 @@ type Service interface {
 	TokenExistsInCache(ctx context.Context, token string) (bool, error)
 }
 
+// TokenRefresher is implemented by services that rotate refresh tokens. The
+// services created by this package implement it:
+//
+//	access, refresh, exp, err := svc.(tokens.TokenRefresher).RefreshTokens(ctx, token)
+type TokenRefresher interface {
+	RefreshTokens(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, refreshTokenExpire time.Time, err error)
+}
+
+// TokenExchanger is implemented by services that support token exchange. The
+// services created by this package implement it.
+type TokenExchanger interface {
+	ExchangeToken(ctx context.Context, subjectToken string, opts ExchangeOptions) (string, time.Time, error)
+}
+
+// KeyRotator is implemented by services whose signing keys can be rotated
+// at runtime. The services created by this package implement it.
+type KeyRotator interface {
+	AddKey(kid string, key any) error
+	RetireKey(kid string) error
+}
+
 type tokenConfigProvider interface {
 	getBase() TokenConfig
 }
@@ -82,7 +124,14 @@ type jwtService struct {
 	tokenCfg      tokenConfigProvider
 	shortLivedCfg *ShortLivedTokenConfig
 	cacheMgr      CacheManager
-	signingMethod jwt.SigningMethod
+	keys          *keySet
+	paseto        *pasetoCodec
+	encKey        []byte
+	requireJWE    bool
+	issuers       map[string]*jwtService
+	validators    []ClaimValidator
+	gracePeriod   time.Duration
+	metrics       *tokenMetrics
 }
 
 func (s *jwtService) getTokenConfig() TokenConfig {
@@ -98,7 +147,7 @@ func NewService(cfg *ShortLivedTokenConfig, opts ...ServiceOption) (Service, err
 	if cfg == nil {
 		return nil, errors.New("tokens: config is nil")
 	}
-	if cfg.SecretKey == "" {
+	if cfg.SecretKey == "" && cfg.KeyProvider == nil {
 		return nil, ErrNoSecret
 	}
 	if cfg.Issuer == "" {
@@ -115,12 +164,15 @@ func NewService(cfg *ShortLivedTokenConfig, opts ...ServiceOption) (Service, err
 	svc := &jwtService{
 		tokenCfg:      cfgCopy,
 		shortLivedCfg: &cfgCopy,
-		signingMethod: jwt.SigningMethodHS256,
+		keys:          configKeySet(cfg.TokenConfig),
 	}
 
 	for _, opt := range opts {
 		opt(svc)
 	}
+	if err := svc.checkEncryption(); err != nil {
+		return nil, err
+	}
 
 	return svc, nil
 }
@@ -134,12 +186,25 @@ func WithCache(cacheMgr CacheManager) ServiceOption {
 	}
 }
 
+// ClaimValidator checks application rules on the claims of a valid token,
+// e.g. that the account is not suspended.
+type ClaimValidator func(claims jwt.MapClaims) error
+
+// WithClaimValidator runs validate in ValidateTokenAndGetClaims, after the
+// signature and registered claims are checked, so every middleware rejects
+// the token when it fails. The error is wrapped with ErrInvalidClaims.
+func WithClaimValidator(validate ClaimValidator) ServiceOption {
+	return func(s *jwtService) {
+		s.validators = append(s.validators, validate)
+	}
+}
+
 // NewLongLivedService creates a new token service with long-lived tokens configuration
 func NewLongLivedService(cfg *LongLivedTokenConfig, opts ...ServiceOption) (Service, error) {
 	if cfg == nil {
 		return nil, errors.New("tokens: config is nil")
 	}
-	if cfg.SecretKey == "" {
+	if cfg.SecretKey == "" && cfg.KeyProvider == nil {
 		return nil, ErrNoSecret
 	}
 	if cfg.Issuer == "" {
@@ -150,13 +215,16 @@ func NewLongLivedService(cfg *LongLivedTokenConfig, opts ...ServiceOption) (Serv
 	}
 
 	svc := &jwtService{
-		tokenCfg:      *cfg,
-		signingMethod: jwt.SigningMethodHS256,
+		tokenCfg: *cfg,
+		keys:     configKeySet(cfg.TokenConfig),
 	}
 
 	for _, opt := range opts {
 		opt(svc)
 	}
+	if err := svc.checkEncryption(); err != nil {
+		return nil, err
+	}
 
 	return svc, nil
 }
@@ -170,12 +238,12 @@ func (s *jwtService) GenerateTokens(userID, email string, customClaims map[strin
 	tokenCfg := s.getTokenConfig()
 	now := time.Now().UTC()
 
-	accessClaims := baseClaims(tokenCfg.Issuer, userID, email, customClaims)
+	accessClaims := baseClaims(tokenCfg, now, userID, email, customClaims)
 	accessClaims["exp"] = now.Add(tokenCfg.AccessTokenExp).Unix()
 	accessClaims["typ"] = "access"
 
 	refreshExp := now.Add(cfg.RefreshTokenExp)
-	refreshClaims := baseClaims(tokenCfg.Issuer, userID, "", nil)
+	refreshClaims := baseClaims(tokenCfg, now, userID, email, customClaims)
 	refreshClaims["exp"] = refreshExp.Unix()
 	refreshClaims["typ"] = "refresh"
 
@@ -197,7 +265,7 @@ func (s *jwtService) GenerateToken(userID, email string, customClaims map[string
 	now := time.Now().UTC()
 
 	tokenExp := now.Add(tokenCfg.AccessTokenExp)
-	claims := baseClaims(tokenCfg.Issuer, userID, email, customClaims)
+	claims := baseClaims(tokenCfg, now, userID, email, customClaims)
 	claims["exp"] = tokenExp.Unix()
 	claims["typ"] = "access"
 
@@ -209,20 +277,25 @@ func (s *jwtService) GenerateToken(userID, email string, customClaims map[string
 	return accessToken, tokenExp, nil
 }
 
-func baseClaims(issuer, userID, email string, customClaims map[string]any) jwt.MapClaims {
-	now := time.Now().UTC().Unix()
+// baseClaims is shared by the tokens of a pair, so they have the same iat.
+func baseClaims(cfg TokenConfig, now time.Time, userID, email string, customClaims map[string]any) jwt.MapClaims {
 	claims := jwt.MapClaims{
 		"sub": userID,
-		"iss": issuer,
-		"iat": now,
-		"nbf": now,
+		"iss": cfg.Issuer,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"jti": newTokenID(),
 	}
 	if email != "" {
 		claims["email"] = email
 	}
+	if len(cfg.Audience) > 0 {
+		claims["aud"] = cfg.Audience
+	}
 	reserved := map[string]bool{
 		"sub": true, "iss": true, "iat": true,
 		"nbf": true, "exp": true, "typ": true,
+		"jti": true, "aud": true,
 	}
 	for k, v := range customClaims {
 		if reserved[k] {
@@ -230,13 +303,48 @@ func baseClaims(issuer, userID, email string, customClaims map[string]any) jwt.M
 		}
 		claims[k] = v
 	}
+	if scopes, ok := claims[ClaimScope].([]string); ok {
+		claims[ClaimScope] = strings.Join(scopes, " ")
+	}
 	return claims
 }
 
+// newTokenID returns a random jti, which also keeps tokens issued in the same
+// second for the same user distinct.
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 func (s *jwtService) signToken(claims jwt.MapClaims) (string, error) {
-	token := jwt.NewWithClaims(s.signingMethod, claims)
-	tokenCfg := s.getTokenConfig()
-	return token.SignedString([]byte(tokenCfg.SecretKey))
+	token, err := s.sign(claims)
+	if err == nil {
+		s.metrics.issued(claims)
+	}
+	return token, err
+}
+
+func (s *jwtService) sign(claims jwt.MapClaims) (string, error) {
+	if s.paseto != nil {
+		return s.paseto.encode(claims)
+	}
+	if err := s.keys.loadProvided(); err != nil {
+		return "", err
+	}
+	kid, key := s.keys.signing()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key.sign)
+	if err != nil || s.encKey == nil {
+		return signed, err
+	}
+	return s.encrypt(signed)
 }
 
 // AddTokenToCache adds a token to the cache and associates it with the user
@@ -253,7 +361,42 @@ func (s *jwtService) AddTokenToCache(ctx context.Context, token, userID string,
 	if expiresAt.IsZero() {
 		return errors.New("expiration time is required")
 	}
-	return s.cacheMgr.AddToken(ctx, token, userID, expiresAt)
+	limit := s.getTokenConfig().MaxActiveTokens
+	sessions, ok := s.cacheMgr.(SessionCacheManager)
+	if !ok && limit > 0 {
+		return errors.New("MaxActiveTokens requires a SessionCacheManager")
+	}
+	// Tokens that cannot be verified are their own session.
+	claims, _ := s.verifiedClaims(token)
+	if err := cacheToken(ctx, s.cacheMgr, token, userID, sessionOf(claims), claims, expiresAt); err != nil {
+		return err
+	}
+	if limit > 0 {
+		evicted, err := sessions.TrimUserTokens(ctx, userID, limit)
+		if err != nil {
+			return fmt.Errorf("failed to enforce token limit: %w", err)
+		}
+		if evicted > 0 {
+			logs.Info(ctx, "[TokenService] evicted tokens over the active limit", "user_id", userID, "evicted", evicted)
+		}
+	}
+	return nil
+}
+
+// sessionOf is the session ID of a token: its sid claim, or else the time
+// of the sign in, shared by the tokens issued together and by their
+// sliding renewals.
+func sessionOf(claims jwt.MapClaims) string {
+	if sid, _ := GetStringClaim(claims, ClaimSessionID); sid != "" {
+		return "sid:" + sid
+	}
+	if v, ok := claims[ClaimAuthTime].(float64); ok {
+		return fmt.Sprintf("iat:%d", int64(v))
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		return fmt.Sprintf("iat:%d", iat.Unix())
+	}
+	return ""
 }
 
 // RemoveTokenFromCache removes a specific token from the cache
@@ -262,6 +405,9 @@ func (s *jwtService) RemoveTokenFromCache(ctx context.Context, token string) err
 	if s.cacheMgr == nil {
 		return nil
 	}
+	if claims, err := s.verifiedClaims(token); err == nil {
+		s.markGrace(ctx, token, claims)
+	}
 	return s.cacheMgr.RemoveToken(ctx, token)
 }
 
@@ -285,15 +431,73 @@ func (s *jwtService) TokenExistsInCache(ctx context.Context, token string) (bool
 }
 
 func (s *jwtService) ValidateTokenAndGetClaims(tokenString string) (jwt.MapClaims, error) {
+	start := time.Now()
+	claims, err := s.validate(tokenString, true)
+	if err == nil {
+		for _, validate := range s.validators {
+			if verr := validate(claims); verr != nil {
+				err = fmt.Errorf("%w: %w", ErrInvalidClaims, verr)
+				break
+			}
+		}
+	}
+	s.metrics.observeValidation(start, err)
+
+	var invalid *invalidTokenError
+	if errors.As(err, &invalid) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifiedClaims returns the claims of a token with a valid signature, even
+// an expired one, without running the claim validators or recording
+// metrics. It reads tokens being cached or revoked, not requests.
+func (s *jwtService) verifiedClaims(tokenString string) (jwt.MapClaims, error) {
+	return s.validate(tokenString, false)
+}
+
+// validate verifies the token and, with checkClaims, its registered claims.
+func (s *jwtService) validate(tokenString string, checkClaims bool) (jwt.MapClaims, error) {
+	if s.issuers != nil {
+		return s.validateMultiIssuer(tokenString, checkClaims)
+	}
+	if s.paseto != nil {
+		return s.validatePASETO(tokenString, checkClaims)
+	}
+	if s.encKey != nil && isJWE(tokenString) {
+		inner, err := s.decrypt(tokenString)
+		if err != nil {
+			return nil, invalidToken(reasonMalformed)
+		}
+		tokenString = inner
+	} else if s.requireJWE {
+		return nil, invalidToken(reasonMalformed)
+	}
+	if err := s.keys.loadProvided(); err != nil {
+		return nil, invalidToken(reasonUnknownKey)
+	}
 	tokenCfg := s.getTokenConfig()
+	parserOpts := []jwt.ParserOption{jwt.WithIssuer(tokenCfg.Issuer), jwt.WithAudience(tokenCfg.Audience...)}
+	if !checkClaims {
+		parserOpts = []jwt.ParserOption{jwt.WithoutClaimsValidation()}
+	}
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if token.Method != s.signingMethod {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys.lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method != key.method {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(tokenCfg.SecretKey), nil
-	}, jwt.WithIssuer(tokenCfg.Issuer))
+		return key.verify, nil
+	}, parserOpts...)
 	if err != nil || !token.Valid {
-		return nil, ErrInvalidToken
+		return nil, invalidToken(failureReason(err))
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
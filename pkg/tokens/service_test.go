@@ -2,6 +2,7 @@ package tokens
 
 import (
 	"context"
+	"crypto/rand"
 	"testing"
 	"time"
 
@@ -156,3 +157,236 @@ func TestInvalidateAllUserTokens(t *testing.T) {
 		}
 	}
 }
+
+func TestMaxActiveTokens(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc, err := NewLongLivedService(&LongLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:       "test-secret-key-minimum-length",
+			Issuer:          "test-issuer",
+			MaxActiveTokens: 2,
+		},
+	}, WithCache(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, tok := range []string{"t1", "t2", "t3"} {
+		if err := svc.AddTokenToCache(ctx, tok, "user1", now.Add(time.Duration(i+1)*time.Hour)); err != nil {
+			t.Fatalf("AddTokenToCache failed: %v", err)
+		}
+	}
+
+	for tok, want := range map[string]bool{"t1": false, "t2": true, "t3": true} {
+		if exists, _ := cm.TokenExists(ctx, tok); exists != want {
+			t.Errorf("token %s: expected exists=%v", tok, want)
+		}
+	}
+
+	_ = svc.AddTokenToCache(ctx, "other", "user2", now.Add(time.Hour))
+	if exists, _ := cm.TokenExists(ctx, "t2"); !exists {
+		t.Error("expected the limit to be per user")
+	}
+}
+
+// basicCacheManager hides the optional interfaces of the wrapped manager.
+type basicCacheManager struct{ CacheManager }
+
+func TestBasicCacheManager(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := basicCacheManager{NewCacheManager(c)}
+	ctx := context.Background()
+
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey: "test-secret-key-minimum-length",
+			Issuer:    "test-issuer",
+		},
+	}, WithCache(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, refresh, refreshExp, err := svc.GenerateTokens("user1", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddTokenToCache(ctx, refresh, "user1", refreshExp); err != nil {
+		t.Fatalf("AddTokenToCache failed: %v", err)
+	}
+	if exists, _ := svc.TokenExistsInCache(ctx, refresh); !exists {
+		t.Error("expected the token to be cached with AddToken")
+	}
+	if _, _, _, err := svc.(TokenRefresher).RefreshTokens(ctx, refresh); err == nil {
+		t.Error("expected RefreshTokens to require a MarkerCacheManager")
+	}
+
+	limited, err := NewLongLivedService(&LongLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:       "test-secret-key-minimum-length",
+			Issuer:          "test-issuer",
+			MaxActiveTokens: 2,
+		},
+	}, WithCache(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := limited.AddTokenToCache(ctx, "t1", "user1", time.Now().Add(time.Hour)); err == nil {
+		t.Error("expected MaxActiveTokens to require a SessionCacheManager")
+	}
+}
+
+func TestMaxActiveTokens_Sessions(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:       "test-secret-key-minimum-length",
+			Issuer:          "test-issuer",
+			AccessTokenExp:  time.Hour,
+			MaxActiveTokens: 2,
+		},
+		RefreshTokenExp: 24 * time.Hour,
+	}, WithCache(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	login := func(claims map[string]any) []string {
+		access, refresh, refreshExp, err := svc.GenerateTokens("user1", "", claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.AddTokenToCache(ctx, access, "user1", time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.AddTokenToCache(ctx, refresh, "user1", refreshExp); err != nil {
+			t.Fatal(err)
+		}
+		return []string{access, refresh}
+	}
+	assertCached := func(name string, tokens []string, want bool) {
+		t.Helper()
+		for _, tok := range tokens {
+			if exists, _ := cm.TokenExists(ctx, tok); exists != want {
+				t.Errorf("%s: expected exists=%v", name, want)
+			}
+		}
+	}
+
+	first := login(nil)
+	second := login(nil)
+	assertCached("first login", first, true)
+	assertCached("second login", second, true)
+
+	_ = svc.InvalidateAllUserTokens(ctx, "user1")
+	laptop := login(map[string]any{ClaimSessionID: "laptop"})
+	phone := login(map[string]any{ClaimSessionID: "phone"})
+	tablet := login(map[string]any{ClaimSessionID: "tablet"})
+	assertCached("oldest session", laptop, false)
+	assertCached("phone session", phone, true)
+	assertCached("newest session", tablet, true)
+}
+
+func TestInvalidateByClaim(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c, WithClaimIndex("tenant", ClaimRoles))
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey: "test-secret-key-minimum-length",
+			Issuer:    "test-issuer",
+		},
+	}, WithCache(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	issue := func(userID, tenant string, roles ...string) string {
+		token, exp, err := svc.GenerateToken(userID, "", map[string]any{"tenant": tenant, ClaimRoles: roles})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.AddTokenToCache(ctx, token, userID, exp); err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	acmeAdmin := issue("user1", "acme", "admin")
+	acmeUser := issue("user2", "acme", "viewer")
+	globexAdmin := issue("user3", "globex", "admin")
+
+	if err := cm.(ClaimIndexCacheManager).InvalidateByClaim(ctx, "tenant", "acme"); err != nil {
+		t.Fatalf("InvalidateByClaim failed: %v", err)
+	}
+	for token, want := range map[string]bool{acmeAdmin: false, acmeUser: false, globexAdmin: true} {
+		if exists, _ := cm.TokenExists(ctx, token); exists != want {
+			t.Errorf("expected exists=%v after revoking tenant acme", want)
+		}
+	}
+
+	if err := cm.(ClaimIndexCacheManager).InvalidateByClaim(ctx, ClaimRoles, "admin"); err != nil {
+		t.Fatalf("InvalidateByClaim failed: %v", err)
+	}
+	if exists, _ := cm.TokenExists(ctx, globexAdmin); exists {
+		t.Error("expected admin tokens to be revoked")
+	}
+}
+
+func TestInvalidateByClaim_EncryptedTokens(t *testing.T) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	cfg := &ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey: "test-secret-key-minimum-length",
+			Issuer:    "test-issuer",
+		},
+	}
+	ctx := context.Background()
+
+	for name, newService := range map[string]func(cm CacheManager) (Service, error){
+		"jwe": func(cm CacheManager) (Service, error) {
+			return NewService(cfg, WithEncryption(key), WithCache(cm))
+		},
+		"paseto": func(cm CacheManager) (Service, error) {
+			return NewPASETOLocalService(cfg, key, WithCache(cm))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := cache.NewMemoryCache()
+			defer c.Close()
+			cm := NewCacheManager(c, WithClaimIndex("tenant"))
+			svc, err := newService(cm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, exp, err := svc.GenerateToken("user1", "", map[string]any{"tenant": "acme"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.AddTokenToCache(ctx, token, "user1", exp); err != nil {
+				t.Fatal(err)
+			}
+			if err := cm.(ClaimIndexCacheManager).InvalidateByClaim(ctx, "tenant", "acme"); err != nil {
+				t.Fatal(err)
+			}
+			if exists, _ := cm.TokenExists(ctx, token); exists {
+				t.Error("expected the encrypted token to be indexed and revoked")
+			}
+
+			if err := svc.AddTokenToCache(ctx, "opaque", "user1", exp); err == nil {
+				t.Error("expected a token without verified claims to be rejected")
+			}
+			if exists, _ := cm.TokenExists(ctx, "opaque"); exists {
+				t.Error("expected the unindexed token to be removed")
+			}
+		})
+	}
+}
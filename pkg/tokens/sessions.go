@@ -0,0 +1,308 @@
+package tokens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+	// ClaimSessionID links tokens to the session they were issued for.
+	ClaimSessionID = "sid"
+	// KeySessionID is the gin context key set by SessionMiddleware.
+	KeySessionID = "session_id"
+
+	// lastSeenResolution limits how often SessionMiddleware writes LastSeen.
+	lastSeenResolution = time.Minute
+)
+
+// SessionMetadata describes the client a session was opened from.
+type SessionMetadata struct {
+	Device    string `json:"device,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// SessionMetadataFromRequest reads the client IP and user agent of a login
+// request; Device is left to the caller.
+func SessionMetadataFromRequest(c *gin.Context) SessionMetadata {
+	return SessionMetadata{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+type Session struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	SessionMetadata
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionManager tracks the logged-in sessions of each user, so they can be
+// listed on an "active devices" page and revoked one by one. Tokens are tied
+// to a session through the sid claim and rejected by SessionMiddleware once
+// it is revoked:
+//
+//	sess, _ := sessions.CreateSession(ctx, userID, tokens.SessionMetadataFromRequest(c), 30*24*time.Hour)
+//	access, refresh, exp, _ := svc.GenerateTokens(userID, email, map[string]any{tokens.ClaimSessionID: sess.ID})
+type SessionManager interface {
+	CreateSession(ctx context.Context, userID string, meta SessionMetadata, ttl time.Duration) (*Session, error)
+	GetSession(ctx context.Context, sessionID string) (*Session, error)
+	ListSessions(ctx context.Context, userID string) ([]*Session, error)
+	Touch(ctx context.Context, sessionID string) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+}
+
+type sessionManager struct {
+	cache cache.Cache
+}
+
+// NewSessionManager stores sessions in the cache used by the CacheManager.
+func NewSessionManager(cache cache.Cache) SessionManager {
+	return &sessionManager{
+		cache: cache,
+	}
+}
+
+func (sm *sessionManager) CreateSession(ctx context.Context, userID string, meta SessionMetadata, ttl time.Duration) (*Session, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID cannot be empty")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("session TTL must be positive")
+	}
+	now := time.Now().UTC()
+	sess := &Session{
+		ID:              newTokenID(),
+		UserID:          userID,
+		SessionMetadata: meta,
+		CreatedAt:       now,
+		LastSeen:        now,
+		ExpiresAt:       now.Add(ttl),
+	}
+	if err := sm.save(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	userSessionsKey := fmt.Sprintf("user_sessions:%s", userID)
+	if err := sm.cache.ZAdd(ctx, userSessionsKey, float64(sess.ExpiresAt.Unix()), sess.ID); err != nil {
+		_ = sm.cache.Delete(ctx, fmt.Sprintf("session:%s", sess.ID))
+		return nil, fmt.Errorf("failed to add session to user set: %w", err)
+	}
+	_, _ = sm.cache.Expire(ctx, userSessionsKey, ttl+time.Hour*24)
+
+	return sess, nil
+}
+
+func (sm *sessionManager) save(ctx context.Context, sess *Session) error {
+	ttl := time.Until(sess.ExpiresAt)
+	if ttl <= 0 {
+		return ErrSessionNotFound
+	}
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := sm.cache.Set(ctx, fmt.Sprintf("session:%s", sess.ID), string(data), ttl); err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+func (sm *sessionManager) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	data, err := sm.cache.Get(ctx, fmt.Sprintf("session:%s", sessionID))
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	var sess Session
+	if err := json.Unmarshal([]byte(data), &sess); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return &sess, nil
+}
+
+// ListSessions returns the live sessions of a user, oldest first.
+func (sm *sessionManager) ListSessions(ctx context.Context, userID string) ([]*Session, error) {
+	userSessionsKey := fmt.Sprintf("user_sessions:%s", userID)
+	ids, err := sm.cache.ZRange(ctx, userSessionsKey, 0, -1)
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		sess, err := sm.GetSession(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			_ = sm.cache.ZRem(ctx, userSessionsKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// Touch records activity on the session.
+func (sm *sessionManager) Touch(ctx context.Context, sessionID string) error {
+	sess, err := sm.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	sess.LastSeen = time.Now().UTC()
+	return sm.save(ctx, sess)
+}
+
+func (sm *sessionManager) RevokeSession(ctx context.Context, sessionID string) error {
+	sess, err := sm.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if err := sm.cache.Delete(ctx, fmt.Sprintf("session:%s", sessionID)); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if err := sm.cache.ZRem(ctx, fmt.Sprintf("user_sessions:%s", sess.UserID), sessionID); err != nil {
+		logs.Warn(ctx, "failed to remove session from user set", "error", err)
+	}
+	return nil
+}
+
+func (sm *sessionManager) RevokeAllSessions(ctx context.Context, userID string) error {
+	sessions, err := sm.ListSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, sess := range sessions {
+		if err := sm.RevokeSession(ctx, sess.ID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SessionMiddleware rejects tokens whose session has been revoked or has
+// expired, and records the session activity. It must run after one of the
+// auth middlewares; tokens without a sid claim are let through.
+func SessionMiddleware(sm SessionManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := claimsFromContext(c)
+		sid, _ := GetStringClaim(claims, ClaimSessionID)
+		if sid == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		sess, err := sm.GetSession(ctx, sid)
+		if errors.Is(err, ErrSessionNotFound) || (err == nil && sess.UserID != c.GetString(KeyUserID)) {
+			logs.Info(ctx, "[SessionMiddleware] session revoked or expired", "session_id", sid)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked or expired"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logs.Warn(ctx, "[SessionMiddleware] error checking session", "error", err)
+			// Continue execution even if cache check fails (graceful degradation)
+		} else if time.Since(sess.LastSeen) >= lastSeenResolution {
+			if err := sm.Touch(ctx, sid); err != nil {
+				logs.Warn(ctx, "[SessionMiddleware] failed to update last seen", "error", err)
+			}
+		}
+
+		c.Set(KeySessionID, sid)
+		c.Next()
+	}
+}
+
+type sessionView struct {
+	*Session
+	Current bool `json:"current"`
+}
+
+// RegisterSessionRoutes adds the endpoints behind an "active devices" page
+// for the authenticated user, to a group protected by the auth and session
+// middlewares:
+//
+//	GET    /sessions      lists the sessions, flagging the current one
+//	DELETE /sessions/:id  revokes a session
+//	DELETE /sessions      revokes every session but the current one
+func RegisterSessionRoutes(r gin.IRoutes, sm SessionManager) {
+	r.GET("/sessions", func(c *gin.Context) {
+		sessions, err := sm.ListSessions(c.Request.Context(), c.GetString(KeyUserID))
+		if err != nil {
+			logs.Error(c.Request.Context(), "[Sessions] failed to list sessions", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+			return
+		}
+		current := c.GetString(KeySessionID)
+		views := make([]sessionView, len(sessions))
+		for i, sess := range sessions {
+			views[i] = sessionView{Session: sess, Current: sess.ID == current}
+		}
+		c.JSON(http.StatusOK, views)
+	})
+
+	r.DELETE("/sessions/:id", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		sess, err := sm.GetSession(ctx, c.Param("id"))
+		if errors.Is(err, ErrSessionNotFound) || (err == nil && sess.UserID != c.GetString(KeyUserID)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		if err == nil {
+			err = sm.RevokeSession(ctx, sess.ID)
+		}
+		if err != nil {
+			logs.Error(ctx, "[Sessions] failed to revoke session", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	r.DELETE("/sessions", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if err := revokeOtherSessions(ctx, sm, c.GetString(KeyUserID), c.GetString(KeySessionID)); err != nil {
+			logs.Error(ctx, "[Sessions] failed to revoke sessions", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+func revokeOtherSessions(ctx context.Context, sm SessionManager, userID, current string) error {
+	sessions, err := sm.ListSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, sess := range sessions {
+		if sess.ID == current {
+			continue
+		}
+		if err := sm.RevokeSession(ctx, sess.ID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
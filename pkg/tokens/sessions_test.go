@@ -0,0 +1,120 @@
+package tokens
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionManager(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	sm := NewSessionManager(c)
+	ctx := context.Background()
+
+	laptop, err := sm.CreateSession(ctx, "user123", SessionMetadata{Device: "laptop", IP: "10.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	phone, err := sm.CreateSession(ctx, "user123", SessionMetadata{Device: "phone"}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	sessions, err := sm.ListSessions(ctx, "user123")
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Device != "laptop" || sessions[0].IP != "10.0.0.1" {
+		t.Fatalf("unexpected sessions %+v", sessions)
+	}
+
+	if err := sm.RevokeSession(ctx, laptop.ID); err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if _, err := sm.GetSession(ctx, laptop.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+	sessions, _ = sm.ListSessions(ctx, "user123")
+	if len(sessions) != 1 || sessions[0].ID != phone.ID {
+		t.Errorf("expected only the phone session, got %+v", sessions)
+	}
+
+	if err := sm.RevokeAllSessions(ctx, "user123"); err != nil {
+		t.Fatalf("RevokeAllSessions failed: %v", err)
+	}
+	if sessions, _ = sm.ListSessions(ctx, "user123"); len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %d", len(sessions))
+	}
+}
+
+func TestSessionRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	sm := NewSessionManager(c)
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	current, _ := sm.CreateSession(ctx, "user123", SessionMetadata{Device: "laptop"}, time.Hour)
+	other, _ := sm.CreateSession(ctx, "user123", SessionMetadata{Device: "phone"}, time.Hour)
+	stranger, _ := sm.CreateSession(ctx, "user456", SessionMetadata{Device: "tablet"}, time.Hour)
+
+	token, _, err := svc.GenerateToken("user123", "", map[string]any{ClaimSessionID: current.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherToken, _, _ := svc.GenerateToken("user123", "", map[string]any{ClaimSessionID: other.ID})
+
+	r := gin.New()
+	r.Use(AuthMiddleware(svc), SessionMiddleware(sm))
+	RegisterSessionRoutes(r, sm)
+
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call(http.MethodGet, "/sessions", token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var views []struct {
+		ID      string `json:"id"`
+		Device  string `json:"device"`
+		Current bool   `json:"current"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &views); err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 2 || !views[0].Current || views[1].Current || views[1].Device != "phone" {
+		t.Errorf("unexpected sessions %+v", views)
+	}
+
+	if w := call(http.MethodDelete, "/sessions/"+stranger.ID, token); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another user's session, got %d", w.Code)
+	}
+	if w := call(http.MethodDelete, "/sessions/"+other.ID, token); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if w := call(http.MethodGet, "/sessions", otherToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked session, got %d", w.Code)
+	}
+
+	if w := call(http.MethodDelete, "/sessions", token); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if w := call(http.MethodGet, "/sessions", token); w.Code != http.StatusOK {
+		t.Errorf("expected the current session to survive, got %d", w.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// RefreshedTokenHeader carries the renewed access token issued by
+	// WithSlidingExpiration. Browser clients need it listed in
+	// Access-Control-Expose-Headers.
+	RefreshedTokenHeader = "X-Refreshed-Token"
+
+	// ClaimAuthTime records when the user signed in, so renewed tokens keep
+	// counting MaxLifetime from the first one.
+	ClaimAuthTime = "auth_time"
+)
+
+// SlidingExpiration configures activity-based renewal of access tokens.
+type SlidingExpiration struct {
+	// IdleTimeout is how long a token stays in the cache without requests;
+	// each authenticated request resets its cache expiry to IdleTimeout from
+	// now. Only used by CachedAuthMiddleware, with an ExtendingCacheManager.
+	IdleTimeout time.Duration
+	// RenewBefore makes the middleware issue a new access token, in the
+	// X-Refreshed-Token response header, when the current one expires
+	// within this window.
+	RenewBefore time.Duration
+	// MaxLifetime is the absolute limit counted from the sign in: the cache
+	// expiry is never extended, nor tokens renewed, past it. Zero means no
+	// limit: with RenewBefore set, a session that stays active is renewed
+	// forever.
+	MaxLifetime time.Duration
+}
+
+// WithSlidingExpiration keeps active users signed in: idle sessions expire
+// after IdleTimeout while active ones are extended, and renewed, up to
+// MaxLifetime.
+//
+//	r.Use(tokens.CachedAuthMiddleware(svc, cm, tokens.WithSlidingExpiration(tokens.SlidingExpiration{
+//		IdleTimeout: 30 * time.Minute,
+//		RenewBefore: 5 * time.Minute,
+//		MaxLifetime: 12 * time.Hour,
+//	})))
+func WithSlidingExpiration(cfg SlidingExpiration) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.sliding = &cfg
+	}
+}
+
+// slideExpiration extends the cache entry of the request token and renews
+// it when close to expiry, returning the renewed token and its expiry.
+// Failures are logged and the request goes on with the current token.
+func slideExpiration(ctx context.Context, header http.Header, svc Service, cacheMgr CacheManager, cfg *middlewareConfig, result *tokenValidationResult) (string, time.Time) {
+	sliding := cfg.sliding
+	if sliding == nil {
+		return "", time.Time{}
+	}
+	now := time.Now()
+
+	exp, err := result.claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return "", time.Time{}
+	}
+	deadline := exp.Time
+	var limit time.Time
+	if sliding.MaxLifetime > 0 {
+		limit = authTime(result.claims).Add(sliding.MaxLifetime)
+		deadline = minTime(deadline, limit)
+	}
+
+	if extender, ok := cacheMgr.(ExtendingCacheManager); ok && sliding.IdleTimeout > 0 {
+		if err := extender.ExtendToken(ctx, result.tokenString, minTime(now.Add(sliding.IdleTimeout), deadline)); err != nil {
+			logs.Warn(ctx, "[SlidingExpiration] failed to extend token", "error", err)
+		}
+	}
+
+	if sliding.RenewBefore <= 0 || exp.Sub(now) > sliding.RenewBefore {
+		return "", time.Time{}
+	}
+	userID, _ := GetStringClaim(result.claims, "sub")
+	email, _ := GetStringClaim(result.claims, "email")
+	claims := customClaims(result.claims)
+	claims[ClaimAuthTime] = authTime(result.claims).Unix()
+
+	token, tokenExp, err := svc.GenerateToken(userID, email, claims)
+	if err != nil {
+		logs.Warn(ctx, "[SlidingExpiration] failed to renew token", "error", err)
+		return "", time.Time{}
+	}
+	if !limit.IsZero() && tokenExp.After(limit) {
+		// The renewed token would outlive MaxLifetime; let the session end.
+		return "", time.Time{}
+	}
+	if cacheMgr != nil {
+		cacheExp := tokenExp
+		if sliding.IdleTimeout > 0 {
+			cacheExp = minTime(now.Add(sliding.IdleTimeout), tokenExp)
+		}
+		// The renewal belongs to the session of the token it replaces, so
+		// MaxActiveTokens does not count it as a new sign in.
+		if err := cacheToken(ctx, cacheMgr, token, userID, sessionOf(result.claims), result.claims, cacheExp); err != nil {
+			logs.Warn(ctx, "[SlidingExpiration] failed to cache renewed token", "error", err)
+			return "", time.Time{}
+		}
+	}
+	header.Set(RefreshedTokenHeader, token)
+	return token, tokenExp
+}
+
+// authTime returns the auth_time claim, or iat for tokens never renewed.
+func authTime(claims jwt.MapClaims) time.Time {
+	if v, ok := claims[ClaimAuthTime].(float64); ok {
+		return time.Unix(int64(v), 0)
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		return iat.Time
+	}
+	return time.Now()
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
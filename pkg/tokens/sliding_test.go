@@ -0,0 +1,149 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSlidingExpirationExtendsCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	token, exp, _ := svc.GenerateToken("user123", "", nil)
+	_ = cm.AddToken(ctx, token, "user123", exp)
+
+	r := gin.New()
+	r.Use(CachedAuthMiddleware(svc, cm, WithSlidingExpiration(SlidingExpiration{IdleTimeout: time.Minute})))
+	r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Header().Get(RefreshedTokenHeader) != "" {
+		t.Error("expected no renewal without RenewBefore")
+	}
+
+	ttl, err := c.TTL(ctx, "token:"+token)
+	if err != nil || ttl > time.Minute || ttl < 50*time.Second {
+		t.Errorf("expected cache TTL reset to the idle timeout, got %v (%v)", ttl, err)
+	}
+}
+
+func TestSlidingExpirationRenewal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc := newTestService(t)
+
+	token, exp, _ := svc.GenerateToken("user123", "user@example.com", map[string]any{ClaimRoles: []string{"admin"}})
+	_ = cm.AddToken(context.Background(), token, "user123", exp)
+	original, _ := svc.ValidateTokenAndGetClaims(token)
+
+	call := func(sliding SlidingExpiration) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(CachedAuthMiddleware(svc, cm, WithSlidingExpiration(sliding)))
+		r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The test service issues 1h tokens, so a 2h window always renews.
+	w := call(SlidingExpiration{RenewBefore: 2 * time.Hour})
+	renewed := w.Header().Get(RefreshedTokenHeader)
+	if renewed == "" {
+		t.Fatal("expected a renewed token")
+	}
+	claims, err := svc.ValidateTokenAndGetClaims(renewed)
+	if err != nil {
+		t.Fatalf("renewed token invalid: %v", err)
+	}
+	if email, _ := GetStringClaim(claims, "email"); email != "user@example.com" {
+		t.Errorf("expected email to carry over, got %q", email)
+	}
+	if roles := claimValues(claims, ClaimRoles); len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("expected roles to carry over, got %v", roles)
+	}
+	if !authTime(claims).Equal(authTime(original)) {
+		t.Errorf("expected auth_time %v, got %v", authTime(original), authTime(claims))
+	}
+	if exists, _ := cm.TokenExists(context.Background(), renewed); !exists {
+		t.Error("expected the renewed token to be cached")
+	}
+
+	w = call(SlidingExpiration{RenewBefore: 2 * time.Hour, MaxLifetime: 30 * time.Minute})
+	if w.Code != http.StatusOK || w.Header().Get(RefreshedTokenHeader) != "" {
+		t.Errorf("expected no renewal past MaxLifetime, got %d %q", w.Code, w.Header().Get(RefreshedTokenHeader))
+	}
+}
+
+func TestSlidingExpirationKeepsSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	cm := NewCacheManager(c)
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:       "test-secret-key-minimum-length",
+			Issuer:          "test-issuer",
+			AccessTokenExp:  time.Hour,
+			MaxActiveTokens: 2,
+		},
+		RefreshTokenExp: 24 * time.Hour,
+	}, WithCache(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	login := func(sid string) []string {
+		access, refresh, refreshExp, err := svc.GenerateTokens("user1", "", map[string]any{ClaimSessionID: sid})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = svc.AddTokenToCache(ctx, access, "user1", time.Now().Add(time.Hour))
+		_ = svc.AddTokenToCache(ctx, refresh, "user1", refreshExp)
+		return []string{access, refresh}
+	}
+
+	r := gin.New()
+	r.Use(CachedAuthMiddleware(svc, cm, WithSlidingExpiration(SlidingExpiration{RenewBefore: 2 * time.Hour})))
+	r.GET("/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	laptop := login("laptop")
+	token := laptop[0]
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if token = w.Header().Get(RefreshedTokenHeader); token == "" {
+			t.Fatalf("expected a renewed token, got %d", w.Code)
+		}
+		laptop = append(laptop, token)
+	}
+	phone := login("phone")
+
+	for _, tok := range append(laptop, phone...) {
+		if exists, _ := cm.TokenExists(ctx, tok); !exists {
+			t.Error("expected renewals to count as the session they renew")
+		}
+	}
+}
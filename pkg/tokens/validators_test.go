@@ -0,0 +1,108 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var errSuspended = errors.New("account suspended")
+
+func TestWithClaimValidator(t *testing.T) {
+	suspended := map[string]bool{"user456": true}
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:      "test-secret-key-minimum-length",
+			Issuer:         "test-issuer",
+			AccessTokenExp: time.Hour,
+		},
+	}, WithClaimValidator(func(claims jwt.MapClaims) error {
+		if sub, _ := claims.GetSubject(); suspended[sub] {
+			return errSuspended
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active, _, _ := svc.GenerateToken("user123", "", nil)
+	if _, err := svc.ValidateTokenAndGetClaims(active); err != nil {
+		t.Errorf("expected active user token to validate, got %v", err)
+	}
+
+	blocked, _, _ := svc.GenerateToken("user456", "", nil)
+	_, err = svc.ValidateTokenAndGetClaims(blocked)
+	if !errors.Is(err, ErrInvalidClaims) || !errors.Is(err, errSuspended) {
+		t.Errorf("expected ErrInvalidClaims wrapping the validator error, got %v", err)
+	}
+}
+
+func TestClaimValidatorSkippedWhenCaching(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	svc, err := NewService(&ShortLivedTokenConfig{
+		TokenConfig: TokenConfig{
+			SecretKey:       "test-secret-key-minimum-length",
+			Issuer:          "test-issuer",
+			AccessTokenExp:  time.Hour,
+			MaxActiveTokens: 1,
+		},
+		RefreshTokenExp: 24 * time.Hour,
+	}, WithCache(NewCacheManager(c)), WithClaimValidator(func(jwt.MapClaims) error {
+		return errSuspended
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// The pair is one session even though the validator rejects both.
+	access, refresh, refreshExp, err := svc.GenerateTokens("user123", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddTokenToCache(ctx, access, "user123", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddTokenToCache(ctx, refresh, "user123", refreshExp); err != nil {
+		t.Fatal(err)
+	}
+	for _, tok := range []string{access, refresh} {
+		if exists, _ := svc.TokenExistsInCache(ctx, tok); !exists {
+			t.Error("expected both tokens of the session to stay cached")
+		}
+	}
+}
+
+func TestRequireClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := newTestService(t)
+
+	r := gin.New()
+	r.Use(AuthMiddleware(svc, RequireClaims(func(claims jwt.MapClaims) error {
+		if plan, _ := GetStringClaim(claims, "plan"); plan != "pro" {
+			return errors.New("plan does not include reports")
+		}
+		return nil
+	})))
+	r.GET("/reports", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for plan, want := range map[string]int{"pro": http.StatusOK, "free": http.StatusForbidden} {
+		token, _, _ := svc.GenerateToken("user123", "", map[string]any{"plan": plan})
+		req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("plan %s: expected %d, got %d", plan, want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package web
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultAccessLogSkipPaths keeps probes and scrapes out of the access log
+// and the request metrics.
+var defaultAccessLogSkipPaths = []string{"/health", "/health/live", "/health/ready", "/metrics"}
+
+// AccessLogConfig controls the volume of the access log. Server errors are
+// always logged; successful and client error responses can be sampled.
+// Zero sample rates mean every request is logged.
+type AccessLogConfig struct {
+	// SkipPaths are never logged; nil uses the health and metrics paths.
+	SkipPaths []string
+	// SuccessSampleRate is the fraction of 1xx-3xx responses logged, e.g.
+	// 0.01 for one in a hundred.
+	SuccessSampleRate float64
+	// ClientErrorSampleRate is the fraction of 4xx responses logged.
+	ClientErrorSampleRate float64
+	// SlowThreshold logs slower requests regardless of sampling.
+	SlowThreshold time.Duration
+}
+
+// DefaultAccessLogConfig reads ACCESS_LOG_SUCCESS_SAMPLE_RATE,
+// ACCESS_LOG_CLIENT_ERROR_SAMPLE_RATE and ACCESS_LOG_SLOW_THRESHOLD (a
+// duration such as "500ms").
+func DefaultAccessLogConfig() AccessLogConfig {
+	cfg := AccessLogConfig{SkipPaths: defaultAccessLogSkipPaths}
+	if v, err := strconv.ParseFloat(os.Getenv("ACCESS_LOG_SUCCESS_SAMPLE_RATE"), 64); err == nil {
+		cfg.SuccessSampleRate = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ACCESS_LOG_CLIENT_ERROR_SAMPLE_RATE"), 64); err == nil {
+		cfg.ClientErrorSampleRate = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("ACCESS_LOG_SLOW_THRESHOLD")); err == nil {
+		cfg.SlowThreshold = v
+	}
+	return cfg
+}
+
+// AccessLogMiddleware logs one structured line per request through pkg/logs:
+// info for 2xx/3xx, warn for 4xx and error for 5xx. Requests to skipPaths
+// are not logged. Register it after RequestIDMiddleware and the tracing
+// middleware so the request and trace IDs are available.
+func AccessLogMiddleware(skipPaths ...string) gin.HandlerFunc {
+	return AccessLogMiddlewareWithConfig(AccessLogConfig{SkipPaths: skipPaths})
+}
+
+// AccessLogMiddlewareWithConfig is AccessLogMiddleware with sampling, for
+// high-traffic services.
+func AccessLogMiddlewareWithConfig(cfg AccessLogConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(cfg.SkipPaths, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		if !cfg.sampled(status, latency, rand.Float64()) {
+			return
+		}
+		ctx := c.Request.Context()
+		fields := accessLogFields(c, latency)
+		switch {
+		case status >= http.StatusInternalServerError:
+			logs.Error(ctx, "[AccessLog] request completed", fields...)
+		case status >= http.StatusBadRequest:
+			logs.Warn(ctx, "[AccessLog] request completed", fields...)
+		default:
+			logs.Info(ctx, "[AccessLog] request completed", fields...)
+		}
+	}
+}
+
+// sampled decides whether a request is logged, given a random number in
+// [0, 1).
+func (cfg AccessLogConfig) sampled(status int, latency time.Duration, r float64) bool {
+	if status >= http.StatusInternalServerError || (cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold) {
+		return true
+	}
+	rate := cfg.SuccessSampleRate
+	if status >= http.StatusBadRequest {
+		rate = cfg.ClientErrorSampleRate
+	}
+	return rate <= 0 || r < rate
+}
+
+func accessLogFields(c *gin.Context, latency time.Duration) []any {
+	route := c.FullPath()
+	if route == "" {
+		route = "unknown"
+	}
+	fields := []any{
+		"method", c.Request.Method,
+		"route", route,
+		"status", c.Writer.Status(),
+		"latency_ms", latency.Milliseconds(),
+		"client_ip", GetIPFromContext(c),
+		"request_id", c.GetString("request_id"),
+	}
+	if traceID := traceIDFromContext(c.Request.Context()); traceID != "" {
+		fields = append(fields, "trace_id", traceID)
+	}
+	return fields
+}
+
+func traceIDFromContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAccessLogFields(t *testing.T) {
+	var fields []any
+	e := gin.New()
+	e.Use(RequestIDMiddleware(), func(c *gin.Context) {
+		c.Next()
+		fields = accessLogFields(c, 42*time.Millisecond)
+	}, AccessLogMiddleware())
+	e.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req = req.WithContext(trace.ContextWithSpanContext(req.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})))
+	req.Header.Set("X-Request-ID", "req-1")
+	req.RemoteAddr = "10.0.0.1:1234"
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := map[string]any{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		got[fields[i].(string)] = fields[i+1]
+	}
+	want := map[string]any{
+		"method":     http.MethodGet,
+		"route":      "/users/:id",
+		"status":     http.StatusNotFound,
+		"latency_ms": int64(42),
+		"client_ip":  "10.0.0.1",
+		"request_id": "req-1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %s: expected %v, got %v", k, v, got[k])
+		}
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	cfg := AccessLogConfig{SuccessSampleRate: 0.01, ClientErrorSampleRate: 0.5, SlowThreshold: time.Second}
+	tests := []struct {
+		name    string
+		status  int
+		latency time.Duration
+		r       float64
+		want    bool
+	}{
+		{"sampled success", http.StatusOK, time.Millisecond, 0.005, true},
+		{"dropped success", http.StatusOK, time.Millisecond, 0.5, false},
+		{"sampled client error", http.StatusNotFound, time.Millisecond, 0.4, true},
+		{"dropped client error", http.StatusNotFound, time.Millisecond, 0.6, false},
+		{"server error", http.StatusInternalServerError, time.Millisecond, 0.99, true},
+		{"slow request", http.StatusOK, 2 * time.Second, 0.99, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.sampled(tt.status, tt.latency, tt.r); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if !(AccessLogConfig{}).sampled(http.StatusOK, 0, 0.99) {
+		t.Error("expected every request to be logged without sample rates")
+	}
+}
+
+func TestMetricsSkipPaths(t *testing.T) {
+	e := gin.New()
+	e.Use(httpServerMetricsMiddleware([]string{"/health"}))
+	e.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	e.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/health", "/orders"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if n := testutil.ToFloat64(httpServerRequestsTotal.WithLabelValues(http.MethodGet, "/health", "200")); n != 0 {
+		t.Errorf("expected /health to be skipped, got %v requests", n)
+	}
+	if n := testutil.ToFloat64(httpServerRequestsTotal.WithLabelValues(http.MethodGet, "/orders", "200")); n != 1 {
+		t.Errorf("expected 1 /orders request, got %v", n)
+	}
+}
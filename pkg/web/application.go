@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -21,32 +24,87 @@ import (
 )
 
 type GinApp struct {
-	engine     *gin.Engine
-	httpServer *http.Server
-	logger     *logs.Logger
-	tracer     *sdktrace.TracerProvider
-	meter      *sdkmetric.MeterProvider
-	ginConfig  GinConfig
+	engine         *gin.Engine
+	httpServer     *http.Server
+	redirectServer *http.Server
+	opsEngine      *gin.Engine
+	opsServer      *http.Server
+	listeners      []net.Listener
+	serveErr       chan error
+	logger         *logs.Logger
+	tracer         *sdktrace.TracerProvider
+	meter          *sdkmetric.MeterProvider
+	ginConfig      GinConfig
+
+	healthMu     sync.RWMutex
+	healthChecks []namedHealthCheck
+	startupDeps  []Dependency
+	starting     atomic.Bool
+
+	wsMu      sync.Mutex
+	wsConns   map[*WSConn]struct{}
+	wsClosing bool
+
+	shutdownMu    sync.Mutex
+	shutdownHooks []ShutdownHook
+
+	workersMu      sync.Mutex
+	workersWG      sync.WaitGroup
+	workersCtx     context.Context
+	workersCancel  context.CancelFunc
+	workersRunning map[string]int
+	workersStopped bool
+
+	versionsMu sync.RWMutex
+	versions   map[string]bool
 }
 
 type GinConfig struct {
-	Port                string
-	ReadTimeout         time.Duration
-	WriteTimeout        time.Duration
-	IdleTimeout         time.Duration
-	ShutdownTimeout     time.Duration
-	MaxHeaderBytes      int
-	EnablePprof         bool
-	EnableMetrics       bool
-	EnableRequestID     bool
-	EnableRecovery      bool
-	EnableCompression   bool
-	EnableCORS          bool
-	EnableTracing       bool
-	EnableGinPagination bool
-	EnableXAuthAppToken bool
-	OTELEndpoint        string
-	CORSOrigins         []string
+	Port                 string
+	ReadTimeout          time.Duration
+	WriteTimeout         time.Duration
+	IdleTimeout          time.Duration
+	ShutdownTimeout      time.Duration
+	StartupTimeout       time.Duration
+	MaxHeaderBytes       int
+	EnablePprof          bool
+	EnableMetrics        bool
+	EnableRequestID      bool
+	EnableRecovery       bool
+	EnableCompression    bool
+	EnableCORS           bool
+	EnableTracing        bool
+	EnableGinPagination  bool
+	EnableXAuthAppToken  bool
+	EnableAccessLog      bool
+	EnableProblemDetails bool
+	EnableH2C            bool
+	EnableDebugRoutes    bool
+	// WaitBeforeListen makes Start block until the dependencies registered
+	// with WaitForDependencies are ready, instead of only gating readiness.
+	WaitBeforeListen bool
+	OTELEndpoint     string
+	CORS             CORSConfig
+	TLS              *TLSConfig
+	ListenAddrs      []string
+	OpsPort          string
+	OpsAuth          OpsAuthConfig
+	// TrustedProxies are the CIDRs or addresses of the load balancers in
+	// front of the app. When set, TrustedRealIPMiddleware replaces
+	// RealIPMiddleware and X-Forwarded-For is only honored from them.
+	TrustedProxies []string
+	// SecurityHeaders replaces the fixed SecureHeadersMiddleware set.
+	SecurityHeaders *SecurityHeadersConfig
+	Versioning      VersioningConfig
+	AccessLog       AccessLogConfig
+	Recovery        RecoveryConfig
+	// FeatureFlags backs RequireFeature; nil reads AppConfig.Extras.
+	FeatureFlags FeatureFlags
+	// MetricsSkipPaths are left out of the request metrics; nil uses the
+	// health and metrics paths.
+	MetricsSkipPaths []string
+	// Deprecated: use CORS.AllowOrigins.
+	CORSOrigins []string
 }
 
 func DefaultGinConfig() *GinConfig {
@@ -54,6 +112,7 @@ func DefaultGinConfig() *GinConfig {
 	if otelEndpoint == "" {
 		otelEndpoint = "otel-collector:4317"
 	}
+	securityHeaders := DefaultSecurityHeadersConfig()
 
 	if env.IsRemote() {
 		return &GinConfig{
@@ -62,6 +121,7 @@ func DefaultGinConfig() *GinConfig {
 			WriteTimeout:        15 * time.Second,
 			IdleTimeout:         60 * time.Second,
 			ShutdownTimeout:     10 * time.Second,
+			StartupTimeout:      time.Minute,
 			MaxHeaderBytes:      1 << 20,
 			EnablePprof:         false,
 			EnableMetrics:       true,
@@ -72,7 +132,15 @@ func DefaultGinConfig() *GinConfig {
 			EnableTracing:       true,
 			EnableGinPagination: true,
 			EnableXAuthAppToken: true,
+			EnableDebugRoutes:   false,
 			OTELEndpoint:        otelEndpoint,
+			CORS:                DefaultCORSConfig(),
+			OpsPort:             os.Getenv("OPS_PORT"),
+			OpsAuth:             DefaultOpsAuthConfig(),
+			TrustedProxies:      splitEnv("TRUSTED_PROXIES"),
+			SecurityHeaders:     &securityHeaders,
+			AccessLog:           DefaultAccessLogConfig(),
+			Recovery:            DefaultRecoveryConfig(),
 		}
 	}
 
@@ -82,6 +150,7 @@ func DefaultGinConfig() *GinConfig {
 		WriteTimeout:        15 * time.Second,
 		IdleTimeout:         60 * time.Second,
 		ShutdownTimeout:     10 * time.Second,
+		StartupTimeout:      time.Minute,
 		MaxHeaderBytes:      1 << 20,
 		EnablePprof:         true,
 		EnableMetrics:       true,
@@ -92,7 +161,15 @@ func DefaultGinConfig() *GinConfig {
 		EnableTracing:       false,
 		EnableGinPagination: true,
 		EnableXAuthAppToken: true,
+		EnableDebugRoutes:   true,
 		OTELEndpoint:        otelEndpoint,
+		CORS:                DefaultCORSConfig(),
+		OpsPort:             os.Getenv("OPS_PORT"),
+		OpsAuth:             DefaultOpsAuthConfig(),
+		TrustedProxies:      splitEnv("TRUSTED_PROXIES"),
+		SecurityHeaders:     &securityHeaders,
+		AccessLog:           DefaultAccessLogConfig(),
+		Recovery:            DefaultRecoveryConfig(),
 	}
 }
 
@@ -124,30 +201,16 @@ func New(config *GinConfig) *GinApp {
 }
 
 func (app *GinApp) Run() error {
-
-	addr := fmt.Sprintf(":%s", app.ginConfig.Port)
-	app.httpServer = &http.Server{
-		Addr:           addr,
-		Handler:        app.engine,
-		ReadTimeout:    app.ginConfig.ReadTimeout,
-		WriteTimeout:   app.ginConfig.WriteTimeout,
-		IdleTimeout:    app.ginConfig.IdleTimeout,
-		MaxHeaderBytes: app.ginConfig.MaxHeaderBytes,
-	}
-
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	serverErr := make(chan error, 1)
-	go func() {
-		app.logger.Info(context.Background(), "Starting server", zap.String("address", addr))
-		if err := app.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			serverErr <- err
-		}
-	}()
+	if err := app.Start(); err != nil {
+		return err
+	}
+
 	cfg := config.Get()
 	select {
-	case err := <-serverErr:
+	case err := <-app.serveErr:
 		return err
 	case <-ctx.Done():
 		app.logger.Warn(
@@ -170,14 +233,125 @@ func (app *GinApp) Run() error {
 	}
 }
 
+// Start opens every listener and serves in the background, without
+// waiting for a signal as Run does. Stop the app with Shutdown.
+func (app *GinApp) Start() error {
+	if app.ginConfig.WaitBeforeListen {
+		if err := app.waitForStartup(); err != nil {
+			return err
+		}
+	}
+
+	var addr string
+	if app.ginConfig.Port != "" {
+		addr = fmt.Sprintf(":%s", app.ginConfig.Port)
+	}
+	app.httpServer = app.newHTTPServer(addr)
+
+	var redirectHandler http.Handler
+	if app.ginConfig.TLS.enabled() {
+		tlsCfg, redirect, err := app.ginConfig.TLS.serverTLS(app.ginConfig.Port)
+		if err != nil {
+			return fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		app.httpServer.TLSConfig = tlsCfg
+		redirectHandler = redirect
+	}
+
+	listeners, err := app.openListeners(addr)
+	if err != nil {
+		return err
+	}
+
+	// Serve may fill in TLSConfig for HTTP/2, so decide on TLS beforehand.
+	useTLS := app.httpServer.TLSConfig != nil
+	app.serveErr = make(chan error, len(listeners)+3)
+	if !app.ginConfig.WaitBeforeListen {
+		app.gateReadiness()
+	}
+	for _, ln := range listeners {
+		go func() {
+			app.logger.Info(context.Background(), "Starting server", zap.String("address", listenerAddr(ln)), zap.Bool("tls", useTLS))
+			var err error
+			if useTLS {
+				err = app.httpServer.ServeTLS(ln, "", "")
+			} else {
+				err = app.httpServer.Serve(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				app.serveErr <- err
+			}
+		}()
+	}
+	if app.opsEngine != nil {
+		app.opsServer = &http.Server{
+			Addr:        fmt.Sprintf(":%s", app.ginConfig.OpsPort),
+			Handler:     app.opsEngine,
+			ReadTimeout: app.ginConfig.ReadTimeout,
+			IdleTimeout: app.ginConfig.IdleTimeout,
+		}
+		go func() {
+			app.logger.Info(context.Background(), "Starting ops server", zap.String("address", app.opsServer.Addr))
+			if err := app.opsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				app.serveErr <- err
+			}
+		}()
+	}
+	if redirectHandler != nil && app.ginConfig.TLS.RedirectPort != "" {
+		app.redirectServer = &http.Server{
+			Addr:        fmt.Sprintf(":%s", app.ginConfig.TLS.RedirectPort),
+			Handler:     redirectHandler,
+			ReadTimeout: app.ginConfig.ReadTimeout,
+			IdleTimeout: app.ginConfig.IdleTimeout,
+		}
+		go func() {
+			app.logger.Info(context.Background(), "Starting HTTPS redirect server", zap.String("address", app.redirectServer.Addr))
+			if err := app.redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				app.serveErr <- err
+			}
+		}()
+	}
+	return nil
+}
+
+func (app *GinApp) newHTTPServer(addr string) *http.Server {
+	srv := &http.Server{
+		Addr:           addr,
+		Handler:        app.handler(),
+		ReadTimeout:    app.ginConfig.ReadTimeout,
+		WriteTimeout:   app.ginConfig.WriteTimeout,
+		IdleTimeout:    app.ginConfig.IdleTimeout,
+		MaxHeaderBytes: app.ginConfig.MaxHeaderBytes,
+	}
+	if app.ginConfig.EnableH2C {
+		// Prior-knowledge HTTP/2 over cleartext, as sent by load balancers
+		// and gRPC clients; the HTTP/1.1 Upgrade: h2c dance is not supported.
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return srv
+}
+
 func (app *GinApp) Shutdown(ctx context.Context) error {
+	app.closeWebSockets()
 	if err := app.ShutdownTelemetry(ctx); err != nil {
 		app.logger.Warn(context.Background(), "Telemetry shutdown error", zap.Error(err))
 	}
+	var errs []error
+	if app.redirectServer != nil {
+		errs = append(errs, app.redirectServer.Shutdown(ctx))
+	}
+	if app.opsServer != nil {
+		errs = append(errs, app.opsServer.Shutdown(ctx))
+	}
 	if app.httpServer != nil {
-		return app.httpServer.Shutdown(ctx)
+		errs = append(errs, app.httpServer.Shutdown(ctx))
 	}
-	return nil
+	errs = append(errs, app.stopWorkers(ctx))
+	errs = append(errs, app.runShutdownHooks(ctx))
+	return errors.Join(errs...)
 }
 
 func (app *GinApp) GetEngine() *gin.Engine {
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func TestH2C(t *testing.T) {
+	app := &GinApp{engine: gin.New(), ginConfig: GinConfig{EnableH2C: true}}
+	app.engine.GET("/proto", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := app.newHTTPServer(ln.Addr().String())
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	resp, err := client.Get("http://" + ln.Addr().String() + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", resp.Proto)
+	}
+
+	resp, err = http.Get("http://" + ln.Addr().String() + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 1 {
+		t.Errorf("expected HTTP/1.1 clients to keep working, got %s", resp.Proto)
+	}
+}
+
+func TestMultipleListeners(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	app := &GinApp{engine: gin.New(), logger: logs.GetLogger(), ginConfig: GinConfig{ListenAddrs: []string{"unix:" + sock}}}
+	app.engine.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.AddListener(ln)
+
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	for name, get := range map[string]func() (*http.Response, error){
+		"tcp":  func() (*http.Response, error) { return http.Get("http://" + ln.Addr().String() + "/ping") },
+		"unix": func() (*http.Response, error) { return unixClient.Get("http://app/ping") },
+	} {
+		resp, err := get()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "pong" {
+			t.Errorf("%s: unexpected body %q", name, body)
+		}
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get("http://" + ln.Addr().String() + "/ping"); err == nil {
+		t.Error("expected the added listener to be closed by Shutdown")
+	}
+}
+
+func TestOpsPort(t *testing.T) {
+	app := &GinApp{
+		engine:    gin.New(),
+		logger:    logs.GetLogger(),
+		ginConfig: GinConfig{OpsPort: "9090", EnableMetrics: true, EnablePprof: true},
+	}
+	app.setupRoutes()
+	app.setupMiddleware()
+
+	for _, path := range []string{"/metrics", "/health/ready", "/debug/pprof/"} {
+		w := httptest.NewRecorder()
+		app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 on the public port, got %d", path, w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		app.opsEngine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 on the ops port, got %d", path, w.Code)
+		}
+	}
+}
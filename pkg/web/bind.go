@@ -0,0 +1,126 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerFieldNamesOnce sync.Once
+
+// BindAndValidate binds the request into obj with the binding matching its
+// Content-Type and runs the `binding` tag validations. On failure it writes
+// a 400 problem+json listing every invalid field, named after its json or
+// form tag, and returns false:
+//
+//	var req CreateOrderRequest
+//	if !web.BindAndValidate(c, &req) {
+//		return
+//	}
+func BindAndValidate(c *gin.Context, obj any) bool {
+	registerFieldNamesOnce.Do(registerFieldNames)
+
+	err := c.ShouldBind(obj)
+	if err == nil {
+		return true
+	}
+
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		p := NewProblem(http.StatusBadRequest, "validation_failed", "the request has invalid fields")
+		for _, fe := range verrs {
+			p.Errors = append(p.Errors, FieldError{Field: fieldPath(fe), Message: validationMessage(fe)})
+		}
+		AbortWithProblemDetails(c, p)
+		return false
+	}
+	AbortWithProblem(c, http.StatusBadRequest, "invalid_request", err.Error())
+	return false
+}
+
+// registerFieldNames makes validation errors report the json or form name of
+// a field rather than its Go name.
+func registerFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri", "header"} {
+			name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+}
+
+// fieldPath drops the root struct from the namespace, so nested fields read
+// as "address.zip" and slice items as "items[0].sku".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, rest, ok := strings.Cut(ns, "."); ok {
+		return rest
+	}
+	return ns
+}
+
+func validationMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "min", "gte":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must contain at least %s items", param)
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", param)
+		}
+		return "must be greater than or equal to " + param
+	case "max", "lte":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must contain at most %s items", param)
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", param)
+		}
+		return "must be less than or equal to " + param
+	case "len":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be exactly %s characters long", param)
+		}
+		return fmt.Sprintf("must contain exactly %s items", param)
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	}
+	if param != "" {
+		return fmt.Sprintf("failed the %s=%s validation", fe.Tag(), param)
+	}
+	return fmt.Sprintf("failed the %s validation", fe.Tag())
+}
+
+func isSized(k reflect.Kind) bool {
+	return k == reflect.Slice || k == reflect.Array || k == reflect.Map
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindAddress struct {
+	Zip string `json:"zip" binding:"required,len=5"`
+}
+
+type bindRequest struct {
+	Email    string      `json:"email" binding:"required,email"`
+	Name     string      `json:"name" binding:"min=2"`
+	Quantity int         `json:"quantity" binding:"gte=1"`
+	Status   string      `json:"status" binding:"oneof=draft published"`
+	Address  bindAddress `json:"address"`
+}
+
+func bind(t *testing.T, body string) (*httptest.ResponseRecorder, *Problem) {
+	t.Helper()
+	e := gin.New()
+	e.POST("/orders", func(c *gin.Context) {
+		var req bindRequest
+		if !BindAndValidate(c, &req) {
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	e.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		return w, nil
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("invalid body %q: %v", w.Body.String(), err)
+	}
+	return w, &p
+}
+
+func TestBindAndValidate(t *testing.T) {
+	w, _ := bind(t, `{"email":"a@b.co","name":"Al","quantity":1,"status":"draft","address":{"zip":"12345"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, p := bind(t, `{"email":"nope","name":"A","quantity":0,"status":"gone","address":{}}`)
+	if w.Code != http.StatusBadRequest || p.Code != "validation_failed" {
+		t.Fatalf("expected a validation problem, got %d %+v", w.Code, p)
+	}
+	got := map[string]string{}
+	for _, fe := range p.Errors {
+		got[fe.Field] = fe.Message
+	}
+	want := map[string]string{
+		"email":       "must be a valid email address",
+		"name":        "must be at least 2 characters long",
+		"quantity":    "must be greater than or equal to 1",
+		"status":      "must be one of: draft, published",
+		"address.zip": "is required",
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Errorf("%s: expected %q, got %q", field, msg, got[field])
+		}
+	}
+}
+
+func TestBindAndValidate_MalformedBody(t *testing.T) {
+	w, p := bind(t, `{"email":`)
+	if w.Code != http.StatusBadRequest || p.Code != "invalid_request" || len(p.Errors) != 0 {
+		t.Errorf("expected invalid_request, got %d %+v", w.Code, p)
+	}
+}
@@ -0,0 +1,228 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETagConfig configures ETagMiddleware.
+type ETagConfig struct {
+	// Weak marks generated ETags as weak. Use it when responses are
+	// compressed, since the hash covers the uncompressed body and a strong
+	// ETag would then claim byte equality for differently encoded bodies.
+	Weak bool
+}
+
+// ETagMiddleware buffers successful GET and HEAD responses, tags them with
+// a hash of the body and answers a matching If-None-Match with 304 Not
+// Modified. An ETag set by the handler is kept, so handlers can tag
+// responses from a version column instead. Responses that flush early,
+// such as event streams, are passed through untagged.
+func ETagMiddleware(cfg ETagConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		bw := newBufferWriter(c.Writer)
+		c.Writer = bw
+		c.Next()
+		c.Writer = bw.ResponseWriter
+		if bw.passthrough {
+			return
+		}
+
+		if bw.status == http.StatusOK {
+			h := c.Writer.Header()
+			etag := h.Get("ETag")
+			if etag == "" {
+				etag = computeETag(bw.body.Bytes(), cfg.Weak)
+				h.Set("ETag", etag)
+			}
+			if etagMatches(c.GetHeader("If-None-Match"), etag) {
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				c.Writer.WriteHeader(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		bw.flush()
+	}
+}
+
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	return etag
+}
+
+// etagMatches applies the weak comparison RFC 9110 prescribes for
+// If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheControlPolicy is a Cache-Control header value. Zero durations are
+// left out.
+type CacheControlPolicy struct {
+	Public               bool
+	Private              bool
+	NoCache              bool
+	NoStore              bool
+	MaxAge               time.Duration
+	SMaxAge              time.Duration
+	StaleWhileRevalidate time.Duration
+	MustRevalidate       bool
+	Immutable            bool
+}
+
+func (p CacheControlPolicy) String() string {
+	var directives []string
+	flag := func(set bool, name string) {
+		if set {
+			directives = append(directives, name)
+		}
+	}
+	seconds := func(d time.Duration, name string) {
+		if d > 0 {
+			directives = append(directives, name+"="+strconv.FormatInt(int64(d/time.Second), 10))
+		}
+	}
+	flag(p.Public, "public")
+	flag(p.Private, "private")
+	flag(p.NoCache, "no-cache")
+	flag(p.NoStore, "no-store")
+	seconds(p.MaxAge, "max-age")
+	seconds(p.SMaxAge, "s-maxage")
+	seconds(p.StaleWhileRevalidate, "stale-while-revalidate")
+	flag(p.MustRevalidate, "must-revalidate")
+	flag(p.Immutable, "immutable")
+	return strings.Join(directives, ", ")
+}
+
+// CacheControlMiddleware sets the Cache-Control header of GET and HEAD
+// responses in a route group, replacing the no-store sent by the security
+// headers. Writes keep the default. Handlers can still override it.
+//
+//	catalog := r.Group("/catalog", web.CacheControlMiddleware(web.CacheControlPolicy{Public: true, MaxAge: 5 * time.Minute}))
+func CacheControlMiddleware(policy CacheControlPolicy) gin.HandlerFunc {
+	value := policy.String()
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Header("Cache-Control", value)
+		}
+		c.Next()
+	}
+}
+
+// bufferWriter holds the response back so middlewares can inspect it once
+// the handler returns. A Flush gives up and streams from then on.
+type bufferWriter struct {
+	gin.ResponseWriter
+
+	status      int
+	body        bytes.Buffer
+	written     bool
+	passthrough bool
+}
+
+func newBufferWriter(w gin.ResponseWriter) *bufferWriter {
+	return &bufferWriter{ResponseWriter: w, status: w.Status()}
+}
+
+func (bw *bufferWriter) WriteHeader(code int) {
+	if bw.passthrough {
+		bw.ResponseWriter.WriteHeader(code)
+	} else if code > 0 && !bw.written {
+		bw.status = code
+	}
+}
+
+func (bw *bufferWriter) WriteHeaderNow() {
+	if bw.passthrough {
+		bw.ResponseWriter.WriteHeaderNow()
+	} else {
+		bw.written = true
+	}
+}
+
+func (bw *bufferWriter) Write(b []byte) (int, error) {
+	if bw.passthrough {
+		return bw.ResponseWriter.Write(b)
+	}
+	bw.written = true
+	return bw.body.Write(b)
+}
+
+func (bw *bufferWriter) WriteString(s string) (int, error) {
+	if bw.passthrough {
+		return bw.ResponseWriter.WriteString(s)
+	}
+	bw.written = true
+	return bw.body.WriteString(s)
+}
+
+func (bw *bufferWriter) Status() int {
+	if bw.passthrough {
+		return bw.ResponseWriter.Status()
+	}
+	return bw.status
+}
+
+func (bw *bufferWriter) Size() int {
+	if bw.passthrough {
+		return bw.ResponseWriter.Size()
+	}
+	if !bw.written {
+		return -1
+	}
+	return bw.body.Len()
+}
+
+func (bw *bufferWriter) Written() bool {
+	if bw.passthrough {
+		return bw.ResponseWriter.Written()
+	}
+	return bw.written
+}
+
+func (bw *bufferWriter) Flush() {
+	bw.flush()
+	bw.ResponseWriter.Flush()
+}
+
+// flush sends what was buffered and switches to passthrough.
+func (bw *bufferWriter) flush() {
+	if bw.passthrough {
+		return
+	}
+	bw.passthrough = true
+	bw.ResponseWriter.WriteHeader(bw.status)
+	if bw.body.Len() > 0 {
+		_, _ = bw.ResponseWriter.Write(bw.body.Bytes())
+	} else if bw.written {
+		bw.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	e := gin.New()
+	e.Use(ETagMiddleware(ETagConfig{}))
+	e.GET("/items", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": 1})
+	})
+	e.GET("/versioned", func(c *gin.Context) {
+		c.Header("ETag", `"v7"`)
+		c.String(http.StatusOK, "body")
+	})
+	e.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	})
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/items", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":1}` || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("unexpected response %d %q etag %q", w.Code, w.Body.String(), etag)
+	}
+
+	w = get("/items", `"other", W/`+etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected an empty 304, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != etag {
+		t.Errorf("expected the ETag on the 304, got %q", w.Header().Get("ETag"))
+	}
+
+	if w := get("/versioned", `"v7"`); w.Code != http.StatusNotModified {
+		t.Errorf("expected the handler's ETag to be honored, got %d", w.Code)
+	}
+	if w := get("/missing", "*"); w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" {
+		t.Errorf("expected errors to pass untagged, got %d etag %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestETagMiddleware_Weak(t *testing.T) {
+	e := setupEngine(ETagMiddleware(ETagConfig{Weak: true}))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	if etag := w.Header().Get("ETag"); !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("expected a weak ETag, got %q", etag)
+	}
+}
+
+func TestCacheControlMiddleware(t *testing.T) {
+	policy := CacheControlPolicy{Public: true, MaxAge: 5 * time.Minute, StaleWhileRevalidate: time.Minute}
+	if got, want := policy.String(), "public, max-age=300, stale-while-revalidate=60"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	e := gin.New()
+	e.Use(SecureHeadersMiddleware())
+	g := e.Group("/catalog", CacheControlMiddleware(policy))
+	g.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	g.POST("", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for method, want := range map[string]string{
+		http.MethodGet:  "public, max-age=300, stale-while-revalidate=60",
+		http.MethodPost: "no-store",
+	} {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(method, "/catalog", nil))
+		if got := w.Header().Get("Cache-Control"); got != want {
+			t.Errorf("%s: expected %q, got %q", method, want, got)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package web
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/env"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig is the cross-origin policy applied when GinConfig.EnableCORS is
+// set. AllowOrigins entries are exact origins such as
+// "https://app.example.com", "*" for any origin, or a wildcard subdomain
+// such as "https://*.example.com", which matches any subdomain of
+// example.com over https but not example.com itself; no origin is allowed
+// when AllowOrigins is empty. Empty method and header lists fall back to
+// the gin-contrib/cors defaults.
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// DefaultCORSConfig reads the policy from the environment:
+//
+//	CORS_ALLOW_ORIGINS      comma-separated origins
+//	CORS_ALLOW_METHODS      comma-separated methods
+//	CORS_ALLOW_HEADERS      comma-separated request headers, added to the defaults
+//	CORS_EXPOSE_HEADERS     comma-separated response headers
+//	CORS_ALLOW_CREDENTIALS  true or false, defaults to true with explicit origins
+//	CORS_MAX_AGE            preflight cache, as a duration ("12h") or seconds
+//
+// Locally every origin is allowed when CORS_ALLOW_ORIGINS is unset; remote
+// environments get no origins, rejecting cross-origin requests, and must
+// configure them.
+func DefaultCORSConfig() CORSConfig {
+	cfg := CORSConfig{
+		AllowOrigins:  splitEnv("CORS_ALLOW_ORIGINS"),
+		AllowMethods:  splitEnv("CORS_ALLOW_METHODS"),
+		AllowHeaders:  splitEnv("CORS_ALLOW_HEADERS"),
+		ExposeHeaders: splitEnv("CORS_EXPOSE_HEADERS"),
+		MaxAge:        12 * time.Hour,
+	}
+	if len(cfg.AllowOrigins) == 0 && !env.IsRemote() {
+		cfg.AllowOrigins = []string{"*"}
+	}
+	cfg.AllowCredentials = !cfg.allowsAllOrigins()
+	if v, err := strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS")); err == nil {
+		cfg.AllowCredentials = v
+	}
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MaxAge = d
+		} else if secs, err := strconv.Atoi(v); err == nil {
+			cfg.MaxAge = time.Duration(secs) * time.Second
+		}
+	}
+	return cfg
+}
+
+// CORSMiddleware applies cfg. Credentials are never allowed together with
+// "*", which browsers reject.
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	return cors.New(cfg.corsConfig())
+}
+
+func (cfg CORSConfig) allowsAllOrigins() bool {
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg CORSConfig) corsConfig() cors.Config {
+	c := cors.DefaultConfig()
+	if len(cfg.AllowMethods) > 0 {
+		c.AllowMethods = cfg.AllowMethods
+	}
+	c.AllowHeaders = append(c.AllowHeaders, "Authorization", "X-Request-ID")
+	c.AllowHeaders = append(c.AllowHeaders, cfg.AllowHeaders...)
+	c.ExposeHeaders = cfg.ExposeHeaders
+	if cfg.MaxAge > 0 {
+		c.MaxAge = cfg.MaxAge
+	}
+
+	if cfg.allowsAllOrigins() {
+		c.AllowAllOrigins = true
+		return c
+	}
+	if len(cfg.AllowOrigins) == 0 {
+		// Fail closed: cross-origin requests are rejected.
+		c.AllowOriginFunc = func(string) bool { return false }
+		return c
+	}
+
+	var patterns []originPattern
+	for _, origin := range cfg.AllowOrigins {
+		if p, ok := parseOriginPattern(origin); ok {
+			patterns = append(patterns, p)
+		} else {
+			c.AllowOrigins = append(c.AllowOrigins, origin)
+		}
+	}
+	if len(patterns) > 0 {
+		c.AllowOriginFunc = func(origin string) bool {
+			for _, p := range patterns {
+				if p.matches(origin) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	c.AllowCredentials = cfg.AllowCredentials
+	return c
+}
+
+// originPattern is a "scheme://*.domain[:port]" origin.
+type originPattern struct {
+	scheme string
+	suffix string
+}
+
+func parseOriginPattern(origin string) (originPattern, bool) {
+	scheme, host, ok := strings.Cut(origin, "://*.")
+	if !ok || host == "" || strings.Contains(host, "*") {
+		return originPattern{}, false
+	}
+	return originPattern{scheme: scheme, suffix: "." + strings.ToLower(host)}, true
+}
+
+func (p originPattern) matches(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != p.scheme || u.Path != "" {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	return len(host) > len(p.suffix) && strings.HasSuffix(host, p.suffix)
+}
+
+func splitEnv(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	e := setupEngine(CORSMiddleware(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowHeaders:     []string{"X-Tenant-ID"},
+		ExposeHeaders:    []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://eu.api.example.org", true},
+		{"https://example.org", false},
+		{"http://eu.example.org", false},
+		{"https://evilexample.org", false},
+		{"https://other.com", false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Origin", tt.origin)
+		e.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin") == tt.origin; got != tt.allowed {
+			t.Errorf("%s: expected allowed=%v, got status %d headers %v", tt.origin, tt.allowed, w.Code, w.Header())
+		}
+		if tt.allowed && w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("%s: expected credentials to be allowed", tt.origin)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	e.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("expected max age 3600, got %q", w.Header().Get("Access-Control-Max-Age"))
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); !containsFold(got, "X-Tenant-ID") {
+		t.Errorf("expected X-Tenant-ID to be allowed, got %q", got)
+	}
+}
+
+func TestDefaultCORSConfig(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://a.example.com, https://*.example.net")
+	t.Setenv("CORS_MAX_AGE", "600")
+
+	cfg := DefaultCORSConfig()
+	if len(cfg.AllowOrigins) != 2 || cfg.AllowOrigins[1] != "https://*.example.net" {
+		t.Errorf("unexpected origins %v", cfg.AllowOrigins)
+	}
+	if !cfg.AllowCredentials {
+		t.Error("expected credentials with explicit origins")
+	}
+	if cfg.MaxAge != 10*time.Minute {
+		t.Errorf("expected 10m max age, got %s", cfg.MaxAge)
+	}
+
+	t.Setenv("CORS_ALLOW_ORIGINS", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	cfg = DefaultCORSConfig()
+	if !cfg.allowsAllOrigins() {
+		t.Errorf("expected all origins locally, got %v", cfg.AllowOrigins)
+	}
+	if c := cfg.corsConfig(); !c.AllowAllOrigins || c.AllowCredentials {
+		t.Error("expected credentials to be dropped with a wildcard origin")
+	}
+}
+
+// Remote environments without CORS_ALLOW_ORIGINS get a config with no
+// origins, which must reject cross-origin requests.
+func TestCORSMiddleware_NoOrigins(t *testing.T) {
+	e := setupEngine(CORSMiddleware(CORSConfig{AllowCredentials: true}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	e.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" || w.Code == http.StatusOK {
+		t.Errorf("expected the origin to be rejected, got status %d and allow-origin %q", w.Code, got)
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected same-origin requests to pass, got %d", w.Code)
+	}
+}
+
+func containsFold(list, item string) bool {
+	for _, v := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// CSRFContextKey is the gin context key holding the request's CSRF token.
+const CSRFContextKey = "csrf_token"
+
+// CSRFConfig configures CSRFMiddleware. The cookie and header names match
+// tokens.DefaultCookieConfig, so both can share the same token.
+type CSRFConfig struct {
+	CookieName string
+	HeaderName string
+	// FormField is read when the header is absent, for HTML form posts.
+	FormField string
+	Domain    string
+	Path      string
+	// Secure should only be disabled for local development over plain HTTP.
+	Secure   bool
+	SameSite http.SameSite
+	TTL      time.Duration
+	// Store, when set, switches to synchronizer tokens: tokens are issued
+	// and remembered by the server, so a cookie planted by a sibling
+	// subdomain is not accepted.
+	Store cache.Cache
+	// ExcludePaths are path prefixes that are not checked, e.g. webhooks.
+	ExcludePaths []string
+}
+
+// DefaultCSRFConfig returns double-submit settings with secure, SameSite=Lax
+// cookies valid for 12 hours.
+func DefaultCSRFConfig() CSRFConfig {
+	return CSRFConfig{
+		CookieName: "csrf_token",
+		HeaderName: "X-CSRF-Token",
+		FormField:  "csrf_token",
+		Path:       "/",
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+		TTL:        12 * time.Hour,
+	}
+}
+
+// CSRFMiddleware protects browser-facing routes authenticated with cookies.
+// Every response carries a CSRF token in a cookie readable from JavaScript;
+// requests with unsafe methods must echo it in HeaderName or FormField,
+// which a cross-site page cannot do. Requests sending an Authorization
+// header are token-authenticated API calls and are not checked, nor are
+// ExcludePaths. Failures are answered with a 403 problem+json.
+func CSRFMiddleware(cfg CSRFConfig) gin.HandlerFunc {
+	def := DefaultCSRFConfig()
+	if cfg.CookieName == "" {
+		cfg.CookieName = def.CookieName
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = def.HeaderName
+	}
+	if cfg.Path == "" {
+		cfg.Path = def.Path
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = def.TTL
+	}
+
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" || cfg.excluded(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		token, known := cfg.currentToken(c)
+		if !known {
+			token = cfg.issueToken(c)
+		}
+		c.Set(CSRFContextKey, token)
+
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		sent := c.GetHeader(cfg.HeaderName)
+		if sent == "" && cfg.FormField != "" {
+			sent = c.PostForm(cfg.FormField)
+		}
+		if !known || sent == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			logs.Warn(c.Request.Context(), "[CSRFMiddleware] invalid CSRF token", "path", c.FullPath())
+			AbortWithProblem(c, http.StatusForbidden, "csrf_failed", "missing or invalid CSRF token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// CSRFToken returns the token for the current request, to embed in forms.
+func CSRFToken(c *gin.Context) string {
+	return c.GetString(CSRFContextKey)
+}
+
+// currentToken returns the cookie token and whether it can be trusted.
+func (cfg CSRFConfig) currentToken(c *gin.Context) (string, bool) {
+	token, err := c.Cookie(cfg.CookieName)
+	if err != nil || token == "" {
+		return "", false
+	}
+	if cfg.Store == nil {
+		return token, true
+	}
+	exists, err := cfg.Store.Exists(c.Request.Context(), csrfStoreKey(token))
+	if err != nil {
+		logs.Warn(c.Request.Context(), "[CSRFMiddleware] error checking token", "error", err)
+		return "", false
+	}
+	return token, exists
+}
+
+func (cfg CSRFConfig) issueToken(c *gin.Context) string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if cfg.Store != nil {
+		if err := cfg.Store.Set(c.Request.Context(), csrfStoreKey(token), "1", cfg.TTL); err != nil {
+			logs.Warn(c.Request.Context(), "[CSRFMiddleware] failed to store token", "error", err)
+		}
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     cfg.CookieName,
+		Value:    token,
+		Domain:   cfg.Domain,
+		Path:     cfg.Path,
+		Expires:  time.Now().Add(cfg.TTL),
+		Secure:   cfg.Secure,
+		HttpOnly: false,
+		SameSite: cfg.SameSite,
+	})
+	return token
+}
+
+func (cfg CSRFConfig) excluded(path string) bool {
+	for _, prefix := range cfg.ExcludePaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func csrfStoreKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "csrf:" + hex.EncodeToString(sum[:])
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func newCSRFEngine(cfg CSRFConfig) *gin.Engine {
+	e := gin.New()
+	e.Use(CSRFMiddleware(cfg))
+	e.GET("/form", func(c *gin.Context) { c.String(http.StatusOK, CSRFToken(c)) })
+	e.POST("/form", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	e.POST("/webhooks/stripe", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return e
+}
+
+func csrfRequest(e *gin.Engine, method, path string, cookie, header string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: cookie})
+	}
+	if header != "" {
+		req.Header.Set("X-CSRF-Token", header)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func issuedToken(t *testing.T, e *gin.Engine) string {
+	t.Helper()
+	w := csrfRequest(e, http.MethodGet, "/form", "", "")
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "csrf_token" {
+			if cookie.HttpOnly || cookie.Value != w.Body.String() {
+				t.Fatalf("unexpected cookie %+v for body %q", cookie, w.Body.String())
+			}
+			return cookie.Value
+		}
+	}
+	t.Fatal("expected a CSRF cookie")
+	return ""
+}
+
+func TestCSRFMiddleware_DoubleSubmit(t *testing.T) {
+	cfg := DefaultCSRFConfig()
+	cfg.ExcludePaths = []string{"/webhooks/"}
+	e := newCSRFEngine(cfg)
+	token := issuedToken(t, e)
+
+	if w := csrfRequest(e, http.MethodPost, "/form", token, token); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if w := csrfRequest(e, http.MethodPost, "/form", token, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without header, got %d", w.Code)
+	}
+	if w := csrfRequest(e, http.MethodPost, "/form", token, "forged"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 with mismatched header, got %d", w.Code)
+	}
+	if w := csrfRequest(e, http.MethodPost, "/form", "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without cookie, got %d", w.Code)
+	}
+	if w := csrfRequest(e, http.MethodPost, "/webhooks/stripe", "", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected excluded path to pass, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected bearer requests to skip the check, got %d", w.Code)
+	}
+}
+
+func TestCSRFMiddleware_FormField(t *testing.T) {
+	e := newCSRFEngine(DefaultCSRFConfig())
+	token := issuedToken(t, e)
+
+	req := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader("csrf_token="+token))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+}
+
+func TestCSRFMiddleware_Synchronizer(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	cfg := DefaultCSRFConfig()
+	cfg.Store = store
+	e := newCSRFEngine(cfg)
+	token := issuedToken(t, e)
+
+	if w := csrfRequest(e, http.MethodPost, "/form", token, token); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+	if w := csrfRequest(e, http.MethodPost, "/form", "planted", "planted"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a token the server did not issue, got %d", w.Code)
+	}
+}
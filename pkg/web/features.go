@@ -0,0 +1,145 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/config"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// FeaturesContextKey is the gin context key holding the flags evaluated for
+// the request, as a map[string]bool.
+const FeaturesContextKey = "features"
+
+// DefaultFeaturesKey is the AppConfig.Extras entry read by
+// NewExtrasFeatureFlags.
+const DefaultFeaturesKey = "features"
+
+const featureFlagsKey = "feature_flags"
+
+// FeatureFlags reports whether a feature is enabled.
+type FeatureFlags interface {
+	Enabled(ctx context.Context, flag string) (bool, error)
+}
+
+// FeatureFlagsFunc adapts a function, e.g. a call to a remote flag service,
+// to FeatureFlags.
+type FeatureFlagsFunc func(ctx context.Context, flag string) (bool, error)
+
+func (f FeatureFlagsFunc) Enabled(ctx context.Context, flag string) (bool, error) {
+	return f(ctx, flag)
+}
+
+// StaticFeatureFlags is a fixed set of flags; missing flags are disabled.
+type StaticFeatureFlags map[string]bool
+
+func (f StaticFeatureFlags) Enabled(_ context.Context, flag string) (bool, error) {
+	return f[flag], nil
+}
+
+// NewExtrasFeatureFlags reads the flags from
+// AppConfig.Extras[DefaultFeaturesKey], a map of flag names to booleans or
+// boolean strings.
+func NewExtrasFeatureFlags(app *config.AppConfig) StaticFeatureFlags {
+	flags := StaticFeatureFlags{}
+	raw, _ := app.Extras[DefaultFeaturesKey].(map[string]any)
+	for name, v := range raw {
+		switch v := v.(type) {
+		case bool:
+			flags[name] = v
+		case string:
+			flags[name], _ = strconv.ParseBool(v)
+		}
+	}
+	return flags
+}
+
+type cacheFeatureFlags struct {
+	cache  cache.Cache
+	prefix string
+}
+
+// NewCacheFeatureFlags reads each flag from the cache key prefix+flag, so
+// flags can be toggled at runtime for every instance. Missing keys are
+// disabled.
+func NewCacheFeatureFlags(c cache.Cache, prefix string) FeatureFlags {
+	if prefix == "" {
+		prefix = "feature:"
+	}
+	return &cacheFeatureFlags{cache: c, prefix: prefix}
+}
+
+func (f *cacheFeatureFlags) Enabled(ctx context.Context, flag string) (bool, error) {
+	v, err := f.cache.Get(ctx, f.prefix+flag)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	enabled, _ := strconv.ParseBool(v)
+	return enabled, nil
+}
+
+// FeatureFlagsMiddleware makes flags the provider used by RequireFeature and
+// FeatureEnabled. Without it, the flags in config.Get().Extras are used.
+func FeatureFlagsMiddleware(flags FeatureFlags) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(featureFlagsKey, flags)
+		c.Next()
+	}
+}
+
+// RequireFeature responds 404 while flag is disabled, so the feature is
+// invisible to clients:
+//
+//	r.POST("/checkout", web.RequireFeature("new-checkout"), newCheckout)
+func RequireFeature(flag string) gin.HandlerFunc {
+	return RequireFeatureWithStatus(flag, http.StatusNotFound)
+}
+
+// RequireFeatureWithStatus is RequireFeature responding status, e.g. 403,
+// while flag is disabled.
+func RequireFeatureWithStatus(flag string, status int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !FeatureEnabled(c, flag) {
+			AbortWithProblem(c, status, "feature_disabled", "")
+			return
+		}
+		c.Next()
+	}
+}
+
+// FeatureEnabled evaluates flag once per request and records the result
+// under FeaturesContextKey. Provider errors are logged and the feature is
+// treated as disabled.
+func FeatureEnabled(c *gin.Context, flag string) bool {
+	v, _ := c.Get(FeaturesContextKey)
+	features, _ := v.(map[string]bool)
+	if enabled, ok := features[flag]; ok {
+		return enabled
+	}
+
+	v, _ = c.Get(featureFlagsKey)
+	flags, ok := v.(FeatureFlags)
+	if !ok {
+		flags = NewExtrasFeatureFlags(config.Get())
+	}
+	enabled, err := flags.Enabled(c.Request.Context(), flag)
+	if err != nil {
+		logs.Warn(c.Request.Context(), "[FeatureFlags] failed to evaluate flag", "flag", flag, "error", err)
+		enabled = false
+	}
+
+	if features == nil {
+		features = map[string]bool{}
+		c.Set(FeaturesContextKey, features)
+	}
+	features[flag] = enabled
+	return enabled
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireFeature(t *testing.T) {
+	calls := 0
+	flags := FeatureFlagsFunc(func(_ context.Context, flag string) (bool, error) {
+		calls++
+		switch flag {
+		case "new-checkout":
+			return true, nil
+		case "broken":
+			return false, errors.New("flag service unavailable")
+		}
+		return false, nil
+	})
+
+	e := gin.New()
+	e.Use(FeatureFlagsMiddleware(flags))
+	e.GET("/checkout", RequireFeature("new-checkout"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"cached": FeatureEnabled(c, "new-checkout")})
+	})
+	e.GET("/beta", RequireFeatureWithStatus("beta", http.StatusForbidden), func(c *gin.Context) {})
+	e.GET("/broken", RequireFeature("broken"), func(c *gin.Context) {})
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/checkout", http.StatusOK},
+		{"/beta", http.StatusForbidden},
+		{"/broken", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.path, tt.want, w.Code)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected one evaluation per request, got %d", calls)
+	}
+}
+
+func TestExtrasFeatureFlags(t *testing.T) {
+	flags := NewExtrasFeatureFlags(&config.AppConfig{Extras: map[string]any{
+		DefaultFeaturesKey: map[string]any{"a": true, "b": "true", "c": "nope"},
+	}})
+	if !flags["a"] || !flags["b"] || flags["c"] || flags["d"] {
+		t.Errorf("unexpected flags %v", flags)
+	}
+}
+
+func TestCacheFeatureFlags(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	ctx := context.Background()
+	flags := NewCacheFeatureFlags(c, "")
+
+	if enabled, err := flags.Enabled(ctx, "new-checkout"); err != nil || enabled {
+		t.Fatalf("expected a missing flag to be disabled, got %v %v", enabled, err)
+	}
+	_ = c.Set(ctx, "feature:new-checkout", "1", 0)
+	if enabled, _ := flags.Enabled(ctx, "new-checkout"); !enabled {
+		t.Error("expected the flag to be enabled")
+	}
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/client"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// healthCheckTimeout bounds each readiness check, so a hung dependency
+// fails the probe instead of stalling it.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck reports whether a dependency is usable; a nil error is healthy.
+type HealthCheck func(ctx context.Context) error
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// CheckResult is the outcome of one check in the /health/ready body.
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport is the /health/ready body.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// RegisterHealthCheck adds a check to /health/ready. Checks run concurrently
+// on every probe; registering a name twice replaces the previous check.
+//
+//	app.RegisterHealthCheck("db", web.DatabaseHealthCheck(db))
+//	app.RegisterHealthCheck("cache", web.CacheHealthCheck(c))
+func (app *GinApp) RegisterHealthCheck(name string, check HealthCheck) {
+	app.healthMu.Lock()
+	defer app.healthMu.Unlock()
+	for i, hc := range app.healthChecks {
+		if hc.name == name {
+			app.healthChecks[i].check = check
+			return
+		}
+	}
+	app.healthChecks = append(app.healthChecks, namedHealthCheck{name: name, check: check})
+}
+
+// liveHandler only tells the process is serving requests; dependencies are
+// left to readiness so an outage does not get the pod restarted.
+func liveHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (app *GinApp) readyHandler(c *gin.Context) {
+	if app.starting.Load() {
+		c.JSON(http.StatusServiceUnavailable, HealthReport{Status: "starting", Checks: map[string]CheckResult{}})
+		return
+	}
+
+	app.healthMu.RLock()
+	checks := append([]namedHealthCheck(nil), app.healthChecks...)
+	app.healthMu.RUnlock()
+
+	report := runHealthChecks(c.Request.Context(), checks)
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+func runHealthChecks(ctx context.Context, checks []namedHealthCheck) HealthReport {
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, hc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, hc.check)
+		}()
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: "ok", Checks: make(map[string]CheckResult, len(checks))}
+	for i, hc := range checks {
+		if results[i].Status != "ok" {
+			report.Status = "fail"
+		}
+		report.Checks[hc.name] = results[i]
+	}
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.LatencyMS = time.Since(start).Milliseconds()
+		if r := recover(); r != nil {
+			result.Status = "fail"
+			result.Error = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	if err := check(ctx); err != nil {
+		return CheckResult{Status: "fail", Error: err.Error()}
+	}
+	return CheckResult{Status: "ok"}
+}
+
+// DatabaseHealthCheck pings the connection pool behind db.
+func DatabaseHealthCheck(db *gorm.DB) HealthCheck {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// CacheHealthCheck does a read round trip against the cache; a missing key
+// is a healthy answer.
+func CacheHealthCheck(c cache.Cache) HealthCheck {
+	return func(ctx context.Context) error {
+		_, err := c.Get(ctx, "health:ping")
+		if err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			return err
+		}
+		return nil
+	}
+}
+
+// ClientHealthCheck calls path, usually the health endpoint of the
+// downstream service, through the client; error statuses fail the check.
+func ClientHealthCheck(c *client.Client, path string) HealthCheck {
+	return func(ctx context.Context) error {
+		resp, err := c.Get(ctx, path, nil)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+}
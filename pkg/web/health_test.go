@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/client"
+	"github.com/gin-gonic/gin"
+)
+
+func newHealthApp() *GinApp {
+	app := &GinApp{engine: gin.New()}
+	app.setupRoutes()
+	return app
+}
+
+func getReport(t *testing.T, app *GinApp) (int, HealthReport) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	var report HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid body %q: %v", w.Body.String(), err)
+	}
+	return w.Code, report
+}
+
+func TestHealthLive(t *testing.T) {
+	app := newHealthApp()
+	app.RegisterHealthCheck("down", func(context.Context) error { return errors.New("down") })
+
+	w := httptest.NewRecorder()
+	app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected liveness to ignore checks, got %d", w.Code)
+	}
+}
+
+func TestHealthReady(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	cl := client.NewClient(client.WithBaseURL(upstream.URL))
+	defer cl.Close()
+
+	app := newHealthApp()
+	app.RegisterHealthCheck("cache", CacheHealthCheck(c))
+	app.RegisterHealthCheck("upstream", ClientHealthCheck(cl, "/health"))
+
+	code, report := getReport(t, app)
+	if code != http.StatusOK || report.Status != "ok" || len(report.Checks) != 2 {
+		t.Fatalf("expected healthy report, got %d %+v", code, report)
+	}
+	if report.Checks["cache"].Status != "ok" || report.Checks["upstream"].Status != "ok" {
+		t.Errorf("unexpected checks %+v", report.Checks)
+	}
+
+	app.RegisterHealthCheck("broken", func(context.Context) error { return errors.New("connection refused") })
+	app.RegisterHealthCheck("panics", func(context.Context) error { panic("boom") })
+
+	code, report = getReport(t, app)
+	if code != http.StatusServiceUnavailable || report.Status != "fail" {
+		t.Fatalf("expected 503, got %d %+v", code, report)
+	}
+	if r := report.Checks["broken"]; r.Status != "fail" || r.Error != "connection refused" {
+		t.Errorf("unexpected broken result %+v", r)
+	}
+	if r := report.Checks["panics"]; r.Status != "fail" || r.Error == "" {
+		t.Errorf("unexpected panics result %+v", r)
+	}
+	if report.Checks["cache"].Status != "ok" {
+		t.Errorf("expected the other checks to stay healthy, got %+v", report.Checks)
+	}
+}
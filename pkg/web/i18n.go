@@ -0,0 +1,210 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleContextKey is the gin context key holding the resolved locale.
+const LocaleContextKey = "locale"
+
+// i18nKey holds the I18nConfig used by T.
+const i18nKey = "web_i18n"
+
+type localeKey struct{}
+
+// Catalog holds the translated messages of each locale.
+type Catalog struct {
+	messages map[string]map[string]string
+}
+
+// NewCatalog builds a catalog from locale → key → message maps.
+func NewCatalog(messages map[string]map[string]string) *Catalog {
+	c := &Catalog{messages: map[string]map[string]string{}}
+	for locale, msgs := range messages {
+		c.messages[normalizeLocale(locale)] = msgs
+	}
+	return c
+}
+
+// LoadCatalog reads one flat JSON object per locale from the files of fsys
+// matching pattern, named after their locale, e.g. locales/es-AR.json. fsys
+// is usually an embed.FS.
+func LoadCatalog(fsys fs.FS, pattern string) (*Catalog, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	messages := make(map[string]map[string]string, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var msgs map[string]string
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("web: invalid catalog %s: %w", name, err)
+		}
+		messages[strings.TrimSuffix(path.Base(name), path.Ext(name))] = msgs
+	}
+	return NewCatalog(messages), nil
+}
+
+// match returns the best supported locale for a requested one, falling back
+// from a regional variant to its language: es-AR is served by es.
+func (c *Catalog) match(requested string) (string, bool) {
+	requested = normalizeLocale(requested)
+	if _, ok := c.messages[requested]; ok {
+		return requested, true
+	}
+	base, _, _ := strings.Cut(requested, "-")
+	if _, ok := c.messages[base]; ok {
+		return base, true
+	}
+	return "", false
+}
+
+// lookup finds key in locale, then in its base language.
+func (c *Catalog) lookup(locale, key string) (string, bool) {
+	if msg, ok := c.messages[locale][key]; ok {
+		return msg, true
+	}
+	base, _, _ := strings.Cut(locale, "-")
+	msg, ok := c.messages[base][key]
+	return msg, ok
+}
+
+// I18nConfig configures I18nMiddleware.
+type I18nConfig struct {
+	Catalog *Catalog
+	// Default is used when nothing the client asks for is supported, and
+	// for messages missing from the resolved locale.
+	Default    string
+	QueryParam string
+	CookieName string
+}
+
+// DefaultI18nConfig reads the locale from ?lang= and the lang cookie
+// before Accept-Language, defaulting to English.
+func DefaultI18nConfig(catalog *Catalog) I18nConfig {
+	return I18nConfig{
+		Catalog:    catalog,
+		Default:    "en",
+		QueryParam: "lang",
+		CookieName: "lang",
+	}
+}
+
+// I18nMiddleware resolves the request locale from the query parameter, the
+// cookie and Accept-Language, in that order, keeping the first one the
+// catalog supports. The locale is stored under LocaleContextKey and in the
+// request context, where LocaleFromContext reads it, and announced with
+// Content-Language.
+func I18nMiddleware(cfg I18nConfig) gin.HandlerFunc {
+	cfg.Default = normalizeLocale(cfg.Default)
+	return func(c *gin.Context) {
+		var candidates []string
+		if cfg.QueryParam != "" {
+			candidates = append(candidates, c.Query(cfg.QueryParam))
+		}
+		if cfg.CookieName != "" {
+			if v, err := c.Cookie(cfg.CookieName); err == nil {
+				candidates = append(candidates, v)
+			}
+		}
+		candidates = append(candidates, parseAcceptLanguage(c.GetHeader("Accept-Language"))...)
+
+		locale := cfg.Default
+		for _, candidate := range candidates {
+			if matched, ok := cfg.Catalog.match(candidate); ok {
+				locale = matched
+				break
+			}
+		}
+
+		c.Set(LocaleContextKey, locale)
+		c.Set(i18nKey, &cfg)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), localeKey{}, locale))
+		c.Header("Content-Language", locale)
+		c.Next()
+	}
+}
+
+// Locale returns the locale resolved by I18nMiddleware.
+func Locale(c *gin.Context) string {
+	return c.GetString(LocaleContextKey)
+}
+
+// LocaleFromContext returns the locale resolved by I18nMiddleware, for code
+// that only receives the request context.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// T translates key into the request locale, falling back to the default
+// locale and then to the key itself. With args the message is used as a
+// fmt format:
+//
+//	c.JSON(http.StatusNotFound, gin.H{"error": web.T(c, "order.not_found", id)})
+func T(c *gin.Context, key string, args ...any) string {
+	msg := key
+	if v, ok := c.Get(i18nKey); ok {
+		cfg := v.(*I18nConfig)
+		if m, ok := cfg.Catalog.lookup(Locale(c), key); ok {
+			msg = m
+		} else if m, ok := cfg.Catalog.lookup(cfg.Default, key); ok {
+			msg = m
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// parseAcceptLanguage returns the languages of an Accept-Language header by
+// decreasing preference, leaving out the wildcard and q=0.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if tag = strings.TrimSpace(tag); tag != "" && tag != "*" && q > 0 {
+			langs = append(langs, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
+
+// normalizeLocale turns es_ar and es-ar into es-AR.
+func normalizeLocale(locale string) string {
+	lang, region, ok := strings.Cut(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestI18nMiddleware(t *testing.T) {
+	catalog, err := LoadCatalog(fstest.MapFS{
+		"locales/en.json":    {Data: []byte(`{"greeting": "Hello %s", "bye": "Bye"}`)},
+		"locales/es.json":    {Data: []byte(`{"greeting": "Hola %s"}`)},
+		"locales/pt_BR.json": {Data: []byte(`{"greeting": "Olá %s"}`)},
+	}, "locales/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := gin.New()
+	e.Use(I18nMiddleware(DefaultI18nConfig(catalog)))
+	e.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "%s|%s|%s", T(c, "greeting", "ana"), T(c, "bye"), LocaleFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    string
+	}{
+		{"default", "/", nil, "Hello ana|Bye|en"},
+		{"accept-language by weight", "/", map[string]string{"Accept-Language": "fr;q=0.9, es-AR;q=0.8, en;q=0.1"}, "Hola ana|Bye|es"},
+		{"region", "/", map[string]string{"Accept-Language": "pt-br"}, "Olá ana|Bye|pt-BR"},
+		{"cookie over header", "/", map[string]string{"Cookie": "lang=es", "Accept-Language": "pt-BR"}, "Hola ana|Bye|es"},
+		{"query over cookie", "/?lang=pt_BR", map[string]string{"Cookie": "lang=es"}, "Olá ana|Bye|pt-BR"},
+		{"unsupported query", "/?lang=de", map[string]string{"Accept-Language": "es"}, "Hola ana|Bye|es"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestT_WithoutMiddleware(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if got := T(c, "missing.key"); got != "missing.key" {
+		t.Errorf("expected the key, got %q", got)
+	}
+}
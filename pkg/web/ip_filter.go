@@ -0,0 +1,152 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// IPFilterConfig configures IPFilterMiddleware. Entries are CIDRs such as
+// "10.0.0.0/8" or single addresses. Deny wins over Allow; an empty Allow
+// admits every address that is not denied.
+type IPFilterConfig struct {
+	Allow []string
+	Deny  []string
+}
+
+// IPFilterMiddleware restricts a route group to client addresses:
+//
+//	admin := app.GetEngine().Group("/admin", web.IPFilterMiddleware(web.IPFilterConfig{Allow: []string{"10.0.0.0/8"}}))
+//
+// The address is the one resolved by TrustedRealIPMiddleware, which the app
+// installs when GinConfig.TrustedProxies is set. Otherwise it is
+// CF-Connecting-IP for Cloudflare peers and the peer address for the rest;
+// the client IP headers honored by RealIPMiddleware are never used, as any
+// caller can set them. Requests without a parsable address are rejected.
+// Rejections are answered with a 403 problem+json. It panics on an invalid
+// entry, as a misconfigured filter must not fail open.
+func IPFilterMiddleware(cfg IPFilterConfig) gin.HandlerFunc {
+	allow, err := parseNets(cfg.Allow)
+	if err != nil {
+		panic(fmt.Sprintf("web: invalid IP filter allow entry: %v", err))
+	}
+	deny, err := parseNets(cfg.Deny)
+	if err != nil {
+		panic(fmt.Sprintf("web: invalid IP filter deny entry: %v", err))
+	}
+
+	return func(c *gin.Context) {
+		addr := c.GetString(trustedClientIPKey)
+		if addr == "" {
+			addr = trustedClientIP(c.Request, nil)
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil || containsIP(deny, ip) || (len(allow) > 0 && !containsIP(allow, ip)) {
+			logs.Info(c.Request.Context(), "[IPFilter] address rejected", "client_ip", addr, "path", c.FullPath())
+			AbortWithProblem(c, http.StatusForbidden, "ip_forbidden", "access from this address is not allowed")
+			return
+		}
+		c.Next()
+	}
+}
+
+// TrustedRealIPMiddleware is RealIPMiddleware for apps behind proxies with
+// known addresses. The client address is taken from CF-Connecting-IP when
+// the peer is Cloudflare, and otherwise from X-Forwarded-For when the peer
+// is a trusted proxy, walked from the right and skipping trusted hops, so
+// entries prepended by the client are ignored. Other client IP headers,
+// such as X-Client-IP, are passed through unchanged by most load balancers
+// and are never read.
+// It panics on an invalid entry.
+func TrustedRealIPMiddleware(trustedProxies []string) gin.HandlerFunc {
+	trusted, err := parseNets(trustedProxies)
+	if err != nil {
+		panic(fmt.Sprintf("web: invalid trusted proxy: %v", err))
+	}
+	return func(c *gin.Context) {
+		ip := trustedClientIP(c.Request, trusted)
+		c.Set("client_ip", ip)
+		c.Set(trustedClientIPKey, ip)
+		c.Next()
+	}
+}
+
+// trustedClientIPKey holds the address resolved by TrustedRealIPMiddleware,
+// apart from client_ip, which RealIPMiddleware also sets from headers.
+const trustedClientIPKey = "trusted_client_ip"
+
+func trustedClientIP(r *http.Request, trusted []*net.IPNet) string {
+	peer := remoteHost(r.RemoteAddr)
+	if IsFromCloudflare(r.RemoteAddr) {
+		if cfIP := r.Header.Get("CF-Connecting-IP"); cfIP != "" {
+			return cfIP
+		}
+	}
+	if !isTrustedHop(peer, trusted) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrustedHop(hop, trusted) || i == 0 {
+			return hop
+		}
+	}
+	return peer
+}
+
+func isTrustedHop(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && containsIP(trusted, ip)
+}
+
+func remoteHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// parseNets parses CIDRs and bare addresses, the latter as single-host
+// networks.
+func parseNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
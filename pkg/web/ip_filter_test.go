@@ -0,0 +1,77 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+	e := setupEngine(
+		TrustedRealIPMiddleware([]string{"10.0.0.0/8"}),
+		IPFilterMiddleware(IPFilterConfig{
+			Allow: []string{"192.168.1.0/24", "2001:db8::1"},
+			Deny:  []string{"192.168.1.66"},
+		}),
+	)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		xClientIP  string
+		want       int
+	}{
+		{"allowed peer", "192.168.1.10:1234", "", "", http.StatusOK},
+		{"allowed ipv6 peer", "[2001:db8::1]:1234", "", "", http.StatusOK},
+		{"denied peer", "192.168.1.66:1234", "", "", http.StatusForbidden},
+		{"unlisted peer", "203.0.113.9:1234", "", "", http.StatusForbidden},
+		{"spoofed header from untrusted peer", "203.0.113.9:1234", "192.168.1.10", "", http.StatusForbidden},
+		{"client behind trusted proxy", "10.1.2.3:1234", "192.168.1.10", "", http.StatusOK},
+		{"prepended hop behind trusted proxy", "10.1.2.3:1234", "192.168.1.10, 203.0.113.9", "", http.StatusForbidden},
+		{"trusted hops are skipped", "10.1.2.3:1234", "192.168.1.10, 10.9.9.9", "", http.StatusOK},
+		{"spoofed X-Client-IP behind trusted proxy", "10.1.2.3:1234", "203.0.113.9", "192.168.1.10", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if tt.xClientIP != "" {
+			req.Header.Set("X-Client-IP", tt.xClientIP)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestIPFilterMiddleware_InvalidEntryPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an invalid CIDR")
+		}
+	}()
+	IPFilterMiddleware(IPFilterConfig{Deny: []string{"10.0.0.0/33"}})
+}
+
+func TestIPFilterMiddleware_SpoofedHeadersWithoutTrustedProxies(t *testing.T) {
+	e := setupEngine(
+		RealIPMiddleware(),
+		IPFilterMiddleware(IPFilterConfig{Allow: []string{"10.0.0.0/8"}}),
+	)
+
+	for _, header := range []string{"X-Client-IP", "X-Original-Client-IP", "X-Forwarded-For", "True-Client-IP", "CF-Connecting-IP"} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "203.0.113.5:1234"
+		req.Header.Set(header, "10.0.0.1")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 for a spoofed address, got %d", header, w.Code)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// AddListener serves the app on ln as well, e.g. a socket inherited through
+// systemd socket activation or a test listener. It must be called before
+// Run or Start; Shutdown closes it.
+func (app *GinApp) AddListener(ln net.Listener) {
+	app.listeners = append(app.listeners, ln)
+}
+
+// openListeners listens on the configured port, unless it is empty, on
+// every GinConfig.ListenAddrs entry, and returns them with the listeners
+// added through AddListener. ListenAddrs entries are "host:port" or
+// "unix:/path/to.sock".
+func (app *GinApp) openListeners(addr string) ([]net.Listener, error) {
+	var addrs []string
+	if addr != "" {
+		addrs = append(addrs, addr)
+	}
+	addrs = append(addrs, app.ginConfig.ListenAddrs...)
+
+	var opened []net.Listener
+	for _, a := range addrs {
+		ln, err := listen(a)
+		if err != nil {
+			for _, ln := range opened {
+				_ = ln.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", a, err)
+		}
+		opened = append(opened, ln)
+	}
+
+	listeners := append(opened, app.listeners...)
+	if len(listeners) == 0 {
+		return nil, errors.New("web: no address to listen on, set Port, ListenAddrs or call AddListener")
+	}
+	return listeners, nil
+}
+
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	// A socket left behind by a crashed process would make Listen fail.
+	if info, err := os.Stat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		_ = os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+func listenerAddr(ln net.Listener) string {
+	addr := ln.Addr()
+	if addr.Network() == "unix" {
+		return "unix:" + addr.String()
+	}
+	return addr.String()
+}
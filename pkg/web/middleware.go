@@ -3,9 +3,9 @@ package web
 import (
 	"context"
 	"crypto/subtle"
-	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 
@@ -14,58 +14,74 @@ import (
 	"github.com/fsandov/go-sdk/pkg/env"
 	"github.com/fsandov/go-sdk/pkg/logs"
 	"github.com/fsandov/go-sdk/pkg/paginate"
-	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func (app *GinApp) setupMiddleware() {
 
-	app.engine.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
-	})
+	app.engine.NoRoute(routeNotFound)
 
 	app.engine.NoMethod(func(c *gin.Context) {
 		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "method not allowed"})
 	})
 
 	if app.ginConfig.EnableTracing {
-		app.engine.Use(otelgin.Middleware(config.Get().AppName))
+		app.engine.Use(tracingMiddleware(config.Get().AppName)...)
 	}
 
 	if app.ginConfig.EnableRequestID {
 		app.engine.Use(RequestIDMiddleware())
 	}
 
+	if app.ginConfig.EnableAccessLog {
+		accessLogCfg := app.ginConfig.AccessLog
+		if accessLogCfg.SkipPaths == nil {
+			accessLogCfg.SkipPaths = defaultAccessLogSkipPaths
+		}
+		app.engine.Use(AccessLogMiddlewareWithConfig(accessLogCfg))
+	}
+
+	if app.ginConfig.EnableProblemDetails {
+		app.engine.Use(ProblemMiddleware())
+	}
+
 	if app.ginConfig.EnableRecovery {
-		app.engine.Use(gin.Recovery())
+		app.engine.Use(RecoveryMiddleware(app.ginConfig.Recovery))
+	}
+
+	if app.ginConfig.FeatureFlags != nil {
+		app.engine.Use(FeatureFlagsMiddleware(app.ginConfig.FeatureFlags))
 	}
 
 	if app.ginConfig.EnableCORS {
-		corsConfig := cors.DefaultConfig()
-		if len(app.ginConfig.CORSOrigins) > 0 {
-			corsConfig.AllowOrigins = app.ginConfig.CORSOrigins
-		} else if env.IsProduction() {
-			logs.Error(context.Background(), "CORS: no origins configured in production — allowing all origins is a security risk. Set CORSOrigins explicitly.", logs.WithNotifier())
-			corsConfig.AllowAllOrigins = true
-		} else if env.IsRemote() {
-			app.logger.Warn(context.Background(), "CORS: no origins configured, allowing all origins in remote environment")
-			corsConfig.AllowAllOrigins = true
-		} else {
-			corsConfig.AllowAllOrigins = true
+		corsCfg := app.ginConfig.CORS
+		if len(corsCfg.AllowOrigins) == 0 && len(app.ginConfig.CORSOrigins) > 0 {
+			corsCfg.AllowOrigins = app.ginConfig.CORSOrigins
+			corsCfg.AllowCredentials = true
 		}
-		corsConfig.AllowCredentials = !corsConfig.AllowAllOrigins
-		corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "Authorization", "X-Request-ID")
-		app.engine.Use(cors.New(corsConfig))
+		if len(corsCfg.AllowOrigins) == 0 {
+			if env.IsProduction() {
+				logs.Error(context.Background(), "CORS: no origins configured in production, rejecting cross-origin requests. Set CORS_ALLOW_ORIGINS explicitly.", logs.WithNotifier())
+			} else {
+				app.logger.Warn(context.Background(), "CORS: no origins configured, rejecting cross-origin requests")
+			}
+		}
+		app.engine.Use(CORSMiddleware(corsCfg))
 	}
 
 	if app.ginConfig.EnableMetrics {
-		app.engine.Use(httpServerMetricsMiddleware())
-		app.engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
+		skipPaths := app.ginConfig.MetricsSkipPaths
+		if skipPaths == nil {
+			skipPaths = defaultAccessLogSkipPaths
+		}
+		app.engine.Use(httpServerMetricsMiddleware(skipPaths))
+		if app.opsEngine == nil {
+			app.engine.GET("/metrics", OpsAuthMiddleware(app.ginConfig.OpsAuth), gin.WrapH(promhttp.Handler()))
+		}
 	}
 
 	if app.ginConfig.EnableCompression {
@@ -80,12 +96,25 @@ func (app *GinApp) setupMiddleware() {
 		app.engine.Use(XAuthAppTokenMiddleware())
 	}
 
-	app.engine.Use(SecureHeadersMiddleware())
-	app.engine.Use(RealIPMiddleware())
+	if app.ginConfig.SecurityHeaders != nil {
+		app.engine.Use(SecurityHeadersMiddleware(*app.ginConfig.SecurityHeaders))
+	} else {
+		app.engine.Use(SecureHeadersMiddleware())
+	}
+	if len(app.ginConfig.TrustedProxies) > 0 {
+		app.engine.Use(TrustedRealIPMiddleware(app.ginConfig.TrustedProxies))
+	} else {
+		app.engine.Use(RealIPMiddleware())
+	}
 	app.engine.Use(IPContextMiddleware())
+	app.engine.Use(InboundHeadersMiddleware())
 
 }
 
+func routeNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+}
+
 func XAuthAppTokenMiddleware() gin.HandlerFunc {
 	appToken := os.Getenv("X_AUTH_APP_TOKEN")
 	return func(c *gin.Context) {
@@ -97,6 +126,11 @@ func XAuthAppTokenMiddleware() gin.HandlerFunc {
 	}
 }
 
+// RequestIDMiddleware reuses the inbound X-Request-ID or generates one,
+// echoes it in the response and stores it in the request context, where
+// pkg/logs adds it to every entry and pkg/client propagates it. With
+// tracing enabled, the trace ID is echoed as X-Trace-ID and logged too, so
+// a support ticket quoting either header leads to the logs and the trace.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
@@ -108,6 +142,11 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("X-Request-ID", requestID)
 
 		ctx := context.WithValue(c.Request.Context(), client.RequestIDContextKey{}, requestID)
+		ctx = context.WithValue(ctx, logs.CtxKeyRequestID, requestID)
+		if traceID := traceIDFromContext(ctx); traceID != "" {
+			c.Writer.Header().Set("X-Trace-ID", traceID)
+			ctx = context.WithValue(ctx, logs.CtxKeyTraceID, traceID)
+		}
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
@@ -166,8 +205,15 @@ func init() {
 	)
 }
 
-func httpServerMetricsMiddleware() gin.HandlerFunc {
+// httpServerMetricsMiddleware records the request metrics. Metrics are
+// aggregates and are never sampled, but skipPaths keep probes and scrapes
+// from dominating the series.
+func httpServerMetricsMiddleware(skipPaths []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if slices.Contains(skipPaths, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
 		start := time.Now()
 		httpServerRequestsInFlight.Inc()
 
@@ -176,7 +222,7 @@ func httpServerMetricsMiddleware() gin.HandlerFunc {
 
 			if r := recover(); r != nil {
 				httpServerPanicsTotal.Inc()
-				panic(r) // re-panic for gin.Recovery() to handle
+				panic(r) // re-panic for RecoveryMiddleware to handle
 			}
 		}()
 
@@ -197,17 +243,17 @@ func httpServerMetricsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// SecureHeadersMiddleware sends a fixed strict header set, HSTS included,
+// regardless of the environment. Use SecurityHeadersMiddleware to tune it.
 func SecureHeadersMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
-		c.Writer.Header().Set("X-Frame-Options", "DENY")
-		c.Writer.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-		c.Writer.Header().Set("X-XSS-Protection", "0")
-		c.Writer.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
-		c.Header("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
-		c.Header("Cache-Control", "no-store")
-		c.Next()
-	}
+	return SecurityHeadersMiddleware(SecurityHeadersConfig{
+		HSTSMaxAge:            63072000 * time.Second,
+		HSTSIncludeSubdomains: true,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=()",
+		CacheControl:          "no-store",
+	})
 }
 
 func RealIPMiddleware() gin.HandlerFunc {
@@ -232,6 +278,10 @@ func GetIPFromContext(c *gin.Context) string {
 	return clientIP(c)
 }
 
+// clientIP resolves the client address for logging and propagation. It
+// honors X-Client-IP and X-Original-Client-IP, which services set for each
+// other through pkg/client's IPPropagationMiddleware, so it must not be used
+// for access control; IPFilterMiddleware resolves the address on its own.
 func clientIP(c *gin.Context) string {
 	remoteAddr := c.Request.RemoteAddr
 
@@ -252,11 +302,7 @@ func clientIP(c *gin.Context) string {
 		return origClientIP
 	}
 
-	host, _, err := net.SplitHostPort(remoteAddr)
-	if err != nil {
-		return remoteAddr
-	}
-	return host
+	return remoteHost(remoteAddr)
 }
 
 func GetIPHeadersFromContext(c *gin.Context) map[string]string {
@@ -300,3 +346,12 @@ func IPContextMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// InboundHeadersMiddleware stores the request headers in the request context
+// so client.PropagationMiddleware can forward them on outbound calls.
+func InboundHeadersMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(client.WithInboundHeaders(c.Request.Context(), c.Request.Header))
+		c.Next()
+	}
+}
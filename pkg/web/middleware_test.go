@@ -5,7 +5,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/fsandov/go-sdk/pkg/logs"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func init() {
@@ -78,6 +80,37 @@ func TestRequestIDMiddleware_PreservesExisting(t *testing.T) {
 	}
 }
 
+func TestRequestIDMiddleware_ContextAndTraceID(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	var ctxRequestID, ctxTraceID any
+	e := gin.New()
+	e.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(trace.ContextWithSpanContext(c.Request.Context(), sc))
+		c.Next()
+	}, RequestIDMiddleware())
+	e.GET("/test", func(c *gin.Context) {
+		ctxRequestID = c.Request.Context().Value(logs.CtxKeyRequestID)
+		ctxTraceID = c.Request.Context().Value(logs.CtxKeyTraceID)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	e.ServeHTTP(w, req)
+
+	if ctxRequestID != "req-42" {
+		t.Errorf("expected the request ID in the logs context, got %v", ctxRequestID)
+	}
+	want := "4bf92f3577b34da6a3ce929d0e0e4736"
+	if ctxTraceID != want || w.Header().Get("X-Trace-ID") != want {
+		t.Errorf("expected trace ID %s, got %v and header %q", want, ctxTraceID, w.Header().Get("X-Trace-ID"))
+	}
+}
+
 func TestRecoveryMiddleware(t *testing.T) {
 	e := gin.New()
 	e.Use(gin.Recovery())
@@ -128,6 +161,23 @@ func TestRealIPMiddleware_CFConnectingIP_FromCloudflare(t *testing.T) {
 	}
 }
 
+func TestRealIPMiddleware_PropagatedClientIP(t *testing.T) {
+	e := gin.New()
+	e.Use(RealIPMiddleware())
+	e.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("client_ip"))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Client-IP", "203.0.113.7")
+	e.ServeHTTP(w, req)
+
+	if w.Body.String() != "203.0.113.7" {
+		t.Errorf("expected the X-Client-IP set by the calling service, got %q", w.Body.String())
+	}
+}
+
 func TestNoRouteHandler(t *testing.T) {
 	e := gin.New()
 	e.NoRoute(func(c *gin.Context) {
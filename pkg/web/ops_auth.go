@@ -0,0 +1,75 @@
+package web
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpsAuthConfig protects /metrics and /debug/pprof. A request passes when
+// it satisfies any configured method; with none configured the endpoints
+// stay open, which is only reasonable behind a private OpsPort. Health
+// endpoints are never gated, since orchestrator probes do not authenticate.
+type OpsAuthConfig struct {
+	BasicAuthUser     string
+	BasicAuthPassword string
+	// Token is accepted as "Authorization: Bearer <token>", which is what
+	// Prometheus sends with bearer_token.
+	Token string
+	// XAuthAppToken accepts the X-Auth-App-Token header checked by
+	// XAuthAppTokenMiddleware.
+	XAuthAppToken bool
+}
+
+// DefaultOpsAuthConfig reads OPS_BASIC_AUTH_USER, OPS_BASIC_AUTH_PASSWORD
+// and OPS_TOKEN.
+func DefaultOpsAuthConfig() OpsAuthConfig {
+	return OpsAuthConfig{
+		BasicAuthUser:     os.Getenv("OPS_BASIC_AUTH_USER"),
+		BasicAuthPassword: os.Getenv("OPS_BASIC_AUTH_PASSWORD"),
+		Token:             os.Getenv("OPS_TOKEN"),
+	}
+}
+
+func (cfg OpsAuthConfig) enabled() bool {
+	return cfg.BasicAuthUser != "" || cfg.Token != "" || cfg.XAuthAppToken
+}
+
+// OpsAuthMiddleware enforces cfg; it is a no-op when no method is set.
+func OpsAuthMiddleware(cfg OpsAuthConfig) gin.HandlerFunc {
+	appToken := os.Getenv("X_AUTH_APP_TOKEN")
+	return func(c *gin.Context) {
+		if !cfg.enabled() || cfg.allows(c.Request, appToken) {
+			c.Next()
+			return
+		}
+		if cfg.BasicAuthUser != "" {
+			c.Header("WWW-Authenticate", `Basic realm="ops"`)
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	}
+}
+
+func (cfg OpsAuthConfig) allows(r *http.Request, appToken string) bool {
+	if cfg.BasicAuthUser != "" {
+		if user, pass, ok := r.BasicAuth(); ok && secureEqual(user, cfg.BasicAuthUser) && secureEqual(pass, cfg.BasicAuthPassword) {
+			return true
+		}
+	}
+	if cfg.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(token, cfg.Token) {
+			return true
+		}
+	}
+	if cfg.XAuthAppToken && appToken != "" && secureEqual(r.Header.Get("X-Auth-App-Token"), appToken) {
+		return true
+	}
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
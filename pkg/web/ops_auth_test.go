@@ -0,0 +1,79 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpsAuthMiddleware(t *testing.T) {
+	t.Setenv("X_AUTH_APP_TOKEN", "app-secret")
+	e := setupEngine(OpsAuthMiddleware(OpsAuthConfig{
+		BasicAuthUser:     "prom",
+		BasicAuthPassword: "scrape",
+		Token:             "ops-token",
+		XAuthAppToken:     true,
+	}))
+
+	tests := []struct {
+		name  string
+		setup func(*http.Request)
+		want  int
+	}{
+		{"none", func(*http.Request) {}, http.StatusUnauthorized},
+		{"basic", func(r *http.Request) { r.SetBasicAuth("prom", "scrape") }, http.StatusOK},
+		{"wrong basic", func(r *http.Request) { r.SetBasicAuth("prom", "nope") }, http.StatusUnauthorized},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer ops-token") }, http.StatusOK},
+		{"wrong bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"app token", func(r *http.Request) { r.Header.Set("X-Auth-App-Token", "app-secret") }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		tt.setup(req)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, w.Code)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected a basic auth challenge", tt.name)
+		}
+	}
+}
+
+func TestOpsAuth_GatesMetricsAndPprof(t *testing.T) {
+	app := &GinApp{
+		engine: gin.New(),
+		logger: logs.GetLogger(),
+		ginConfig: GinConfig{
+			EnableMetrics: true,
+			EnablePprof:   true,
+			OpsAuth:       OpsAuthConfig{Token: "ops-token"},
+		},
+	}
+	app.setupRoutes()
+	app.setupMiddleware()
+
+	for _, path := range []string{"/metrics", "/debug/pprof/", "/health/live"} {
+		w := httptest.NewRecorder()
+		app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		want := http.StatusUnauthorized
+		if path == "/health/live" {
+			want = http.StatusOK
+		}
+		if w.Code != want {
+			t.Errorf("%s: expected %d without credentials, got %d", path, want, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer ops-token")
+	w := httptest.NewRecorder()
+	app.engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 with the token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details payload. Code is a stable,
+// machine-readable identifier for clients to branch on; Errors carries
+// field-level details for validation failures.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Errors   []FieldError   `json:"errors,omitempty"`
+	Extra    map[string]any `json:"-"`
+}
+
+// FieldError describes why one field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewProblem builds a problem with the standard title for status.
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Error lets handlers return a Problem through c.Error for ProblemMiddleware
+// to render.
+func (p *Problem) Error() string {
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Code, p.Detail)
+}
+
+// AbortWithProblem writes a problem+json response and aborts the chain.
+//
+//	web.AbortWithProblem(c, http.StatusNotFound, "order_not_found", "order 42 does not exist")
+func AbortWithProblem(c *gin.Context, status int, code, detail string) {
+	AbortWithProblemDetails(c, NewProblem(status, code, detail))
+}
+
+// AbortWithProblemDetails writes p as the response and aborts the chain.
+// Instance defaults to the request path.
+func AbortWithProblemDetails(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(p.Status, p.body())
+}
+
+// body merges Extra into the top-level members, as RFC 7807 extensions.
+func (p *Problem) body() any {
+	if len(p.Extra) == 0 {
+		return p
+	}
+	body := make(map[string]any, len(p.Extra)+8)
+	for k, v := range p.Extra {
+		body[k] = v
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	if p.Code != "" {
+		body["code"] = p.Code
+	}
+	if len(p.Errors) > 0 {
+		body["errors"] = p.Errors
+	}
+	return body
+}
+
+// ProblemMiddleware turns panics and errors attached with c.Error into
+// problem+json responses when the handler has not written one itself:
+// a *Problem is rendered as is, binding errors become 400 and anything else
+// a 500 whose detail is not exposed. When both are enabled, GinApp
+// registers RecoveryMiddleware after it so panics are handled there.
+func ProblemMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer recoverPanic(c, "[ProblemMiddleware]", false)
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		AbortWithProblemDetails(c, problemFromError(c, c.Errors.Last()))
+	}
+}
+
+func problemFromError(c *gin.Context, ginErr *gin.Error) *Problem {
+	var p *Problem
+	if errors.As(ginErr.Err, &p) {
+		cp := *p
+		return &cp
+	}
+	if ginErr.IsType(gin.ErrorTypeBind) {
+		return NewProblem(http.StatusBadRequest, "invalid_request", ginErr.Error())
+	}
+	logs.Error(c.Request.Context(), "[ProblemMiddleware] unhandled error", "error", ginErr.Err, "path", c.Request.URL.Path)
+	return NewProblem(http.StatusInternalServerError, "internal_error", "")
+}
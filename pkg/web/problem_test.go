@@ -0,0 +1,98 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveProblem(t *testing.T, handler gin.HandlerFunc) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	e := gin.New()
+	e.Use(ProblemMiddleware())
+	e.GET("/orders/:id", handler)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", w.Body.String(), err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("expected %s, got %q", ProblemContentType, ct)
+	}
+	return w, body
+}
+
+func TestAbortWithProblem(t *testing.T) {
+	w, body := serveProblem(t, func(c *gin.Context) {
+		AbortWithProblem(c, http.StatusNotFound, "order_not_found", "order 42 does not exist")
+	})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	want := map[string]any{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(404),
+		"detail":   "order 42 does not exist",
+		"instance": "/orders/42",
+		"code":     "order_not_found",
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, body[k])
+		}
+	}
+}
+
+func TestProblemExtensions(t *testing.T) {
+	_, body := serveProblem(t, func(c *gin.Context) {
+		p := NewProblem(http.StatusConflict, "stale_version", "")
+		p.Extra = map[string]any{"current_version": 3}
+		AbortWithProblemDetails(c, p)
+	})
+	if body["current_version"] != float64(3) || body["code"] != "stale_version" {
+		t.Errorf("unexpected body %v", body)
+	}
+}
+
+func TestProblemMiddleware(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		w, body := serveProblem(t, func(c *gin.Context) { panic("boom") })
+		if w.Code != http.StatusInternalServerError || body["code"] != "internal_error" {
+			t.Errorf("unexpected response %d %v", w.Code, body)
+		}
+	})
+
+	t.Run("problem error", func(t *testing.T) {
+		w, body := serveProblem(t, func(c *gin.Context) {
+			_ = c.Error(NewProblem(http.StatusPaymentRequired, "quota_exceeded", "upgrade your plan"))
+		})
+		if w.Code != http.StatusPaymentRequired || body["detail"] != "upgrade your plan" {
+			t.Errorf("unexpected response %d %v", w.Code, body)
+		}
+	})
+
+	t.Run("bind error", func(t *testing.T) {
+		w, body := serveProblem(t, func(c *gin.Context) {
+			_ = c.Error(errors.New("invalid character")).SetType(gin.ErrorTypeBind)
+		})
+		if w.Code != http.StatusBadRequest || body["code"] != "invalid_request" {
+			t.Errorf("unexpected response %d %v", w.Code, body)
+		}
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		w, body := serveProblem(t, func(c *gin.Context) {
+			_ = c.Error(errors.New("db password is hunter2"))
+		})
+		if w.Code != http.StatusInternalServerError || body["detail"] != nil {
+			t.Errorf("expected internal errors to be hidden, got %d %v", w.Code, body)
+		}
+	})
+}
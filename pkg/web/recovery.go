@@ -0,0 +1,65 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/fsandov/go-sdk/pkg/env"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/fsandov/go-sdk/pkg/tokens"
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryConfig configures RecoveryMiddleware.
+type RecoveryConfig struct {
+	// Notify fires the configured notifiers for every panic.
+	Notify bool
+}
+
+// DefaultRecoveryConfig notifies panics in remote environments.
+func DefaultRecoveryConfig() RecoveryConfig {
+	return RecoveryConfig{Notify: env.IsRemote()}
+}
+
+// RecoveryMiddleware replaces gin.Recovery: panics are logged with their
+// stack, route, request ID and user ID and answered with a problem+json 500
+// whose detail is not exposed. http.ErrAbortHandler is re-panicked so the
+// server drops the connection as usual.
+func RecoveryMiddleware(cfg RecoveryConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer recoverPanic(c, "[Recovery]", cfg.Notify)
+		c.Next()
+	}
+}
+
+// recoverPanic must be deferred directly by the middleware.
+func recoverPanic(c *gin.Context, name string, notify bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+
+	fields := []any{
+		"panic", fmt.Sprint(r),
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"route", c.FullPath(),
+		"request_id", c.GetString("request_id"),
+		"user_id", c.GetString(tokens.KeyUserID),
+		"stack", string(debug.Stack()),
+	}
+	if notify {
+		fields = append(fields, logs.WithNotifier())
+	}
+	logs.Error(c.Request.Context(), name+" panic recovered", fields...)
+
+	if !c.Writer.Written() {
+		AbortWithProblem(c, http.StatusInternalServerError, "internal_error", "")
+	} else {
+		c.Abort()
+	}
+}
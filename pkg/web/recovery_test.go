@@ -0,0 +1,51 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveryMiddleware_Problem(t *testing.T) {
+	e := gin.New()
+	e.Use(RecoveryMiddleware(RecoveryConfig{}))
+	e.GET("/panic", func(c *gin.Context) {
+		panic("test panic")
+	})
+	e.GET("/partial", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("late panic")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("expected problem+json, got %q", ct)
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("expected the written response to be kept, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryMiddleware_AbortHandler(t *testing.T) {
+	e := gin.New()
+	e.Use(RecoveryMiddleware(RecoveryConfig{}))
+	e.GET("/abort", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to be re-panicked, got %v", r)
+		}
+	}()
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+}
@@ -0,0 +1,223 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseCacheConfig configures a ResponseCache.
+type ResponseCacheConfig struct {
+	Cache cache.Cache
+	TTL   time.Duration
+	// VaryHeaders are request headers that select different entries, e.g.
+	// Accept-Language. Requests carrying credentials, i.e. an Authorization,
+	// Cookie or X-API-Key header, bypass the cache unless the header is
+	// listed here, so one user's response is never served to another.
+	VaryHeaders []string
+	// VaryQuery restricts the query parameters that select entries; others
+	// are ignored, which keeps cache busters from filling the cache. When
+	// empty the whole query is used.
+	VaryQuery   []string
+	StatusCodes []int
+	KeyPrefix   string
+}
+
+// ResponseCache stores full GET responses in a cache.Cache, so repeated
+// requests skip the handler. It is the inbound counterpart of the client's
+// CacheMiddleware. Handlers can opt a response out with Cache-Control
+// no-store or private, and responses setting cookies are never stored.
+//
+//	products := web.NewResponseCache(web.ResponseCacheConfig{Cache: c, TTL: time.Minute})
+//	r.GET("/products/:id", products.Middleware(), getProduct)
+//	r.PUT("/products/:id", updateProduct) // calls products.Invalidate(ctx, "/products/"+id)
+type ResponseCache struct {
+	cfg ResponseCacheConfig
+}
+
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Headers owned by the request rather than the response.
+var uncachedHeaders = []string{"Set-Cookie", "X-Request-Id", "X-Cache"}
+
+// NewResponseCache applies defaults: one minute TTL and only 200 responses
+// stored.
+func NewResponseCache(cfg ResponseCacheConfig) *ResponseCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Minute
+	}
+	if len(cfg.StatusCodes) == 0 {
+		cfg.StatusCodes = []int{http.StatusOK}
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "response_cache:"
+	}
+	return &ResponseCache{cfg: cfg}
+}
+
+// Middleware serves GET requests from the cache, marking responses with
+// X-Cache: HIT or MISS. Cache errors are logged and the handler runs.
+func (rc *ResponseCache) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || !rc.cacheable(c.Request) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		path := c.Request.URL.Path
+		key := rc.key(c.Request)
+		if data, err := rc.cfg.Cache.Get(ctx, key); err == nil {
+			var entry cachedResponse
+			if err := json.Unmarshal([]byte(data), &entry); err == nil {
+				h := c.Writer.Header()
+				for k, v := range entry.Header {
+					h[k] = v
+				}
+				h.Set("X-Cache", "HIT")
+				c.Data(entry.Status, h.Get("Content-Type"), []byte(entry.Body))
+				c.Abort()
+				return
+			}
+		} else if !errors.Is(err, cache.ErrKeyNotFound) {
+			logs.Warn(ctx, "[ResponseCache] failed to read entry", "error", err)
+		}
+
+		before := c.Writer.Header().Clone()
+		c.Header("X-Cache", "MISS")
+		bw := newBufferWriter(c.Writer)
+		c.Writer = bw
+		c.Next()
+		c.Writer = bw.ResponseWriter
+		if bw.passthrough {
+			return
+		}
+		if rc.storable(bw) {
+			entry := cachedResponse{
+				Status: bw.status,
+				Header: handlerHeaders(before, bw.Header()),
+				Body:   bw.body.String(),
+			}
+			if err := rc.store(ctx, path, key, entry); err != nil {
+				logs.Warn(ctx, "[ResponseCache] failed to store entry", "error", err)
+			}
+		}
+		bw.flush()
+	}
+}
+
+// Invalidate drops every cached variant of the given request paths.
+func (rc *ResponseCache) Invalidate(ctx context.Context, paths ...string) error {
+	var errs []error
+	for _, path := range paths {
+		index := rc.indexKey(path)
+		keys, err := rc.cfg.Cache.ZRange(ctx, index, 0, -1)
+		if err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+		for _, key := range append(keys, index) {
+			if err := rc.cfg.Cache.Delete(ctx, key); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// credentialHeaders identify the user of a request: the bearer token, the
+// session and auth cookies, and API keys.
+var credentialHeaders = []string{"Authorization", "Cookie", "X-API-Key"}
+
+func (rc *ResponseCache) cacheable(r *http.Request) bool {
+	for _, name := range credentialHeaders {
+		if r.Header.Get(name) == "" {
+			continue
+		}
+		if !slices.ContainsFunc(rc.cfg.VaryHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (rc *ResponseCache) storable(bw *bufferWriter) bool {
+	if !slices.Contains(rc.cfg.StatusCodes, bw.status) || bw.Header().Get("Set-Cookie") != "" {
+		return false
+	}
+	cc := strings.ToLower(bw.Header().Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// store saves the entry and indexes it under its path for Invalidate.
+func (rc *ResponseCache) store(ctx context.Context, path, key string, entry cachedResponse) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := rc.cfg.Cache.Set(ctx, key, string(data), rc.cfg.TTL); err != nil {
+		return err
+	}
+	index := rc.indexKey(path)
+	if err := rc.cfg.Cache.ZAdd(ctx, index, float64(time.Now().Unix()), key); err != nil {
+		return err
+	}
+	_, err = rc.cfg.Cache.Expire(ctx, index, rc.cfg.TTL)
+	return err
+}
+
+// key hashes the path with the varying query parameters and headers.
+func (rc *ResponseCache) key(r *http.Request) string {
+	query := r.URL.Query()
+	if len(rc.cfg.VaryQuery) > 0 {
+		selected := url.Values{}
+		for _, name := range rc.cfg.VaryQuery {
+			if v, ok := query[name]; ok {
+				selected[name] = v
+			}
+		}
+		query = selected
+	}
+
+	h := sha256.New()
+	h.Write([]byte(r.URL.Path + "?" + query.Encode()))
+	for _, name := range rc.cfg.VaryHeaders {
+		h.Write([]byte("\n" + strings.ToLower(name) + ":" + strings.Join(r.Header.Values(name), ",")))
+	}
+	return rc.cfg.KeyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+func (rc *ResponseCache) indexKey(path string) string {
+	return rc.cfg.KeyPrefix + "path:" + path
+}
+
+// handlerHeaders returns the headers set or changed after before was taken,
+// leaving out those set by earlier middlewares for the current request only,
+// such as CORS.
+func handlerHeaders(before, after http.Header) http.Header {
+	out := http.Header{}
+	for k, v := range after {
+		if !slices.Equal(before[k], v) {
+			out[k] = v
+		}
+	}
+	for _, k := range uncachedHeaders {
+		out.Del(k)
+	}
+	return out
+}
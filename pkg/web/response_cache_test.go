@@ -0,0 +1,91 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponseCache(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	rc := NewResponseCache(ResponseCacheConfig{
+		Cache:       store,
+		VaryHeaders: []string{"Accept-Language"},
+		VaryQuery:   []string{"page"},
+	})
+
+	calls := 0
+	e := gin.New()
+	e.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
+		c.Next()
+	})
+	e.GET("/products", rc.Middleware(), func(c *gin.Context) {
+		calls++
+		c.Header("Content-Language", c.GetHeader("Accept-Language"))
+		c.JSON(http.StatusOK, gin.H{"page": c.Query("page"), "calls": calls})
+	})
+	e.GET("/private", rc.Middleware(), func(c *gin.Context) {
+		calls++
+		c.Header("Cache-Control", "private")
+		c.String(http.StatusOK, "mine")
+	})
+
+	get := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/products?page=1", map[string]string{"Origin": "https://a.example"})
+	if first.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("expected a miss, got %q", first.Header().Get("X-Cache"))
+	}
+	hit := get("/products?page=1&_=123", map[string]string{"Origin": "https://b.example"})
+	if hit.Header().Get("X-Cache") != "HIT" || hit.Body.String() != first.Body.String() || calls != 1 {
+		t.Fatalf("expected a hit with the cached body, got %q %q after %d calls", hit.Header().Get("X-Cache"), hit.Body.String(), calls)
+	}
+	if got := hit.Header().Get("Access-Control-Allow-Origin"); got != "https://b.example" {
+		t.Errorf("expected middleware headers from the current request, got %q", got)
+	}
+	if hit.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("unexpected content type %q", hit.Header().Get("Content-Type"))
+	}
+
+	if w := get("/products?page=2", nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected another page to miss")
+	}
+	if w := get("/products?page=1", map[string]string{"Accept-Language": "es"}); w.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected another language to miss")
+	}
+	for _, creds := range []map[string]string{
+		{"Authorization": "Bearer x"},
+		{"Cookie": "session=abc"},
+		{"X-API-Key": "key"},
+	} {
+		if w := get("/products?page=1", creds); w.Header().Get("X-Cache") != "" {
+			t.Errorf("expected requests with %v to bypass the cache", creds)
+		}
+	}
+
+	if err := rc.Invalidate(context.Background(), "/products"); err != nil {
+		t.Fatal(err)
+	}
+	if w := get("/products?page=1", nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected a miss after invalidation")
+	}
+
+	get("/private", nil)
+	if w := get("/private", nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected private responses not to be stored")
+	}
+}
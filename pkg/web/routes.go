@@ -1,18 +1,75 @@
 package web
 
 import (
+	"cmp"
 	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
 
+	"github.com/fsandov/go-sdk/pkg/env"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// setupRoutes registers the operational endpoints. With an OpsPort they go
+// on a separate engine served on that port only, so they are never exposed
+// through the public ingress.
 func (app *GinApp) setupRoutes() {
-	app.engine.GET("/health", func(c *gin.Context) {
+	ops := app.engine
+	if app.ginConfig.OpsPort != "" {
+		app.opsEngine = gin.New()
+		app.opsEngine.Use(gin.Recovery())
+		ops = app.opsEngine
+		if app.ginConfig.EnableMetrics {
+			ops.GET("/metrics", OpsAuthMiddleware(app.ginConfig.OpsAuth), gin.WrapH(promhttp.Handler()))
+		}
+	}
+
+	ops.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
+	ops.GET("/health/live", liveHandler)
+	ops.GET("/health/ready", app.readyHandler)
 
 	if app.ginConfig.EnablePprof {
-		pprof.RouteRegister(&app.engine.RouterGroup, "/debug/pprof")
+		pprof.RouteRegister(ops.Group("", OpsAuthMiddleware(app.ginConfig.OpsAuth)), "/debug/pprof")
+	}
+
+	// The route table is only served locally or on the ops port.
+	if app.ginConfig.EnableDebugRoutes && (app.opsEngine != nil || !env.IsRemote()) {
+		ops.GET("/debug/routes", OpsAuthMiddleware(app.ginConfig.OpsAuth), func(c *gin.Context) {
+			c.JSON(http.StatusOK, app.Routes())
+		})
+	}
+}
+
+// RouteInfo describes a route registered on the app.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+	// Middleware lists the engine-wide middleware, outermost first. Group
+	// middleware is not exposed by gin and is not included.
+	Middleware []string `json:"middleware"`
+}
+
+// Routes returns the routes registered on the main engine, sorted by path
+// and method, to verify a deployment or generate docs.
+func (app *GinApp) Routes() []RouteInfo {
+	middleware := make([]string, len(app.engine.Handlers))
+	for i, h := range app.engine.Handlers {
+		middleware[i] = runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
 	}
+
+	routes := app.engine.Routes()
+	infos := make([]RouteInfo, len(routes))
+	for i, r := range routes {
+		infos[i] = RouteInfo{Method: r.Method, Path: r.Path, Handler: r.Handler, Middleware: middleware}
+	}
+	slices.SortFunc(infos, func(a, b RouteInfo) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Method, b.Method))
+	})
+	return infos
 }
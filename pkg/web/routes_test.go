@@ -0,0 +1,56 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func listOrders(c *gin.Context) {}
+
+func TestRoutes(t *testing.T) {
+	app := &GinApp{
+		engine:    gin.New(),
+		logger:    logs.GetLogger(),
+		ginConfig: GinConfig{OpsPort: "9090", EnableDebugRoutes: true},
+	}
+	app.engine.Use(RequestIDMiddleware())
+	app.setupRoutes()
+	app.engine.GET("/orders", listOrders)
+	app.engine.POST("/orders", listOrders)
+
+	routes := app.Routes()
+	if len(routes) != 2 || routes[0].Method != http.MethodGet || routes[1].Method != http.MethodPost {
+		t.Fatalf("expected GET and POST /orders, got %+v", routes)
+	}
+	if !strings.HasSuffix(routes[0].Handler, ".listOrders") {
+		t.Errorf("unexpected handler %q", routes[0].Handler)
+	}
+	if len(routes[0].Middleware) != 1 || !strings.Contains(routes[0].Middleware[0], "RequestIDMiddleware") {
+		t.Errorf("unexpected middleware %v", routes[0].Middleware)
+	}
+
+	w := httptest.NewRecorder()
+	app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 on the public port, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	app.opsEngine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on the ops port, got %d", w.Code)
+	}
+	var got []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Path != "/orders" {
+		t.Errorf("unexpected routes %+v", got)
+	}
+}
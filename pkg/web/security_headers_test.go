@@ -0,0 +1,54 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	e := setupEngine(SecurityHeadersMiddleware(SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           true,
+		ContentSecurityPolicy: "default-src 'self'",
+		CSPReportOnly:         true,
+		FrameOptions:          "SAMEORIGIN",
+	}))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	expected := map[string]string{
+		"X-Content-Type-Options":              "nosniff",
+		"X-Frame-Options":                     "SAMEORIGIN",
+		"Strict-Transport-Security":           "max-age=31536000; includeSubDomains; preload",
+		"Content-Security-Policy-Report-Only": "default-src 'self'",
+		"Content-Security-Policy":             "",
+		"Referrer-Policy":                     "",
+		"Cache-Control":                       "",
+	}
+	for header, want := range expected {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("header %s: expected %q, got %q", header, want, got)
+		}
+	}
+}
+
+func TestDefaultSecurityHeadersConfig(t *testing.T) {
+	t.Setenv("CONTENT_SECURITY_POLICY", "default-src 'self'")
+
+	cfg := DefaultSecurityHeadersConfig()
+	if cfg.HSTSMaxAge != 0 {
+		t.Errorf("expected no HSTS locally, got %v", cfg.HSTSMaxAge)
+	}
+	if cfg.ContentSecurityPolicy != "default-src 'self'" {
+		t.Errorf("expected the CSP from the environment, got %q", cfg.ContentSecurityPolicy)
+	}
+
+	t.Setenv("HSTS_MAX_AGE", "3600")
+	if cfg := DefaultSecurityHeadersConfig(); cfg.HSTSMaxAge != time.Hour {
+		t.Errorf("expected HSTS_MAX_AGE in seconds, got %v", cfg.HSTSMaxAge)
+	}
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionContextKey is the gin context key holding the request's *Session.
+const SessionContextKey = "web_session"
+
+// SessionConfig configures the session cookie and its lifetime. The cookie
+// only carries the session ID; values live in the cache.
+type SessionConfig struct {
+	CookieName string
+	Domain     string
+	Path       string
+	// Secure should only be disabled for local development over plain HTTP.
+	Secure   bool
+	SameSite http.SameSite
+	// TTL is an idle timeout: every request pushes the expiration back.
+	TTL       time.Duration
+	KeyPrefix string
+}
+
+// DefaultSessionConfig returns secure, SameSite=Lax cookies expiring after
+// 24 hours of inactivity.
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		CookieName: "session_id",
+		Path:       "/",
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+		TTL:        24 * time.Hour,
+		KeyPrefix:  "web_session:",
+	}
+}
+
+// Session is a server-side cookie session. Values are stored as JSON, so
+// read them back with SessionValue to get their original type.
+type Session struct {
+	id     string
+	values map[string]json.RawMessage
+
+	cfg       *SessionConfig
+	c         *gin.Context
+	isNew     bool
+	dirty     bool
+	destroyed bool
+	staleIDs  []string
+}
+
+// Sessions loads the session named by the cookie into the gin context,
+// where handlers get it with GetSession, and saves it after the handler.
+// A session is only stored and sent to the client once a value is set.
+//
+//	r.Use(web.Sessions(redisCache, web.DefaultSessionConfig()))
+func Sessions(store cache.Cache, cfg SessionConfig) gin.HandlerFunc {
+	def := DefaultSessionConfig()
+	if cfg.CookieName == "" {
+		cfg.CookieName = def.CookieName
+	}
+	if cfg.Path == "" {
+		cfg.Path = def.Path
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = def.TTL
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = def.KeyPrefix
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		sess := &Session{cfg: &cfg, c: c, values: map[string]json.RawMessage{}}
+		if id, err := c.Cookie(cfg.CookieName); err == nil && id != "" {
+			if err := sess.load(ctx, store, id); err != nil {
+				logs.Warn(ctx, "[Sessions] failed to load session", "error", err)
+			}
+		}
+		if sess.id == "" {
+			sess.id = newSessionID()
+			sess.isNew = true
+		} else {
+			sess.setCookie()
+		}
+		c.Set(SessionContextKey, sess)
+
+		c.Next()
+
+		if err := sess.save(ctx, store); err != nil {
+			logs.Error(ctx, "[Sessions] failed to save session", "error", err)
+		}
+	}
+}
+
+// GetSession returns the session loaded by the Sessions middleware, or nil
+// when the middleware is not registered.
+func GetSession(c *gin.Context) *Session {
+	v, ok := c.Get(SessionContextKey)
+	if !ok {
+		return nil
+	}
+	sess, _ := v.(*Session)
+	return sess
+}
+
+// SessionValue decodes the value stored under key into T.
+//
+//	userID, ok := web.SessionValue[string](sess, "user_id")
+func SessionValue[T any](s *Session, key string) (T, bool) {
+	var v T
+	raw, ok := s.values[key]
+	if !ok {
+		return v, false
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, false
+	}
+	return v, true
+}
+
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Set stores value, which must be JSON-encodable, under key.
+func (s *Session) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.values[key] = raw
+	s.touch()
+	return nil
+}
+
+func (s *Session) GetString(key string) string {
+	v, _ := SessionValue[string](s, key)
+	return v
+}
+
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.touch()
+	}
+}
+
+// Regenerate moves the session to a new ID, keeping its values. Call it
+// when the privilege level changes, on login in particular, so an ID
+// planted by an attacker before authentication becomes useless.
+func (s *Session) Regenerate() {
+	if !s.isNew {
+		s.staleIDs = append(s.staleIDs, s.id)
+	}
+	s.id = newSessionID()
+	s.isNew = true
+	s.destroyed = false
+	s.dirty = true
+	s.setCookie()
+}
+
+// Destroy removes the session and its cookie, e.g. on logout.
+func (s *Session) Destroy() {
+	if !s.isNew {
+		s.staleIDs = append(s.staleIDs, s.id)
+	}
+	s.values = map[string]json.RawMessage{}
+	s.destroyed = true
+	s.dirty = false
+	s.cookie("", -1)
+}
+
+// touch marks the session for saving and, the first time, sends its cookie
+// while the response headers can still be written.
+func (s *Session) touch() {
+	if s.destroyed {
+		return
+	}
+	if !s.dirty && s.isNew {
+		s.setCookie()
+	}
+	s.dirty = true
+}
+
+func (s *Session) setCookie() {
+	s.cookie(s.id, int(s.cfg.TTL.Seconds()))
+}
+
+func (s *Session) cookie(value string, maxAge int) {
+	http.SetCookie(s.c.Writer, &http.Cookie{
+		Name:     s.cfg.CookieName,
+		Value:    value,
+		Domain:   s.cfg.Domain,
+		Path:     s.cfg.Path,
+		MaxAge:   maxAge,
+		Secure:   s.cfg.Secure,
+		HttpOnly: true,
+		SameSite: s.cfg.SameSite,
+	})
+}
+
+func (s *Session) load(ctx context.Context, store cache.Cache, id string) error {
+	data, err := store.Get(ctx, s.cfg.KeyPrefix+id)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(data), &s.values); err != nil {
+		return err
+	}
+	s.id = id
+	return nil
+}
+
+func (s *Session) save(ctx context.Context, store cache.Cache) error {
+	for _, id := range s.staleIDs {
+		if err := store.Delete(ctx, s.cfg.KeyPrefix+id); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			return err
+		}
+	}
+	switch {
+	case s.destroyed:
+		return nil
+	case s.dirty:
+		data, err := json.Marshal(s.values)
+		if err != nil {
+			return err
+		}
+		return store.Set(ctx, s.cfg.KeyPrefix+s.id, string(data), s.cfg.TTL)
+	case !s.isNew:
+		_, err := store.Expire(ctx, s.cfg.KeyPrefix+s.id, s.cfg.TTL)
+		return err
+	}
+	return nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
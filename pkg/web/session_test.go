@@ -0,0 +1,99 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+type cartItem struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+func newSessionEngine(store cache.Cache) *gin.Engine {
+	e := gin.New()
+	e.Use(Sessions(store, DefaultSessionConfig()))
+	e.GET("/anonymous", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	e.POST("/cart", func(c *gin.Context) {
+		_ = GetSession(c).Set("cart", []cartItem{{SKU: "A1", Qty: 2}})
+		c.Status(http.StatusNoContent)
+	})
+	e.POST("/login", func(c *gin.Context) {
+		sess := GetSession(c)
+		sess.Regenerate()
+		_ = sess.Set("user_id", "user123")
+		c.Status(http.StatusNoContent)
+	})
+	e.GET("/me", func(c *gin.Context) {
+		sess := GetSession(c)
+		cart, _ := SessionValue[[]cartItem](sess, "cart")
+		c.JSON(http.StatusOK, gin.H{"user_id": sess.GetString("user_id"), "items": len(cart)})
+	})
+	e.POST("/logout", func(c *gin.Context) {
+		GetSession(c).Destroy()
+		c.Status(http.StatusNoContent)
+	})
+	return e
+}
+
+func sessionRequest(e *gin.Engine, method, path, sessionID string) (*httptest.ResponseRecorder, string) {
+	req := httptest.NewRequest(method, path, nil)
+	if sessionID != "" {
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	cookie := ""
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session_id" {
+			cookie = c.Value
+		}
+	}
+	return w, cookie
+}
+
+func TestSessions(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+	e := newSessionEngine(store)
+	ctx := context.Background()
+
+	if _, cookie := sessionRequest(e, http.MethodGet, "/anonymous", ""); cookie != "" {
+		t.Errorf("expected no cookie for an empty session, got %q", cookie)
+	}
+
+	_, anonID := sessionRequest(e, http.MethodPost, "/cart", "")
+	if anonID == "" {
+		t.Fatal("expected a session cookie once a value is set")
+	}
+
+	_, userID := sessionRequest(e, http.MethodPost, "/login", anonID)
+	if userID == "" || userID == anonID {
+		t.Fatalf("expected login to regenerate the session ID, got %q", userID)
+	}
+	if exists, _ := store.Exists(ctx, "web_session:"+anonID); exists {
+		t.Error("expected the pre-login session to be deleted")
+	}
+
+	w, _ := sessionRequest(e, http.MethodGet, "/me", userID)
+	if body := w.Body.String(); body != `{"items":1,"user_id":"user123"}` {
+		t.Errorf("expected values to survive login, got %s", body)
+	}
+	if w, _ := sessionRequest(e, http.MethodGet, "/me", anonID); w.Body.String() != `{"items":0,"user_id":""}` {
+		t.Errorf("expected the old ID to be unusable, got %s", w.Body.String())
+	}
+
+	if _, cookie := sessionRequest(e, http.MethodPost, "/logout", userID); cookie != "" {
+		t.Errorf("expected the cookie to be cleared, got %q", cookie)
+	}
+	if exists, _ := store.Exists(ctx, "web_session:"+userID); exists {
+		t.Error("expected the session to be deleted on logout")
+	}
+}
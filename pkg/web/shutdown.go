@@ -0,0 +1,37 @@
+package web
+
+import (
+	"context"
+	"errors"
+)
+
+// ShutdownHook releases a resource during graceful shutdown. It should
+// return once ctx is done.
+type ShutdownHook func(ctx context.Context) error
+
+// OnShutdown registers hook to run during Shutdown, once the servers have
+// stopped accepting requests and in-flight ones have completed, so
+// handlers never see a closed pool. Hooks run in reverse registration
+// order, like defers: register the database before the scheduler that uses
+// it and the scheduler is stopped first. Every hook runs even if earlier
+// ones fail, sharing the ShutdownTimeout context.
+//
+//	app.OnShutdown(func(ctx context.Context) error { return sqlDB.Close() })
+func (app *GinApp) OnShutdown(hook ShutdownHook) {
+	app.shutdownMu.Lock()
+	defer app.shutdownMu.Unlock()
+	app.shutdownHooks = append(app.shutdownHooks, hook)
+}
+
+func (app *GinApp) runShutdownHooks(ctx context.Context) error {
+	app.shutdownMu.Lock()
+	hooks := app.shutdownHooks
+	app.shutdownHooks = nil
+	app.shutdownMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		errs = append(errs, hooks[i](ctx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOnShutdown(t *testing.T) {
+	app := &GinApp{engine: gin.New(), logger: logs.GetLogger()}
+
+	var order []string
+	errCache := errors.New("cache close failed")
+	app.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "db")
+		return nil
+	})
+	app.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "cache")
+		return errCache
+	})
+	app.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "scheduler")
+		return nil
+	})
+
+	err := app.Shutdown(context.Background())
+	if !errors.Is(err, errCache) {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+	if want := []string{"scheduler", "cache", "db"}; !slices.Equal(order, want) {
+		t.Errorf("expected hooks in reverse order %v, got %v", want, order)
+	}
+
+	order = nil
+	if err := app.Shutdown(context.Background()); err != nil || len(order) != 0 {
+		t.Errorf("expected hooks to run once, got %v and %v", order, err)
+	}
+}
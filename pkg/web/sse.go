@@ -0,0 +1,174 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrStreamClosed is returned when writing to a stream whose client has
+// disconnected.
+var ErrStreamClosed = errors.New("web: event stream closed")
+
+const defaultSSEHeartbeat = 15 * time.Second
+
+// SSEEvent is one Server-Sent Event. Data may span several lines; Retry
+// tells the client how long to wait before reconnecting.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// EventStream writes Server-Sent Events to a client.
+type EventStream struct {
+	c         *gin.Context
+	flusher   http.Flusher
+	heartbeat time.Duration
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type SSEOption func(*EventStream)
+
+// SSEHeartbeat sets how often Stream sends a comment line to keep proxies
+// from closing an idle connection. Zero disables heartbeats.
+func SSEHeartbeat(d time.Duration) SSEOption {
+	return func(s *EventStream) {
+		s.heartbeat = d
+	}
+}
+
+// SSE starts a text/event-stream response. Send events one by one, or hand
+// a channel to Stream, which also sends heartbeats:
+//
+//	r.GET("/orders/:id/events", func(c *gin.Context) {
+//		stream := web.SSE(c)
+//		updates := orders.Subscribe(c.Request.Context(), c.Param("id"), stream.LastEventID())
+//		_ = stream.Stream(updates)
+//	})
+func SSE(c *gin.Context, opts ...SSEOption) *EventStream {
+	s := &EventStream{c: c, heartbeat: defaultSSEHeartbeat}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.flusher, _ = c.Writer.(http.Flusher)
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	s.flush()
+	return s
+}
+
+// LastEventID is the ID of the last event the client received before
+// reconnecting, to resume from.
+func (s *EventStream) LastEventID() string {
+	return s.c.GetHeader("Last-Event-ID")
+}
+
+// Done is closed when the client disconnects.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.c.Request.Context().Done()
+}
+
+// Send writes an event and flushes it to the client.
+func (s *EventStream) Send(e SSEEvent) error {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// SendJSON sends v encoded as JSON in an event named event.
+func (s *EventStream) SendJSON(event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(SSEEvent{Event: event, Data: string(data)})
+}
+
+// Stream sends the events received on events, with heartbeats in between,
+// until the channel is closed (nil is returned) or the client disconnects
+// (ErrStreamClosed).
+func (s *EventStream) Stream(events <-chan SSEEvent) error {
+	var tick <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.Done():
+			s.close()
+			return ErrStreamClosed
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := s.Send(e); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := s.write(": heartbeat\n\n"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *EventStream) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.c.Request.Context().Err() != nil {
+		s.closed = true
+		return ErrStreamClosed
+	}
+	if _, err := s.c.Writer.WriteString(data); err != nil {
+		s.closed = true
+		return ErrStreamClosed
+	}
+	s.flush()
+	return nil
+}
+
+func (s *EventStream) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+func (s *EventStream) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSSE(t *testing.T) {
+	e := gin.New()
+	e.GET("/events", func(c *gin.Context) {
+		stream := SSE(c)
+		_ = stream.Send(SSEEvent{ID: "7", Event: "order", Data: "line one\nline two", Retry: 5 * time.Second})
+		_ = stream.SendJSON("status", gin.H{"resume_from": stream.LastEventID()})
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "6")
+	e.ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+	want := "id: 7\nevent: order\nretry: 5000\ndata: line one\ndata: line two\n\n" +
+		"event: status\ndata: {\"resume_from\":\"6\"}\n\n"
+	if w.Body.String() != want {
+		t.Errorf("unexpected stream:\n%s", w.Body.String())
+	}
+}
+
+func TestSSE_StreamHeartbeatAndDisconnect(t *testing.T) {
+	events := make(chan SSEEvent)
+	result := make(chan error, 1)
+	e := gin.New()
+	e.GET("/events", func(c *gin.Context) {
+		result <- SSE(c, SSEHeartbeat(20*time.Millisecond)).Stream(events)
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	events <- SSEEvent{Data: "hello"}
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 4 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if lines[0] != "data: hello" || lines[2] != ": heartbeat" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+
+	cancel()
+	select {
+	case err := <-result:
+		if err != ErrStreamClosed {
+			t.Errorf("expected ErrStreamClosed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Stream to return after the client disconnected")
+	}
+}
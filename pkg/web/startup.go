@@ -0,0 +1,140 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"gorm.io/gorm"
+)
+
+const (
+	startupRetryMin = 500 * time.Millisecond
+	startupRetryMax = 5 * time.Second
+)
+
+// Dependency is a named check WaitFor waits on.
+type Dependency struct {
+	Name  string
+	Check HealthCheck
+}
+
+// WaitFor runs the checks until they all pass, retrying with backoff, and
+// returns an error naming the failing dependencies once ctx is done. Bound
+// it with a timeout:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	err := web.WaitFor(ctx,
+//		web.Dependency{Name: "db", Check: web.DatabaseHealthCheck(db)},
+//		web.Dependency{Name: "cache", Check: web.CacheHealthCheck(c)},
+//	)
+func WaitFor(ctx context.Context, deps ...Dependency) error {
+	checks := make([]namedHealthCheck, len(deps))
+	for i, dep := range deps {
+		checks[i] = namedHealthCheck{name: dep.Name, check: dep.Check}
+	}
+
+	start := time.Now()
+	delay := startupRetryMin
+	for attempt := 1; ; attempt++ {
+		report := runHealthChecks(ctx, checks)
+		if report.Status == "ok" {
+			if attempt > 1 {
+				logs.Info(ctx, "[Startup] dependencies ready", "attempts", attempt, "elapsed_ms", time.Since(start).Milliseconds())
+			}
+			return nil
+		}
+
+		var failing []string
+		for name, result := range report.Checks {
+			if result.Status != "ok" {
+				failing = append(failing, name)
+				logs.Warn(ctx, "[Startup] dependency not ready", "dependency", name, "error", result.Error, "attempt", attempt)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logs.Error(ctx, "[Startup] dependencies not ready", "dependencies", failing, "elapsed_ms", time.Since(start).Milliseconds(), logs.WithNotifier())
+			return fmt.Errorf("dependencies not ready: %s: %w", strings.Join(failing, ", "), ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, startupRetryMax)
+	}
+}
+
+// WaitForDependencies makes Start wait for deps: /health/ready reports
+// "starting" until they are reachable, and the app fails with the error of
+// WaitFor if they are not within GinConfig.StartupTimeout. With
+// GinConfig.WaitBeforeListen, Start itself blocks until then, so no request
+// is accepted before the dependencies are up.
+func (app *GinApp) WaitForDependencies(deps ...Dependency) {
+	app.healthMu.Lock()
+	defer app.healthMu.Unlock()
+	app.startupDeps = append(app.startupDeps, deps...)
+}
+
+func (app *GinApp) startupDependencies() ([]Dependency, time.Duration) {
+	app.healthMu.RLock()
+	defer app.healthMu.RUnlock()
+	timeout := app.ginConfig.StartupTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	return append([]Dependency(nil), app.startupDeps...), timeout
+}
+
+// waitForStartup blocks until the startup dependencies are ready.
+func (app *GinApp) waitForStartup() error {
+	deps, timeout := app.startupDependencies()
+	if len(deps) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return WaitFor(ctx, deps...)
+}
+
+// gateReadiness reports the app as starting until the startup dependencies
+// are ready, and fails it once StartupTimeout is exceeded.
+func (app *GinApp) gateReadiness() {
+	deps, timeout := app.startupDependencies()
+	if len(deps) == 0 {
+		return
+	}
+	app.starting.Store(true)
+	app.Go("startup", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := WaitFor(ctx, deps...); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				app.serveErr <- err
+			}
+			return err
+		}
+		app.starting.Store(false)
+		return nil
+	})
+}
+
+// MigrationsHealthCheck passes once the tables exist, i.e. the migrations
+// creating them have run.
+func MigrationsHealthCheck(db *gorm.DB, tables ...string) HealthCheck {
+	return func(ctx context.Context) error {
+		migrator := db.WithContext(ctx).Migrator()
+		var missing []string
+		for _, table := range tables {
+			if !migrator.HasTable(table) {
+				missing = append(missing, table)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing tables: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWaitFor(t *testing.T) {
+	var attempts atomic.Int32
+	flaky := func(ctx context.Context) error {
+		if attempts.Add(1) < 2 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitFor(ctx, Dependency{Name: "db", Check: flaky}); err != nil {
+		t.Fatalf("expected the dependency to become ready, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := WaitFor(ctx, Dependency{Name: "db", Check: flaky}, Dependency{Name: "cache", Check: down})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "cache") || strings.Contains(err.Error(), "db") {
+		t.Errorf("expected only cache to be reported, got %v", err)
+	}
+}
+
+func TestWaitForDependencies_GatesReadiness(t *testing.T) {
+	var up atomic.Bool
+	app := &GinApp{engine: gin.New(), logger: logs.GetLogger(), serveErr: make(chan error, 1)}
+	app.engine.GET("/health/ready", app.readyHandler)
+	app.WaitForDependencies(Dependency{Name: "db", Check: func(ctx context.Context) error {
+		if !up.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}})
+	app.gateReadiness()
+	defer app.stopWorkers(context.Background())
+
+	ready := func() int {
+		w := httptest.NewRecorder()
+		app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		return w.Code
+	}
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while starting, got %d", code)
+	}
+
+	up.Store(true)
+	deadline := time.Now().Add(3 * time.Second)
+	for ready() != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the app to become ready")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
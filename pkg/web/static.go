@@ -0,0 +1,126 @@
+package web
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaticOptions configures Static and StaticFS.
+type StaticOptions struct {
+	// Index is served for directories, "index.html" by default. It is sent
+	// with Cache-Control: no-cache so new deployments are picked up.
+	Index string
+	// CacheControl applies to every other file. Build tools fingerprint
+	// asset names, so these can usually be cached long and immutable.
+	CacheControl CacheControlPolicy
+	// Precompressed serves name.br or name.gz next to name when the client
+	// accepts it. It has no effect while GinConfig.EnableCompression
+	// already compresses the response.
+	Precompressed bool
+	// SPA serves Index for unknown paths without an extension, so
+	// client-side routes survive a reload. Only requests accepting
+	// text/html fall back; API clients still get a 404.
+	SPA bool
+}
+
+// Static serves the files of a directory under prefix.
+func (app *GinApp) Static(prefix, dir string, opts StaticOptions) {
+	app.StaticFS(prefix, os.DirFS(dir), opts)
+}
+
+// StaticFS serves the files of fsys under prefix, typically an embed.FS
+// holding a frontend build:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, _ := fs.Sub(dist, "dist")
+//	app.StaticFS("/", assets, web.StaticOptions{SPA: true, Precompressed: true,
+//		CacheControl: web.CacheControlPolicy{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true}})
+//
+// At the root prefix the files are served for paths no route matches, so
+// API routes keep precedence.
+func (app *GinApp) StaticFS(prefix string, fsys fs.FS, opts StaticOptions) {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	prefix = "/" + strings.Trim(prefix, "/")
+	if prefix == "/" {
+		app.engine.NoRoute(func(c *gin.Context) {
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				routeNotFound(c)
+				return
+			}
+			serveStatic(c, fsys, c.Request.URL.Path, opts)
+		})
+		return
+	}
+
+	handler := func(c *gin.Context) {
+		serveStatic(c, fsys, c.Param("filepath"), opts)
+	}
+	app.engine.GET(prefix+"/*filepath", handler)
+	app.engine.HEAD(prefix+"/*filepath", handler)
+}
+
+func serveStatic(c *gin.Context, fsys fs.FS, name string, opts StaticOptions) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = opts.Index
+	}
+	if info, err := fs.Stat(fsys, name); err == nil && info.IsDir() {
+		name = path.Join(name, opts.Index)
+	}
+	if _, err := fs.Stat(fsys, name); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) || !opts.SPA || path.Ext(name) != "" ||
+			!strings.Contains(c.GetHeader("Accept"), "text/html") {
+			routeNotFound(c)
+			return
+		}
+		name = opts.Index
+	}
+
+	h := c.Writer.Header()
+	if path.Base(name) == opts.Index {
+		h.Set("Cache-Control", "no-cache")
+	} else if policy := opts.CacheControl.String(); policy != "" {
+		h.Set("Cache-Control", policy)
+	}
+
+	if opts.Precompressed && h.Get("Content-Encoding") == "" {
+		h.Add("Vary", "Accept-Encoding")
+		for _, enc := range []struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+			if !acceptsEncoding(c.Request, enc.name) {
+				continue
+			}
+			if _, err := fs.Stat(fsys, name+enc.ext); err == nil {
+				contentType := mime.TypeByExtension(path.Ext(name))
+				if contentType == "" {
+					contentType = "application/octet-stream"
+				}
+				h.Set("Content-Type", contentType)
+				h.Set("Content-Encoding", enc.name)
+				name += enc.ext
+				break
+			}
+		}
+	}
+	http.ServeFileFS(c.Writer, c.Request, fsys, name)
+}
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), encoding) {
+			return strings.TrimSpace(strings.ReplaceAll(params, " ", "")) != "q=0"
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStaticFS(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html":       {Data: []byte("<html>app</html>")},
+		"assets/app.js":    {Data: []byte("console.log(1)")},
+		"assets/app.js.br": {Data: []byte("brotli")},
+		"assets/app.js.gz": {Data: []byte("gzip")},
+		"assets/style.css": {Data: []byte("body{}")},
+		"docs/index.html":  {Data: []byte("<html>docs</html>")},
+	}
+
+	app := &GinApp{engine: gin.New()}
+	app.engine.NoRoute(routeNotFound)
+	app.engine.GET("/api/orders", func(c *gin.Context) { c.String(http.StatusOK, "orders") })
+	docs, _ := fs.Sub(assets, "docs")
+	app.StaticFS("/docs", docs, StaticOptions{})
+	app.StaticFS("/", assets, StaticOptions{
+		SPA:           true,
+		Precompressed: true,
+		CacheControl:  CacheControlPolicy{Public: true, MaxAge: 24 * time.Hour, Immutable: true},
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		headers  map[string]string
+		code     int
+		body     string
+		encoding string
+		cache    string
+	}{
+		{"route wins", http.MethodGet, "/api/orders", nil, http.StatusOK, "orders", "", ""},
+		{"index", http.MethodGet, "/", nil, http.StatusOK, "<html>app</html>", "", "no-cache"},
+		{"asset", http.MethodGet, "/assets/style.css", nil, http.StatusOK, "body{}", "", "public, max-age=86400, immutable"},
+		{"brotli", http.MethodGet, "/assets/app.js", map[string]string{"Accept-Encoding": "gzip, br"}, http.StatusOK, "brotli", "br", "public, max-age=86400, immutable"},
+		{"gzip", http.MethodGet, "/assets/app.js", map[string]string{"Accept-Encoding": "gzip, br;q=0"}, http.StatusOK, "gzip", "gzip", ""},
+		{"identity", http.MethodGet, "/assets/app.js", nil, http.StatusOK, "console.log(1)", "", ""},
+		{"spa fallback", http.MethodGet, "/orders/42", map[string]string{"Accept": "text/html,*/*"}, http.StatusOK, "<html>app</html>", "", "no-cache"},
+		{"no fallback for api clients", http.MethodGet, "/orders/42", map[string]string{"Accept": "application/json"}, http.StatusNotFound, "", "", ""},
+		{"no fallback for missing assets", http.MethodGet, "/assets/missing.js", map[string]string{"Accept": "text/html"}, http.StatusNotFound, "", "", ""},
+		{"no fallback for writes", http.MethodPost, "/orders/42", map[string]string{"Accept": "text/html"}, http.StatusNotFound, "", "", ""},
+		{"prefixed directory index", http.MethodGet, "/docs/", nil, http.StatusOK, "<html>docs</html>", "", ""},
+		{"prefixed missing file", http.MethodGet, "/docs/missing.html", nil, http.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		app.engine.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.code, w.Code)
+			continue
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.body, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("%s: expected encoding %q, got %q", tt.name, tt.encoding, got)
+		}
+		if tt.cache != "" && w.Header().Get("Cache-Control") != tt.cache {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.name, tt.cache, w.Header().Get("Cache-Control"))
+		}
+		if tt.encoding != "" && w.Header().Get("Content-Type") != "text/javascript; charset=utf-8" {
+			t.Errorf("%s: unexpected content type %q", tt.name, w.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -3,16 +3,22 @@ package web
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/fsandov/go-sdk/pkg/config"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (app *GinApp) setupTelemetry() error {
@@ -37,6 +43,10 @@ func (app *GinApp) setupTelemetry() error {
 		app.tracer = tp
 
 		otel.SetTracerProvider(tp)
+	}
+	if app.ginConfig.EnableTracing {
+		// Without an exporter spans are not recorded, but incoming
+		// traceparent headers are still honored and propagated.
 		otel.SetTextMapPropagator(
 			propagation.NewCompositeTextMapPropagator(
 				propagation.TraceContext{},
@@ -111,3 +121,38 @@ func (app *GinApp) ShutdownTelemetry(ctx context.Context) error {
 
 	return nil
 }
+
+// tracingMiddleware starts a server span per request, continuing the trace
+// of an incoming traceparent header. Operational endpoints are not traced.
+func tracingMiddleware(service string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		otelgin.Middleware(service, otelgin.WithFilter(func(r *http.Request) bool {
+			return !isOpsPath(r.URL.Path)
+		})),
+		spanDetailsMiddleware,
+	}
+}
+
+// spanDetailsMiddleware names the server span "METHOD /route/:param", as the
+// semantic conventions recommend, and records the handler errors on it.
+func spanDetailsMiddleware(c *gin.Context) {
+	c.Next()
+
+	span := trace.SpanFromContext(c.Request.Context())
+	if !span.IsRecording() {
+		return
+	}
+	if route := c.FullPath(); route != "" {
+		span.SetName(c.Request.Method + " " + route)
+	}
+	for _, err := range c.Errors {
+		span.RecordError(err.Err)
+	}
+	if c.Writer.Status() >= http.StatusInternalServerError && len(c.Errors) > 0 {
+		span.SetStatus(codes.Error, c.Errors.Last().Error())
+	}
+}
+
+func isOpsPath(path string) bool {
+	return path == "/metrics" || path == "/health" || strings.HasPrefix(path, "/health/") || strings.HasPrefix(path, "/debug/pprof")
+}
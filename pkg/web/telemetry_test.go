@@ -2,10 +2,20 @@ package web
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/fsandov/go-sdk/pkg/config"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func init() {
@@ -38,3 +48,45 @@ func TestSetupTelemetryDoesNotAutoShutdown(t *testing.T) {
 		t.Fatalf("ShutdownTelemetry failed (provider was likely already shut down): %v", err)
 	}
 }
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	}()
+
+	e := gin.New()
+	e.Use(tracingMiddleware("test-app")...)
+	e.GET("/orders/:id", func(c *gin.Context) {
+		_ = c.Error(errors.New("db unavailable"))
+		c.Status(http.StatusInternalServerError)
+	})
+	e.GET("/health/live", liveHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, health excluded, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /orders/:id" {
+		t.Errorf("unexpected span name %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer || span.Status().Code != codes.Error {
+		t.Errorf("unexpected kind %v or status %v", span.SpanKind(), span.Status())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Error("expected the span to continue the incoming trace")
+	}
+	if len(span.Events()) != 1 || span.Events()[0].Name != "exception" {
+		t.Errorf("expected the handler error to be recorded, got %v", span.Events())
+	}
+}
@@ -0,0 +1,163 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/fsandov/go-sdk/pkg/env"
+	"github.com/gin-gonic/gin/render"
+)
+
+// TemplateConfig configures LoadTemplates. Every page is parsed together
+// with all layouts and partials, so pages can fill the blocks of a layout
+// without clashing with each other:
+//
+//	{{/* layouts/base.html */}}
+//	<html><body>{{template "nav.html" .}}{{block "content" .}}{{end}}</body></html>
+//
+//	{{/* pages/users.html */}}
+//	{{define "content"}}<ul>{{range .Users}}<li>{{.Name}}</li>{{end}}</ul>{{end}}
+//
+// Pages are rendered by their path relative to the root, e.g.
+// c.HTML(http.StatusOK, "pages/users.html", data); layouts and partials are
+// referenced by file name.
+type TemplateConfig struct {
+	// FS holds the templates, e.g. an embed.FS; Root on disk is used when
+	// it is nil.
+	FS   fs.FS
+	Root string
+	// Layouts, Partials and Pages are glob patterns relative to the root.
+	Layouts  []string
+	Partials []string
+	Pages    []string
+	// Layout is the layout file executed for every page that can see it;
+	// pages are executed on their own when it is empty.
+	Layout string
+	Funcs  template.FuncMap
+	// Reload parses the templates again on every render, so edits show up
+	// without a restart. Meant for local development.
+	Reload bool
+}
+
+// DefaultTemplateConfig reads templates/layouts, templates/partials and
+// templates/pages from disk, wrapping pages in base.html, and reloads them
+// in local environments.
+func DefaultTemplateConfig() TemplateConfig {
+	return TemplateConfig{
+		Root:     "templates",
+		Layouts:  []string{"layouts/*.html"},
+		Partials: []string{"partials/*.html"},
+		Pages:    []string{"pages/*.html", "pages/*/*.html"},
+		Layout:   "base.html",
+		Reload:   !env.IsRemote(),
+	}
+}
+
+// LoadTemplates parses the templates and makes them the engine's HTML
+// renderer, used by c.HTML.
+func (app *GinApp) LoadTemplates(cfg TemplateConfig) error {
+	r := &templateRenderer{cfg: cfg, fsys: cfg.FS}
+	if r.fsys == nil {
+		r.fsys = os.DirFS(cfg.Root)
+	}
+	if err := r.load(); err != nil {
+		return err
+	}
+	app.engine.HTMLRender = r
+	return nil
+}
+
+type templateRenderer struct {
+	cfg  TemplateConfig
+	fsys fs.FS
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+func (r *templateRenderer) load() error {
+	base := template.New("").Funcs(r.cfg.Funcs)
+	shared, err := r.glob(append(append([]string{}, r.cfg.Layouts...), r.cfg.Partials...))
+	if err != nil {
+		return err
+	}
+	if len(shared) > 0 {
+		if base, err = base.ParseFS(r.fsys, shared...); err != nil {
+			return fmt.Errorf("web: failed to parse layouts: %w", err)
+		}
+	}
+
+	names, err := r.glob(r.cfg.Pages)
+	if err != nil {
+		return err
+	}
+	pages := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		src, err := fs.ReadFile(r.fsys, name)
+		if err != nil {
+			return fmt.Errorf("web: failed to read template %s: %w", name, err)
+		}
+		page, err := base.Clone()
+		if err == nil {
+			_, err = page.New(name).Parse(string(src))
+		}
+		if err != nil {
+			return fmt.Errorf("web: failed to parse template %s: %w", name, err)
+		}
+		pages[name] = page
+	}
+
+	r.mu.Lock()
+	r.pages = pages
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *templateRenderer) glob(patterns []string) ([]string, error) {
+	var names []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(r.fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("web: invalid template pattern %q: %w", pattern, err)
+		}
+		names = append(names, matches...)
+	}
+	return names, nil
+}
+
+// Instance implements render.HTMLRender.
+func (r *templateRenderer) Instance(name string, data any) render.Render {
+	if r.cfg.Reload {
+		if err := r.load(); err != nil {
+			return templateError{err}
+		}
+	}
+	r.mu.RLock()
+	page := r.pages[name]
+	r.mu.RUnlock()
+	if page == nil {
+		return templateError{fmt.Errorf("web: template %q not found", name)}
+	}
+
+	exec := name
+	if r.cfg.Layout != "" && page.Lookup(r.cfg.Layout) != nil {
+		exec = r.cfg.Layout
+	}
+	return render.HTML{Template: page, Name: exec, Data: data}
+}
+
+// templateError fails the render, leaving the response to the error
+// handling middlewares.
+type templateError struct {
+	err error
+}
+
+func (e templateError) Render(http.ResponseWriter) error {
+	return e.err
+}
+
+func (e templateError) WriteContentType(http.ResponseWriter) {}
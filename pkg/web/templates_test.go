@@ -0,0 +1,85 @@
+package web
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	files := fstest.MapFS{
+		"layouts/base.html":    {Data: []byte(`<title>{{block "title" .}}Admin{{end}}</title>{{template "nav.html" .}}{{block "content" .}}{{end}}`)},
+		"partials/nav.html":    {Data: []byte(`<nav>{{.User | upper}}</nav>`)},
+		"pages/users.html":     {Data: []byte(`{{define "title"}}Users{{end}}{{define "content"}}<ul>{{range .Users}}<li>{{.}}</li>{{end}}</ul>{{end}}`)},
+		"pages/dashboard.html": {Data: []byte(`{{define "content"}}<p>{{.User}}</p>{{end}}`)},
+	}
+	cfg := DefaultTemplateConfig()
+	cfg.FS = files
+	cfg.Reload = false
+	cfg.Funcs = template.FuncMap{"upper": strings.ToUpper}
+
+	app := &GinApp{engine: gin.New()}
+	if err := app.LoadTemplates(cfg); err != nil {
+		t.Fatal(err)
+	}
+	app.engine.GET("/:page", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "pages/"+c.Param("page")+".html", gin.H{"User": "ana", "Users": []string{"<b>ana</b>", "bob"}})
+	})
+
+	tests := map[string]string{
+		"/users":     "<title>Users</title><nav>ANA</nav><ul><li>&lt;b&gt;ana&lt;/b&gt;</li><li>bob</li></ul>",
+		"/dashboard": "<title>Admin</title><nav>ANA</nav><p>ana</p>",
+	}
+	for path, want := range tests {
+		w := httptest.NewRecorder()
+		app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("%s: expected %q, got %d %q", path, want, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Body.Len() != 0 {
+		t.Errorf("expected no body for a missing template, got %q", w.Body.String())
+	}
+}
+
+func TestLoadTemplates_Reload(t *testing.T) {
+	root := t.TempDir()
+	page := filepath.Join(root, "pages", "home.html")
+	if err := os.MkdirAll(filepath.Dir(page), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(page, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &GinApp{engine: gin.New()}
+	if err := app.LoadTemplates(TemplateConfig{Root: root, Pages: []string{"pages/*.html"}, Reload: true}); err != nil {
+		t.Fatal(err)
+	}
+	app.engine.GET("/", func(c *gin.Context) { c.HTML(http.StatusOK, "pages/home.html", nil) })
+
+	render := func() string {
+		w := httptest.NewRecorder()
+		app.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Body.String()
+	}
+	if got := render(); got != "v1" {
+		t.Fatalf("expected v1, got %q", got)
+	}
+	if err := os.WriteFile(page, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := render(); got != "v2" {
+		t.Errorf("expected the edited template, got %q", got)
+	}
+}
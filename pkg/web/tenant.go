@@ -0,0 +1,130 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/fsandov/go-sdk/pkg/database"
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/fsandov/go-sdk/pkg/tokens"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantContextKey is the gin context key holding the resolved tenant ID.
+const TenantContextKey = "tenant_id"
+
+// TenantConfig configures TenantMiddleware. Each source is optional; the
+// ones configured must agree, so a client authenticated for one tenant
+// cannot reach another by changing the header or the host.
+type TenantConfig struct {
+	// Claim is read from the token claims set by the pkg/tokens auth
+	// middlewares, which must run first.
+	Claim  string
+	Header string
+	// BaseDomain resolves tenants from subdomains: acme.example.com is
+	// tenant acme when BaseDomain is example.com. www is ignored.
+	BaseDomain string
+	// Required rejects requests without a tenant with a 400.
+	Required bool
+	// Validate rejects tenants that do not exist, e.g. by looking them up in
+	// a registry, with a 404.
+	Validate func(ctx context.Context, tenantID string) bool
+}
+
+// DefaultTenantConfig reads the tenant claim, the X-Tenant-ID header and
+// the subdomains of TENANT_BASE_DOMAIN, and requires a tenant.
+func DefaultTenantConfig() TenantConfig {
+	return TenantConfig{
+		Claim:      "tenant",
+		Header:     "X-Tenant-ID",
+		BaseDomain: os.Getenv("TENANT_BASE_DOMAIN"),
+		Required:   true,
+	}
+}
+
+// TenantMiddleware resolves the tenant of the request and stores it under
+// TenantContextKey and in the request context through database.WithTenant,
+// so database.TenantDBs picks the tenant's connection pool:
+//
+//	api := r.Group("/api", tokens.AuthMiddleware(svc), web.TenantMiddleware(web.DefaultTenantConfig()))
+//	db, err := dbs.DB(c.Request.Context())
+//
+// Failures are answered with a problem+json.
+func TenantMiddleware(cfg TenantConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var tenantID string
+		for _, candidate := range []string{cfg.fromClaim(c), cfg.fromHeader(c), cfg.fromHost(c.Request.Host)} {
+			if candidate == "" {
+				continue
+			}
+			if tenantID == "" {
+				tenantID = candidate
+			} else if candidate != tenantID {
+				logs.Warn(ctx, "[TenantMiddleware] conflicting tenants", "tenant_id", tenantID, "requested", candidate)
+				AbortWithProblem(c, http.StatusForbidden, "tenant_mismatch", "the requested tenant does not match the credentials")
+				return
+			}
+		}
+
+		if tenantID == "" {
+			if cfg.Required {
+				AbortWithProblem(c, http.StatusBadRequest, "tenant_required", "the request does not identify a tenant")
+				return
+			}
+			c.Next()
+			return
+		}
+		if cfg.Validate != nil && !cfg.Validate(ctx, tenantID) {
+			AbortWithProblem(c, http.StatusNotFound, "tenant_not_found", "unknown tenant")
+			return
+		}
+
+		c.Set(TenantContextKey, tenantID)
+		c.Request = c.Request.WithContext(database.WithTenant(ctx, tenantID))
+		c.Next()
+	}
+}
+
+// TenantID returns the tenant resolved by TenantMiddleware.
+func TenantID(c *gin.Context) string {
+	return c.GetString(TenantContextKey)
+}
+
+func (cfg TenantConfig) fromClaim(c *gin.Context) string {
+	if cfg.Claim == "" {
+		return ""
+	}
+	claims, _ := c.Get(tokens.KeyClaims)
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	tenantID, _ := tokens.GetStringClaim(mapClaims, cfg.Claim)
+	return tenantID
+}
+
+func (cfg TenantConfig) fromHeader(c *gin.Context) string {
+	if cfg.Header == "" {
+		return ""
+	}
+	return strings.TrimSpace(c.GetHeader(cfg.Header))
+}
+
+func (cfg TenantConfig) fromHost(host string) string {
+	if cfg.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	sub, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(cfg.BaseDomain))
+	if !ok || sub == "www" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
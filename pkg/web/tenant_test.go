@@ -0,0 +1,64 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fsandov/go-sdk/pkg/database"
+	"github.com/fsandov/go-sdk/pkg/tokens"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	cfg := DefaultTenantConfig()
+	cfg.BaseDomain = "example.com"
+	cfg.Validate = func(ctx context.Context, tenantID string) bool { return tenantID != "ghost" }
+
+	e := gin.New()
+	e.Use(func(c *gin.Context) {
+		if tenant := c.GetHeader("Test-Claim"); tenant != "" {
+			c.Set(tokens.KeyClaims, jwt.MapClaims{"tenant": tenant})
+		}
+		c.Next()
+	})
+	e.Use(TenantMiddleware(cfg))
+	e.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, TenantID(c)+"|"+database.TenantFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name    string
+		host    string
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{"subdomain", "acme.example.com:8080", nil, http.StatusOK, "acme|acme"},
+		{"header", "api.internal", map[string]string{"X-Tenant-ID": "acme"}, http.StatusOK, "acme|acme"},
+		{"claim", "example.com", map[string]string{"Test-Claim": "acme"}, http.StatusOK, "acme|acme"},
+		{"agreeing sources", "acme.example.com", map[string]string{"Test-Claim": "acme", "X-Tenant-ID": "acme"}, http.StatusOK, "acme|acme"},
+		{"header conflicts with claim", "example.com", map[string]string{"Test-Claim": "acme", "X-Tenant-ID": "globex"}, http.StatusForbidden, ""},
+		{"host conflicts with claim", "globex.example.com", map[string]string{"Test-Claim": "acme"}, http.StatusForbidden, ""},
+		{"www is not a tenant", "www.example.com", nil, http.StatusBadRequest, ""},
+		{"missing", "example.com", nil, http.StatusBadRequest, ""},
+		{"unknown", "ghost.example.com", nil, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = tt.host
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.code, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.body, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware gives the handlers of a route group d to complete, which
+// should be shorter than GinConfig.WriteTimeout. When d elapses the request
+// context is cancelled and a 504 problem+json is sent right away; whatever
+// the handler writes afterwards is discarded.
+//
+//	reports := r.Group("/reports", web.TimeoutMiddleware(3*time.Second))
+func TimeoutMiddleware(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		path := c.Request.URL.Path
+		tw := newTimeoutWriter(c.Writer)
+		fired := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			defer close(fired)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				tw.timeout(path)
+			}
+		})
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = tw
+		c.Next()
+
+		if !stop() {
+			<-fired
+		}
+		tw.finish()
+		c.Writer = tw.ResponseWriter
+		if tw.timedOut {
+			logs.Warn(ctx, "[TimeoutMiddleware] handler deadline exceeded",
+				"path", c.FullPath(),
+				"timeout", d.String(),
+			)
+			c.Abort()
+		}
+	}
+}
+
+// timeoutWriter serializes the handler's writes with the timeout response.
+// The handler gets its own header map, copied to the real one when the
+// response starts, so the timeout response can be written concurrently.
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu          sync.Mutex
+	header      http.Header
+	wroteHeader bool
+	timedOut    bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{ResponseWriter: w, header: w.Header().Clone()}
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) WriteHeaderNow() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut {
+		tw.startResponse()
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.startResponse()
+	return tw.ResponseWriter.Write(b)
+}
+
+func (tw *timeoutWriter) WriteString(s string) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.startResponse()
+	return tw.ResponseWriter.WriteString(s)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut {
+		tw.startResponse()
+		tw.ResponseWriter.Flush()
+	}
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.ResponseWriter.Status()
+}
+
+func (tw *timeoutWriter) Written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.wroteHeader || tw.timedOut
+}
+
+// startResponse copies the handler's headers and sends the status line.
+// Callers hold mu.
+func (tw *timeoutWriter) startResponse() {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	dst := tw.ResponseWriter.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	maps.Copy(dst, tw.header)
+	tw.ResponseWriter.WriteHeaderNow()
+}
+
+// finish hands the headers of a handler that set a status, or nothing,
+// without writing a body to the real writer, which sends them with the
+// status when the request completes.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader || tw.timedOut {
+		return
+	}
+	tw.wroteHeader = true
+	dst := tw.ResponseWriter.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	maps.Copy(dst, tw.header)
+}
+
+// timeout sends the 504, unless the handler has already started responding.
+func (tw *timeoutWriter) timeout(path string) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return
+	}
+	tw.timedOut = true
+
+	p := NewProblem(http.StatusGatewayTimeout, "timeout", "the request took too long to process")
+	p.Instance = path
+	body, _ := json.Marshal(p)
+	tw.ResponseWriter.Header().Set("Content-Type", ProblemContentType)
+	tw.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = tw.ResponseWriter.Write(body)
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	e := gin.New()
+	g := e.Group("/", TimeoutMiddleware(50*time.Millisecond))
+	g.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Handler", "fast")
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+	g.DELETE("/no-body", func(c *gin.Context) {
+		c.Header("X-Deleted", "1")
+		c.Status(http.StatusNoContent)
+	})
+	g.GET("/cooperative", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(time.Second):
+			t.Error("expected the request context to be cancelled")
+		}
+	})
+	g.GET("/stubborn", func(c *gin.Context) {
+		time.Sleep(150 * time.Millisecond)
+		c.Header("X-Handler", "stubborn")
+		c.JSON(http.StatusOK, gin.H{"late": true})
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := serve("/fast")
+	if w.Code != http.StatusCreated || w.Header().Get("X-Handler") != "fast" {
+		t.Errorf("expected the handler response, got %d %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/no-body", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("X-Deleted") != "1" {
+		t.Errorf("expected the status and headers of a handler without body, got %d %v", w.Code, w.Header())
+	}
+
+	for _, path := range []string{"/cooperative", "/stubborn"} {
+		w := serve(path)
+		if w.Code != http.StatusGatewayTimeout {
+			t.Fatalf("%s: expected 504, got %d", path, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+			t.Errorf("%s: expected problem+json, got %q", path, ct)
+		}
+		if w.Header().Get("X-Handler") != "" {
+			t.Errorf("%s: expected late headers to be dropped", path)
+		}
+		var p Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || p.Code != "timeout" {
+			t.Errorf("%s: unexpected body %q", path, w.Body.String())
+		}
+	}
+}
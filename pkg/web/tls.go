@@ -0,0 +1,140 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig enables HTTPS on the server, from certificate files, a custom
+// tls.Config or Let's Encrypt certificates obtained on demand.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// Config is used as is when set; CertFile and KeyFile, if any, are
+	// added to it.
+	Config *tls.Config
+
+	// AutocertDomains turns on ACME (Let's Encrypt) for these host names.
+	// The http-01 challenge is answered on the redirect listener, so
+	// RedirectPort must be reachable on port 80 from the internet.
+	AutocertDomains []string
+	AutocertEmail   string
+	// AutocertCache stores certificates and the account key, so they
+	// survive restarts and are shared between replicas. Required with
+	// autocert: Let's Encrypt rate-limits issuance.
+	AutocertCache cache.Cache
+	// AutocertDirectoryURL overrides the ACME directory, e.g. the Let's
+	// Encrypt staging environment.
+	AutocertDirectoryURL string
+
+	// RedirectPort, when set, serves plain HTTP on this port, redirecting
+	// every request to HTTPS.
+	RedirectPort string
+}
+
+func (cfg *TLSConfig) enabled() bool {
+	return cfg != nil && (cfg.CertFile != "" || cfg.Config != nil || len(cfg.AutocertDomains) > 0)
+}
+
+// serverTLS builds the tls.Config of the main server, and the handler of
+// the redirect listener, which also answers ACME challenges.
+func (cfg *TLSConfig) serverTLS(httpsPort string) (*tls.Config, http.Handler, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.Config != nil {
+		tlsCfg = cfg.Config.Clone()
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsCfg.Certificates = append(tlsCfg.Certificates, cert)
+	}
+
+	var redirect http.Handler = httpsRedirect(httpsPort)
+	if len(cfg.AutocertDomains) > 0 {
+		if cfg.AutocertCache == nil {
+			return nil, nil, errors.New("web: AutocertCache is required with AutocertDomains")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      AutocertCache(cfg.AutocertCache),
+			Email:      cfg.AutocertEmail,
+		}
+		if cfg.AutocertDirectoryURL != "" {
+			m.Client = &acme.Client{DirectoryURL: cfg.AutocertDirectoryURL}
+		}
+		tlsCfg.GetCertificate = m.GetCertificate
+		for _, proto := range []string{"h2", "http/1.1", acme.ALPNProto} {
+			if !slices.Contains(tlsCfg.NextProtos, proto) {
+				tlsCfg.NextProtos = append(tlsCfg.NextProtos, proto)
+			}
+		}
+		redirect = m.HTTPHandler(redirect)
+	}
+	return tlsCfg, redirect, nil
+}
+
+// httpsRedirect permanently redirects to the same URL over HTTPS.
+func httpsRedirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
+
+// AutocertCache stores ACME certificates and keys in c.
+func AutocertCache(c cache.Cache) autocert.Cache {
+	return autocertCache{cache: c}
+}
+
+type autocertCache struct {
+	cache cache.Cache
+}
+
+func (a autocertCache) Get(ctx context.Context, name string) ([]byte, error) {
+	data, err := a.cache.Get(ctx, autocertKey(name))
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, autocert.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+func (a autocertCache) Put(ctx context.Context, name string, data []byte) error {
+	return a.cache.Set(ctx, autocertKey(name), string(data), 0)
+}
+
+func (a autocertCache) Delete(ctx context.Context, name string) error {
+	err := a.cache.Delete(ctx, autocertKey(name))
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func autocertKey(name string) string {
+	return "autocert:" + strings.ToLower(name)
+}
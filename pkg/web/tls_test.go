@@ -0,0 +1,132 @@
+package web
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/cache"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		method, target, port string
+		status               int
+		location             string
+	}{
+		{http.MethodGet, "http://example.com/a?b=c", "443", http.StatusMovedPermanently, "https://example.com/a?b=c"},
+		{http.MethodGet, "http://example.com:80/a", "8443", http.StatusMovedPermanently, "https://example.com:8443/a"},
+		{http.MethodPost, "http://example.com/orders", "443", http.StatusPermanentRedirect, "https://example.com/orders"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		httpsRedirect(tt.port).ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: expected %d %s, got %d %s", tt.method, tt.target, tt.status, tt.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestAutocertCache(t *testing.T) {
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	ac := AutocertCache(c)
+	ctx := context.Background()
+
+	if _, err := ac.Get(ctx, "example.com"); !errors.Is(err, autocert.ErrCacheMiss) {
+		t.Errorf("expected ErrCacheMiss, got %v", err)
+	}
+	if err := ac.Put(ctx, "example.com", []byte("cert")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ac.Get(ctx, "example.com"); err != nil || string(data) != "cert" {
+		t.Errorf("expected stored cert, got %q %v", data, err)
+	}
+	if err := ac.Delete(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.Delete(ctx, "example.com"); err != nil {
+		t.Errorf("expected deleting a missing entry to succeed, got %v", err)
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	tlsCfg, redirect, err := (&TLSConfig{CertFile: certFile, KeyFile: keyFile}).serverTLS("443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tlsCfg.Certificates) != 1 || redirect == nil {
+		t.Errorf("expected the certificate to be loaded, got %d", len(tlsCfg.Certificates))
+	}
+
+	if _, _, err := (&TLSConfig{AutocertDomains: []string{"example.com"}}).serverTLS("443"); err == nil {
+		t.Error("expected an error without AutocertCache")
+	}
+
+	c := cache.NewMemoryCache()
+	defer c.Close()
+	tlsCfg, _, err = (&TLSConfig{AutocertDomains: []string{"example.com"}, AutocertCache: c}).serverTLS("443")
+	if err != nil || tlsCfg.GetCertificate == nil {
+		t.Errorf("expected autocert to provide certificates, got %v", err)
+	}
+
+	custom := &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	tlsCfg, _, err = (&TLSConfig{Config: custom, AutocertDomains: []string{"example.com"}, AutocertCache: c}).serverTLS("443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"h2", "http/1.1", acme.ALPNProto}; !slices.Equal(tlsCfg.NextProtos, want) {
+		t.Errorf("expected NextProtos %v, got %v", want, tlsCfg.NextProtos)
+	}
+}
+
+func writeTestCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionContextKey is the gin context key holding the version of the
+// route group serving the request.
+const APIVersionContextKey = "api_version"
+
+// VersionStrategy selects how clients ask for an API version.
+type VersionStrategy string
+
+const (
+	// VersionByPath serves each version under its own prefix, /v1/orders.
+	VersionByPath VersionStrategy = "path"
+	// VersionByHeader reads the version from VersioningConfig.Header.
+	VersionByHeader VersionStrategy = "header"
+	// VersionByAccept reads it from the Accept header, either as a vendor
+	// media type, application/vnd.acme.v2+json, or as a version parameter,
+	// application/json; version=v2.
+	VersionByAccept VersionStrategy = "accept"
+)
+
+// VersioningConfig configures the routing of app.Version groups. With the
+// header and Accept strategies, unprefixed paths are routed to the
+// requested version, or to Default when none is requested; prefixed paths
+// keep working, which helps clients migrating between strategies.
+type VersioningConfig struct {
+	Strategy VersionStrategy
+	// Header defaults to X-API-Version.
+	Header  string
+	Default string
+}
+
+// VersionOption configures a version group.
+type VersionOption func(*versionPolicy)
+
+type versionPolicy struct {
+	deprecated time.Time
+	sunset     time.Time
+	link       string
+}
+
+// DeprecatedSince marks the version as deprecated since t, announced with
+// the Deprecation header.
+func DeprecatedSince(t time.Time) VersionOption {
+	return func(p *versionPolicy) {
+		p.deprecated = t
+	}
+}
+
+// SunsetAt announces with the Sunset header when the version will be
+// removed.
+func SunsetAt(t time.Time) VersionOption {
+	return func(p *versionPolicy) {
+		p.sunset = t
+	}
+}
+
+// DeprecationLink points clients to the migration guide.
+func DeprecationLink(url string) VersionOption {
+	return func(p *versionPolicy) {
+		p.link = url
+	}
+}
+
+// Version returns the route group of an API version, mounted at /<version>:
+//
+//	v1 := app.Version("v1", web.DeprecatedSince(deprecatedAt), web.SunsetAt(sunsetAt))
+//	v1.GET("/orders", listOrdersV1)
+//	v2 := app.Version("v2")
+//	v2.GET("/orders", listOrders)
+//
+// Responses carry X-API-Version, plus the deprecation headers for retiring
+// versions.
+func (app *GinApp) Version(version string, opts ...VersionOption) *gin.RouterGroup {
+	var policy versionPolicy
+	for _, opt := range opts {
+		opt(&policy)
+	}
+
+	app.versionsMu.Lock()
+	if app.versions == nil {
+		app.versions = map[string]bool{}
+	}
+	app.versions[version] = true
+	app.versionsMu.Unlock()
+
+	var deprecation, sunset string
+	if !policy.deprecated.IsZero() {
+		deprecation = "@" + strconv.FormatInt(policy.deprecated.Unix(), 10)
+	}
+	if !policy.sunset.IsZero() {
+		sunset = policy.sunset.UTC().Format(http.TimeFormat)
+	}
+	return app.engine.Group("/"+version, func(c *gin.Context) {
+		c.Set(APIVersionContextKey, version)
+		c.Header("X-API-Version", version)
+		if deprecation != "" {
+			c.Header("Deprecation", deprecation)
+		}
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+		if policy.link != "" {
+			c.Writer.Header().Add("Link", "<"+policy.link+`>; rel="deprecation"`)
+		}
+		c.Next()
+	})
+}
+
+// handler returns the engine, behind the version router when versions are
+// negotiated through headers. Only routes registered by then are versioned;
+// paths without a route in the version, such as /health, are left alone.
+func (app *GinApp) handler() http.Handler {
+	cfg := app.ginConfig.Versioning
+	if cfg.Strategy != VersionByHeader && cfg.Strategy != VersionByAccept {
+		return app.engine
+	}
+	if cfg.Header == "" {
+		cfg.Header = "X-API-Version"
+	}
+	vary := cfg.Header
+	if cfg.Strategy == VersionByAccept {
+		vary = "Accept"
+	}
+	var routes []string
+	for _, route := range app.engine.Routes() {
+		routes = append(routes, route.Path)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", vary)
+		var requested string
+		if cfg.Strategy == VersionByHeader {
+			requested = r.Header.Get(cfg.Header)
+		} else {
+			requested = acceptVersion(r.Header.Get("Accept"))
+		}
+
+		version, ok := app.lookupVersion(requested)
+		if requested == "" {
+			version, ok = app.lookupVersion(cfg.Default)
+		}
+		if ok && !strings.HasPrefix(r.URL.Path+"/", "/"+version+"/") {
+			if path := "/" + version + r.URL.Path; slices.ContainsFunc(routes, func(route string) bool {
+				return matchRoute(route, path)
+			}) {
+				r.URL.Path = path
+				r.URL.RawPath = ""
+			}
+		}
+		app.engine.ServeHTTP(w, r)
+	})
+}
+
+// lookupVersion finds a registered version, accepting "2" for "v2".
+func (app *GinApp) lookupVersion(version string) (string, bool) {
+	if version == "" {
+		return "", false
+	}
+	app.versionsMu.RLock()
+	defer app.versionsMu.RUnlock()
+	if app.versions[version] {
+		return version, true
+	}
+	if app.versions["v"+version] {
+		return "v" + version, true
+	}
+	return "", false
+}
+
+var vendorVersion = regexp.MustCompile(`^application/vnd\.[^+]+\.(v[0-9][0-9.]*)\+`)
+
+func acceptVersion(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		if v := params["version"]; v != "" {
+			return v
+		}
+		if m := vendorVersion.FindStringSubmatch(mediaType); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// matchRoute reports whether path matches a gin route pattern.
+func matchRoute(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) || (!strings.HasPrefix(part, ":") && part != pathParts[i]) {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
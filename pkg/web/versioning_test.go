@@ -0,0 +1,95 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newVersionedApp(cfg VersioningConfig) *GinApp {
+	app := &GinApp{engine: gin.New(), ginConfig: GinConfig{Versioning: cfg}}
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	v1 := app.Version("v1", DeprecatedSince(time.Unix(1700000000, 0)), SunsetAt(sunset), DeprecationLink("https://docs.example.com/v2"))
+	v1.GET("/orders/:id", func(c *gin.Context) { c.String(http.StatusOK, "v1 "+c.Param("id")) })
+	v2 := app.Version("v2")
+	v2.GET("/orders/:id", func(c *gin.Context) { c.String(http.StatusOK, "v2 "+c.Param("id")) })
+	app.engine.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return app
+}
+
+func serveVersioned(h http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestVersion_Path(t *testing.T) {
+	h := newVersionedApp(VersioningConfig{}).handler()
+
+	w := serveVersioned(h, "/v1/orders/7", nil)
+	if w.Body.String() != "v1 7" || w.Header().Get("X-API-Version") != "v1" {
+		t.Fatalf("unexpected response %q", w.Body.String())
+	}
+	if w.Header().Get("Deprecation") != "@1700000000" || w.Header().Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("unexpected deprecation headers %v", w.Header())
+	}
+	if w.Header().Get("Link") != `<https://docs.example.com/v2>; rel="deprecation"` {
+		t.Errorf("unexpected Link %q", w.Header().Get("Link"))
+	}
+	if w := serveVersioned(h, "/v2/orders/7", nil); w.Header().Get("Deprecation") != "" {
+		t.Error("expected no deprecation headers on v2")
+	}
+	if w := serveVersioned(h, "/orders/7", map[string]string{"X-API-Version": "v2"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected headers to be ignored with path versioning, got %d", w.Code)
+	}
+}
+
+func TestVersion_Header(t *testing.T) {
+	h := newVersionedApp(VersioningConfig{Strategy: VersionByHeader, Default: "v2"}).handler()
+
+	tests := []struct {
+		path    string
+		version string
+		want    string
+	}{
+		{"/orders/7", "v1", "v1 7"},
+		{"/orders/7", "1", "v1 7"},
+		{"/orders/7", "", "v2 7"},
+		{"/v1/orders/7", "", "v1 7"},
+		{"/health", "v1", "ok"},
+	}
+	for _, tt := range tests {
+		w := serveVersioned(h, tt.path, map[string]string{"X-API-Version": tt.version})
+		if w.Body.String() != tt.want {
+			t.Errorf("%s with %q: expected %q, got %q", tt.path, tt.version, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestVersion_Accept(t *testing.T) {
+	h := newVersionedApp(VersioningConfig{Strategy: VersionByAccept}).handler()
+
+	for accept, want := range map[string]string{
+		"application/vnd.acme.v1+json":          "v1 7",
+		"application/json; version=v2":          "v2 7",
+		"text/html, application/json;version=1": "v1 7",
+	} {
+		w := serveVersioned(h, "/orders/7", map[string]string{"Accept": accept})
+		if w.Body.String() != want {
+			t.Errorf("%s: expected %q, got %q", accept, want, w.Body.String())
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("expected Vary: Accept, got %q", w.Header().Get("Vary"))
+		}
+	}
+	if w := serveVersioned(h, "/orders/7", map[string]string{"Accept": "application/json"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a version or default, got %d", w.Code)
+	}
+}
@@ -0,0 +1,641 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocket message types, as RFC 6455 opcodes.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+
+	wsContinuation = 0
+	wsClose        = 8
+	wsPing         = 9
+	wsPong         = 10
+)
+
+// WebSocket close codes.
+const (
+	CloseNormal         = 1000
+	CloseGoingAway      = 1001
+	CloseProtocol       = 1002
+	CloseNoStatus       = 1005
+	CloseInvalidPayload = 1007
+	CloseTooBig         = 1009
+	closeInternalErr    = 1011
+)
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrMessageTooBig is returned by ReadMessage for messages larger than
+	// WebSocketConfig.MaxMessageSize.
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	// ErrWebSocketClosed is returned by writes once Close was called.
+	ErrWebSocketClosed = errors.New("websocket: connection closed")
+)
+
+// wsFailure is a protocol violation by the peer, answered with a close
+// frame carrying code.
+type wsFailure struct {
+	code int
+	err  error
+}
+
+func (f *wsFailure) Error() string { return f.err.Error() }
+func (f *wsFailure) Unwrap() error { return f.err }
+
+func protocolError(code int, msg string) error {
+	return &wsFailure{code: code, err: errors.New("websocket: " + msg)}
+}
+
+// CloseError is returned by ReadMessage once the peer closes the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with code %d %s", e.Code, e.Reason)
+}
+
+// WebSocketHandler serves one connection; it is closed when the handler
+// returns. ctx is the request context, carrying what the route middlewares
+// stored (e.g. tokens.ClaimsFromContext), and is cancelled when the
+// connection drops or the server shuts down.
+type WebSocketHandler func(ctx context.Context, conn *WSConn)
+
+// WebSocketConfig tunes the connections of a WebSocket route.
+type WebSocketConfig struct {
+	// PingInterval is how often the server pings; a peer that sends nothing,
+	// not even a pong, for PingInterval+PongTimeout is disconnected.
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	WriteTimeout time.Duration
+	// CloseTimeout bounds how long Close waits for the peer to answer the
+	// close frame before dropping the connection.
+	CloseTimeout   time.Duration
+	MaxMessageSize int64
+	// CheckOrigin accepts or rejects the handshake. By default the Origin
+	// header, when present, must match the request host.
+	CheckOrigin func(r *http.Request) bool
+}
+
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		PingInterval:   30 * time.Second,
+		PongTimeout:    30 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		CloseTimeout:   5 * time.Second,
+		MaxMessageSize: 1 << 20,
+		CheckOrigin:    sameOrigin,
+	}
+}
+
+// WebSocket serves handler on path with the default config. Route
+// middlewares run before the upgrade, so auth can reject the handshake;
+// browsers cannot set headers on WebSocket requests, so authenticate with
+// a cookie or a query parameter:
+//
+//	app.WebSocket("/ws", func(ctx context.Context, conn *web.WSConn) {
+//		userID, _ := tokens.UserIDFromContext(ctx)
+//		for {
+//			_, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			_ = conn.WriteMessage(web.TextMessage, msg)
+//		}
+//	}, tokens.CookieAuthMiddleware(svc, cm, tokens.DefaultCookieConfig()))
+func (app *GinApp) WebSocket(path string, handler WebSocketHandler, middlewares ...gin.HandlerFunc) {
+	app.WebSocketWithConfig(path, DefaultWebSocketConfig(), handler, middlewares...)
+}
+
+// WebSocketWithConfig is WebSocket with a custom config.
+func (app *GinApp) WebSocketWithConfig(path string, cfg WebSocketConfig, handler WebSocketHandler, middlewares ...gin.HandlerFunc) {
+	handlers := append(append([]gin.HandlerFunc{}, middlewares...), app.webSocketHandler(cfg, handler))
+	app.engine.GET(path, handlers...)
+}
+
+func (app *GinApp) webSocketHandler(cfg WebSocketConfig, handler WebSocketHandler) gin.HandlerFunc {
+	def := DefaultWebSocketConfig()
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = def.PingInterval
+	}
+	if cfg.PongTimeout <= 0 {
+		cfg.PongTimeout = def.PongTimeout
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = def.WriteTimeout
+	}
+	if cfg.CloseTimeout <= 0 {
+		cfg.CloseTimeout = def.CloseTimeout
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = def.MaxMessageSize
+	}
+	if cfg.CheckOrigin == nil {
+		cfg.CheckOrigin = def.CheckOrigin
+	}
+
+	return func(c *gin.Context) {
+		conn, err := upgrade(c, cfg)
+		if err != nil {
+			logs.Warn(c.Request.Context(), "[WebSocket] upgrade failed", "error", err, "path", c.FullPath())
+			return
+		}
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		conn.cancel = cancel
+		if !app.trackWebSocket(conn, true) {
+			_ = conn.Close(CloseGoingAway, "server shutting down")
+			cancel()
+			return
+		}
+		defer app.trackWebSocket(conn, false)
+
+		go conn.keepalive(ctx)
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Error(ctx, "[WebSocket] handler panic", "panic", fmt.Sprint(r), "path", c.FullPath())
+				_ = conn.Close(closeInternalErr, "")
+			}
+			_ = conn.Close(CloseNormal, "")
+		}()
+		handler(ctx, conn)
+	}
+}
+
+// trackWebSocket registers or forgets conn for Shutdown; registering fails
+// once the app is shutting down.
+func (app *GinApp) trackWebSocket(conn *WSConn, add bool) bool {
+	app.wsMu.Lock()
+	defer app.wsMu.Unlock()
+	if !add {
+		delete(app.wsConns, conn)
+		return true
+	}
+	if app.wsClosing {
+		return false
+	}
+	if app.wsConns == nil {
+		app.wsConns = map[*WSConn]struct{}{}
+	}
+	app.wsConns[conn] = struct{}{}
+	return true
+}
+
+// closeWebSockets sends a going-away close to every open connection, since
+// http.Server.Shutdown does not track hijacked connections, and returns once
+// every closing handshake completed or timed out.
+func (app *GinApp) closeWebSockets() {
+	app.wsMu.Lock()
+	app.wsClosing = true
+	conns := make([]*WSConn, 0, len(app.wsConns))
+	for conn := range app.wsConns {
+		conns = append(conns, conn)
+	}
+	app.wsMu.Unlock()
+
+	// Close concurrently: each Close waits for its peer's answer.
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = conn.Close(CloseGoingAway, "server shutting down")
+		}()
+	}
+	wg.Wait()
+}
+
+func upgrade(c *gin.Context, cfg WebSocketConfig) (*WSConn, error) {
+	r := c.Request
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		AbortWithProblem(c, http.StatusBadRequest, "websocket_required", "expected a WebSocket handshake")
+		return nil, errors.New("not a websocket handshake")
+	}
+	if !cfg.CheckOrigin(r) {
+		AbortWithProblem(c, http.StatusForbidden, "origin_not_allowed", "")
+		return nil, errors.New("origin not allowed")
+	}
+
+	netConn, rw, err := c.Writer.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " +
+		base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n"
+	_ = netConn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+	if _, err := netConn.Write([]byte(resp)); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	conn := newWSConn(netConn, rw.Reader, false, cfg)
+	conn.extendReadDeadline()
+	return conn, nil
+}
+
+// WSConn is an upgraded WebSocket connection. Writes may be called
+// concurrently; reads must come from one goroutine, and the handler must
+// keep reading for pings, pongs and close frames to be processed.
+type WSConn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool
+	cfg    WebSocketConfig
+	cancel context.CancelFunc
+
+	// readMu is held by ReadMessage, so Close knows whether it may read
+	// the peer's close frame itself or must wait for the reader to see it.
+	readMu     sync.Mutex
+	writeMu    sync.Mutex
+	closing    atomic.Bool
+	closeOnce  sync.Once
+	closed     chan struct{}
+	peerOnce   sync.Once
+	peerClosed chan struct{}
+}
+
+func newWSConn(conn net.Conn, br *bufio.Reader, client bool, cfg WebSocketConfig) *WSConn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &WSConn{
+		conn:       conn,
+		br:         br,
+		client:     client,
+		cfg:        cfg,
+		closed:     make(chan struct{}),
+		peerClosed: make(chan struct{}),
+	}
+}
+
+func (c *WSConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage returns the next text or binary message, answering pings and
+// closes along the way. After the peer closes it returns a *CloseError.
+// Text messages that are not valid UTF-8 and invalid close frames fail the
+// connection as RFC 6455 requires.
+func (c *WSConn) ReadMessage() (int, []byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	var (
+		msgType int
+		msg     []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+		c.extendReadDeadline()
+
+		switch op {
+		case wsPing:
+			if c.closing.Load() {
+				continue
+			}
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.peerOnce.Do(func() { close(c.peerClosed) })
+			ce, err := parseClose(payload)
+			if err != nil {
+				return 0, nil, c.fail(err)
+			}
+			// Echo the peer's code, as RFC 6455 suggests.
+			_ = c.close(ce.Code, "", true)
+			return 0, nil, ce
+		case TextMessage, BinaryMessage:
+			if msgType != 0 {
+				return 0, nil, c.fail(protocolError(CloseProtocol, "unexpected data frame"))
+			}
+			msgType = op
+		case wsContinuation:
+			if msgType == 0 {
+				return 0, nil, c.fail(protocolError(CloseProtocol, "unexpected continuation frame"))
+			}
+		default:
+			return 0, nil, c.fail(protocolError(CloseProtocol, fmt.Sprintf("unknown opcode %d", op)))
+		}
+
+		if int64(len(msg)+len(payload)) > c.cfg.MaxMessageSize {
+			return 0, nil, c.fail(&wsFailure{code: CloseTooBig, err: ErrMessageTooBig})
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if msgType == TextMessage && !utf8.Valid(msg) {
+				return 0, nil, c.fail(protocolError(CloseInvalidPayload, "invalid UTF-8 in text message"))
+			}
+			return msgType, msg, nil
+		}
+	}
+}
+
+// fail closes the connection after a read error: with a close frame for
+// protocol violations, without one when the connection is broken. It is
+// called with readMu held.
+func (c *WSConn) fail(err error) error {
+	var f *wsFailure
+	if errors.As(err, &f) {
+		_ = c.close(f.code, "", true)
+		return f.err
+	}
+	c.shutdown()
+	return err
+}
+
+// parseClose decodes the payload of a close frame.
+func parseClose(payload []byte) (*CloseError, error) {
+	switch {
+	case len(payload) == 0:
+		return &CloseError{Code: CloseNoStatus}, nil
+	case len(payload) == 1:
+		return nil, protocolError(CloseProtocol, "truncated close code")
+	}
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return nil, protocolError(CloseProtocol, fmt.Sprintf("invalid close code %d", code))
+	}
+	if !utf8.Valid(payload[2:]) {
+		return nil, protocolError(CloseInvalidPayload, "invalid UTF-8 in close reason")
+	}
+	return &CloseError{Code: code, Reason: string(payload[2:])}, nil
+}
+
+// validCloseCode reports whether code may be sent in a close frame: the
+// codes defined by RFC 6455 and the IANA registry, and the 3000-4999 range
+// left to libraries and applications. 1005 and 1006 are reserved for
+// reporting and never sent.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// ReadJSON reads the next message and decodes it into v.
+func (c *WSConn) ReadJSON(v any) error {
+	_, msg, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, v)
+}
+
+// WriteMessage sends a text or binary message.
+func (c *WSConn) WriteMessage(msgType int, data []byte) error {
+	if msgType != TextMessage && msgType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", msgType)
+	}
+	return c.writeFrame(msgType, data)
+}
+
+// WriteJSON sends v as a text message.
+func (c *WSConn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(TextMessage, data)
+}
+
+// Close sends a close frame with code and reason, waits up to
+// WebSocketConfig.CloseTimeout for the peer's close frame and closes the
+// connection. Only the first call has an effect.
+func (c *WSConn) Close(code int, reason string) error {
+	// Read the peer's answer here unless ReadMessage is running on another
+	// goroutine, which then sees it instead.
+	canRead := c.readMu.TryLock()
+	if canRead {
+		defer c.readMu.Unlock()
+	}
+	return c.close(code, reason, canRead)
+}
+
+func (c *WSConn) close(code int, reason string, canRead bool) error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.closing.Store(true)
+		var payload []byte
+		if code != CloseNoStatus {
+			payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+			payload = append(payload, reason...)
+		}
+		if c.writeFrame(wsClose, payload) == nil {
+			c.awaitPeerClose(canRead)
+		}
+		err = c.conn.Close()
+		close(c.closed)
+		if c.cancel != nil {
+			c.cancel()
+		}
+	})
+	return err
+}
+
+// awaitPeerClose completes the closing handshake, discarding data frames
+// the peer sent before seeing our close frame.
+func (c *WSConn) awaitPeerClose(canRead bool) {
+	select {
+	case <-c.peerClosed:
+		return
+	default:
+	}
+	if canRead {
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.cfg.CloseTimeout))
+		for {
+			_, op, _, err := c.readFrame()
+			if err != nil || op == wsClose {
+				return
+			}
+		}
+	}
+	timer := time.NewTimer(c.cfg.CloseTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.peerClosed:
+	case <-timer.C:
+	}
+}
+
+// shutdown closes the connection without a close frame, once it is broken.
+func (c *WSConn) shutdown() {
+	c.closeOnce.Do(func() {
+		_ = c.conn.Close()
+		close(c.closed)
+		if c.cancel != nil {
+			c.cancel()
+		}
+	})
+}
+
+func (c *WSConn) keepalive(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(wsPing, nil); err != nil {
+				c.shutdown()
+				return
+			}
+		}
+	}
+}
+
+func (c *WSConn) extendReadDeadline() {
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.cfg.PingInterval + c.cfg.PongTimeout))
+}
+
+func (c *WSConn) readFrame() (fin bool, op int, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	op = int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	if header[0]&0x70 != 0 || masked == c.client {
+		return false, 0, nil, protocolError(CloseProtocol, "protocol error")
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if op >= wsClose && (length > 125 || !fin) {
+		return false, 0, nil, protocolError(CloseProtocol, "invalid control frame")
+	}
+	if length < 0 || length > c.cfg.MaxMessageSize {
+		return false, 0, nil, &wsFailure{code: CloseTooBig, err: ErrMessageTooBig}
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+func (c *WSConn) writeFrame(op int, payload []byte) error {
+	// Nothing may follow our close frame.
+	if op != wsClose && c.closing.Load() {
+		return ErrWebSocketClosed
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	frame := []byte{0x80 | byte(op)}
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.cfg.WriteTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
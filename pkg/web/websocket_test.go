@@ -0,0 +1,441 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type wsUserKey struct{}
+
+func newWebSocketServer(t *testing.T) (*GinApp, *httptest.Server) {
+	t.Helper()
+	app := &GinApp{engine: gin.New()}
+	withUser := func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), wsUserKey{}, "user123"))
+	}
+	app.WebSocket("/ws", func(ctx context.Context, conn *WSConn) {
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(msg) == "whoami" {
+				msg = []byte(ctx.Value(wsUserKey{}).(string))
+			}
+			if err := conn.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+		}
+	}, withUser)
+	srv := httptest.NewServer(app.engine)
+	t.Cleanup(srv.Close)
+	return app, srv
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server) *WSConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: " + conn.RemoteAddr().String() +
+		"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+	return newWSConn(conn, br, true, DefaultWebSocketConfig())
+}
+
+func TestWebSocket(t *testing.T) {
+	_, srv := newWebSocketServer(t)
+	client := dialWebSocket(t, srv)
+
+	if err := client.writeFrame(wsPing, []byte("hb")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WriteMessage(TextMessage, []byte("whoami")); err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err := client.ReadMessage(); err != nil || string(msg) != "user123" {
+		t.Fatalf("expected the middleware context in the handler, got %q %v", msg, err)
+	}
+
+	big := strings.Repeat("x", 70000)
+	if err := client.WriteMessage(BinaryMessage, []byte(big)); err != nil {
+		t.Fatal(err)
+	}
+	if msgType, msg, err := client.ReadMessage(); err != nil || msgType != BinaryMessage || string(msg) != big {
+		t.Fatalf("unexpected echo of a large message: type %d, %d bytes, %v", msgType, len(msg), err)
+	}
+
+	if err := client.Close(CloseNormal, "bye"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebSocket_Shutdown(t *testing.T) {
+	app, srv := newWebSocketServer(t)
+	client := dialWebSocket(t, srv)
+	if err := client.WriteMessage(TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		app.closeWebSockets()
+		close(done)
+	}()
+
+	_, _, err := client.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseGoingAway {
+		t.Fatalf("expected a going-away close, got %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the server to finish closing once the client answered")
+	}
+}
+
+func TestWebSocket_RejectsPlainRequests(t *testing.T) {
+	_, srv := newWebSocketServer(t)
+	resp, err := http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+// sendFrame writes one masked client frame, allowing non-final frames and
+// payloads WriteMessage would refuse.
+func sendFrame(t *testing.T, c *WSConn, fin bool, op int, payload []byte) {
+	t.Helper()
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	sendRawFrame(t, c, b0, payload)
+}
+
+// sendRawFrame writes a masked client frame with the given first byte, so
+// reserved bits can be set too.
+func sendRawFrame(t *testing.T, c *WSConn, b0 byte, payload []byte) {
+	t.Helper()
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame returns the next frame the server sends.
+func readServerFrame(t *testing.T, c *WSConn) (int, []byte) {
+	t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	fin, op, payload, err := c.readFrame()
+	if err != nil {
+		t.Fatalf("expected a frame, got %v", err)
+	}
+	if !fin {
+		t.Fatalf("expected a final frame, got opcode %d", op)
+	}
+	return op, payload
+}
+
+// readServerClose returns the code of the next close frame the server sends.
+func readServerClose(t *testing.T, c *WSConn) int {
+	t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, op, payload, err := c.readFrame()
+		if err != nil {
+			t.Fatalf("expected a close frame, got %v", err)
+		}
+		if op != wsClose {
+			continue
+		}
+		if len(payload) < 2 {
+			return CloseNoStatus
+		}
+		return int(binary.BigEndian.Uint16(payload))
+	}
+}
+
+func closePayload(code int, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+// Cases modeled on the Autobahn test suite: reserved bits and opcodes,
+// fragmentation errors, invalid UTF-8, close frame validation and control
+// frame limits.
+func TestWebSocket_ProtocolViolations(t *testing.T) {
+	invalidUTF8 := []byte("\xce\xba\xe1\xbd\xb9\xcf\x83\xce\xbc\xce\xb5\xed\xa0\x80\x65\x64\x69\x74\x65\x64")
+	tests := []struct {
+		name   string
+		frames func(t *testing.T, c *WSConn)
+		code   int
+	}{
+		{"invalid UTF-8 text", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, TextMessage, invalidUTF8)
+		}, CloseInvalidPayload},
+		{"invalid UTF-8 across fragments", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, false, TextMessage, invalidUTF8[:5])
+			sendFrame(t, c, true, wsContinuation, invalidUTF8[5:])
+		}, CloseInvalidPayload},
+		{"one-byte close payload", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, []byte{0x03})
+		}, CloseProtocol},
+		{"reserved close code 1005", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(CloseNoStatus, ""))
+		}, CloseProtocol},
+		{"unassigned close code 2999", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(2999, ""))
+		}, CloseProtocol},
+		{"close code below 1000", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(999, ""))
+		}, CloseProtocol},
+		{"invalid UTF-8 close reason", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(CloseNormal, string(invalidUTF8)))
+		}, CloseInvalidPayload},
+		{"oversized ping", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsPing, make([]byte, 126))
+		}, CloseProtocol},
+		{"fragmented ping", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, false, wsPing, []byte("hb"))
+		}, CloseProtocol},
+		{"RSV1 set", func(t *testing.T, c *WSConn) {
+			sendRawFrame(t, c, 0x80|0x40|TextMessage, []byte("hi"))
+		}, CloseProtocol},
+		{"RSV3 set on a ping", func(t *testing.T, c *WSConn) {
+			sendRawFrame(t, c, 0x80|0x10|wsPing, nil)
+		}, CloseProtocol},
+		{"reserved data opcode 3", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, 3, nil)
+		}, CloseProtocol},
+		{"reserved control opcode 11", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, 11, []byte("hi"))
+		}, CloseProtocol},
+		{"continuation without a message", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsContinuation, []byte("hi"))
+		}, CloseProtocol},
+		{"text frame inside a fragmented message", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, false, TextMessage, []byte("fragment1"))
+			sendFrame(t, c, true, TextMessage, []byte("fragment2"))
+		}, CloseProtocol},
+		{"fragmented close", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, false, wsClose, closePayload(CloseNormal, ""))
+		}, CloseProtocol},
+		{"unmasked frame", func(t *testing.T, c *WSConn) {
+			_, _ = c.conn.Write([]byte{0x80 | TextMessage, 2, 'h', 'i'})
+		}, CloseProtocol},
+		{"overlong UTF-8 encoding", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, TextMessage, []byte("\xc0\xaf"))
+		}, CloseInvalidPayload},
+		{"code point above U+10FFFF", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, TextMessage, []byte("\xf4\x90\x80\x80"))
+		}, CloseInvalidPayload},
+		{"truncated UTF-8 sequence", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, TextMessage, []byte("\xce\xba\xe1\xbd"))
+		}, CloseInvalidPayload},
+		{"close code 1004", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(1004, ""))
+		}, CloseProtocol},
+		{"close code 1006", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(1006, ""))
+		}, CloseProtocol},
+		{"close code 1015", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(1015, ""))
+		}, CloseProtocol},
+		{"close code 5000", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(5000, ""))
+		}, CloseProtocol},
+		{"message over MaxMessageSize", func(t *testing.T, c *WSConn) {
+			half := make([]byte, DefaultWebSocketConfig().MaxMessageSize/2+1)
+			sendFrame(t, c, false, BinaryMessage, half)
+			sendFrame(t, c, true, wsContinuation, half)
+		}, CloseTooBig},
+		{"application close code", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, closePayload(4000, "done"))
+		}, 4000},
+		{"empty close", func(t *testing.T, c *WSConn) {
+			sendFrame(t, c, true, wsClose, nil)
+		}, CloseNoStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newWebSocketServer(t)
+			client := dialWebSocket(t, srv)
+			tt.frames(t, client)
+			if code := readServerClose(t, client); code != tt.code {
+				t.Errorf("expected close code %d, got %d", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestWebSocket_FragmentedUTF8(t *testing.T) {
+	_, srv := newWebSocketServer(t)
+	client := dialWebSocket(t, srv)
+
+	// κόσμε split inside a multi-byte sequence.
+	msg := []byte("\xce\xba\xe1\xbd\xb9\xcf\x83\xce\xbc\xce\xb5")
+	sendFrame(t, client, false, TextMessage, msg[:1])
+	sendFrame(t, client, false, wsContinuation, msg[1:4])
+	sendFrame(t, client, true, wsContinuation, msg[4:])
+	if msgType, got, err := client.ReadMessage(); err != nil || msgType != TextMessage || string(got) != string(msg) {
+		t.Fatalf("expected the fragmented text echoed, got type %d %q %v", msgType, got, err)
+	}
+}
+
+func TestWebSocket_CloseWaitsForPeer(t *testing.T) {
+	app := &GinApp{engine: gin.New()}
+	app.WebSocket("/ws", func(ctx context.Context, conn *WSConn) {})
+	srv := httptest.NewServer(app.engine)
+	t.Cleanup(srv.Close)
+	client := dialWebSocket(t, srv)
+
+	if code := readServerClose(t, client); code != CloseNormal {
+		t.Fatalf("expected a normal close, got %d", code)
+	}
+	// The server keeps the connection open until the close is answered.
+	_ = client.conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	var buf [1]byte
+	if _, err := client.conn.Read(buf[:]); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected the connection to stay open, got %v", err)
+	}
+
+	sendFrame(t, client, true, wsClose, closePayload(CloseNormal, ""))
+	_ = client.conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := client.conn.Read(buf[:]); err != io.EOF {
+		t.Fatalf("expected the server to close the connection, got %v", err)
+	}
+}
+
+// Autobahn section 1: payload lengths around the 7, 16 and 64-bit length
+// encodings are echoed intact.
+func TestWebSocket_Framing(t *testing.T) {
+	_, srv := newWebSocketServer(t)
+	client := dialWebSocket(t, srv)
+
+	for _, n := range []int{0, 125, 126, 127, 128, 0xffff, 0x10000, 0x10001} {
+		for _, op := range []int{TextMessage, BinaryMessage} {
+			payload := []byte(strings.Repeat("*", n))
+			sendFrame(t, client, true, op, payload)
+			gotOp, got := readServerFrame(t, client)
+			if gotOp != op || string(got) != string(payload) {
+				t.Fatalf("%d-byte message of type %d: echoed type %d with %d bytes", n, op, gotOp, len(got))
+			}
+		}
+	}
+}
+
+// Autobahn sections 2 and 5: pings are answered with their payload, also
+// between the fragments of a message, and unsolicited pongs are ignored.
+func TestWebSocket_PingPong(t *testing.T) {
+	_, srv := newWebSocketServer(t)
+	client := dialWebSocket(t, srv)
+
+	for _, payload := range [][]byte{nil, []byte("Hello, world!"), make([]byte, 125), {0x00, 0xff, 0xfe, 0xfd}} {
+		sendFrame(t, client, true, wsPing, payload)
+		if op, got := readServerFrame(t, client); op != wsPong || string(got) != string(payload) {
+			t.Fatalf("expected a pong echoing %q, got opcode %d %q", payload, op, got)
+		}
+	}
+
+	sendFrame(t, client, true, wsPong, []byte("unsolicited"))
+	sendFrame(t, client, false, TextMessage, []byte("frag"))
+	sendFrame(t, client, true, wsPing, []byte("mid"))
+	sendFrame(t, client, true, wsContinuation, []byte("ment"))
+	if op, got := readServerFrame(t, client); op != wsPong || string(got) != "mid" {
+		t.Fatalf("expected the ping inside the message answered first, got opcode %d %q", op, got)
+	}
+	if op, got := readServerFrame(t, client); op != TextMessage || string(got) != "fragment" {
+		t.Fatalf("expected the fragmented message echoed, got opcode %d %q", op, got)
+	}
+}
+
+// Autobahn section 6: valid UTF-8 is accepted, including the boundaries of
+// each sequence length and noncharacters.
+func TestWebSocket_ValidUTF8(t *testing.T) {
+	_, srv := newWebSocketServer(t)
+	client := dialWebSocket(t, srv)
+
+	for _, text := range []string{
+		"",
+		"\x7f",
+		"\xc2\x80",
+		"\xdf\xbf",
+		"\xe0\xa0\x80",
+		"\xed\x9f\xbf",
+		"\xee\x80\x80",
+		"\xef\xbf\xbf",
+		"\xf0\x90\x80\x80",
+		"\xf4\x8f\xbf\xbf",
+		"Hello-\xc2\xb5@\xc3\x9f\xc3\xb6\xc3\xa4\xc3\xbc\xc3\xa0\xc3\xa1-UTF-8!!",
+	} {
+		sendFrame(t, client, true, TextMessage, []byte(text))
+		if op, got := readServerFrame(t, client); op != TextMessage || string(got) != text {
+			t.Fatalf("expected %q echoed, got opcode %d %q", text, op, got)
+		}
+	}
+}
+
+// Autobahn section 7: valid close codes are echoed, and data sent after
+// the close frame is ignored.
+func TestWebSocket_ValidCloseCodes(t *testing.T) {
+	for _, code := range []int{1000, 1001, 1002, 1003, 1007, 1008, 1009, 1010, 1011, 1012, 1013, 1014, 3000, 3999, 4000, 4999} {
+		t.Run(strconv.Itoa(code), func(t *testing.T) {
+			_, srv := newWebSocketServer(t)
+			client := dialWebSocket(t, srv)
+			sendFrame(t, client, true, wsClose, closePayload(code, strings.Repeat("r", 123)))
+			sendFrame(t, client, true, TextMessage, []byte("after close"))
+			if got := readServerClose(t, client); got != code {
+				t.Errorf("expected close code %d echoed, got %d", code, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+)
+
+// Go runs fn in a background goroutine supervised by the app: panics are
+// recovered and logged, errors other than the cancellation are logged, and
+// Shutdown cancels ctx and waits for fn to return, within the
+// ShutdownTimeout. Workers stop after the servers have drained and before
+// the OnShutdown hooks run, so they can still use the resources those
+// release. Calls made once Shutdown has started are ignored.
+//
+//	app.Go("outbox-relay", func(ctx context.Context) error {
+//		return relay.Run(ctx)
+//	})
+func (app *GinApp) Go(name string, fn func(ctx context.Context) error) {
+	app.workersMu.Lock()
+	defer app.workersMu.Unlock()
+	if app.workersStopped {
+		logs.Warn(context.Background(), "[Workers] app is shutting down, worker not started", "worker", name)
+		return
+	}
+	if app.workersCtx == nil {
+		app.workersCtx, app.workersCancel = context.WithCancel(context.Background())
+		app.workersRunning = map[string]int{}
+	}
+	app.workersRunning[name]++
+	app.workersWG.Add(1)
+
+	ctx := app.workersCtx
+	go func() {
+		defer app.workersWG.Done()
+		defer app.workerDone(name)
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Error(ctx, "[Workers] worker panic", "worker", name, "panic", fmt.Sprint(r), logs.WithNotifier())
+			}
+		}()
+		if err := fn(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logs.Error(ctx, "[Workers] worker failed", "worker", name, "error", err)
+		}
+	}()
+}
+
+func (app *GinApp) workerDone(name string) {
+	app.workersMu.Lock()
+	defer app.workersMu.Unlock()
+	if app.workersRunning[name]--; app.workersRunning[name] == 0 {
+		delete(app.workersRunning, name)
+	}
+}
+
+// stopWorkers cancels the workers and waits for them until ctx is done.
+func (app *GinApp) stopWorkers(ctx context.Context) error {
+	app.workersMu.Lock()
+	app.workersStopped = true
+	cancel := app.workersCancel
+	app.workersMu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		app.workersWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		app.workersMu.Lock()
+		defer app.workersMu.Unlock()
+		names := make([]string, 0, len(app.workersRunning))
+		for name := range app.workersRunning {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("workers still running: %s", strings.Join(names, ", "))
+	}
+}
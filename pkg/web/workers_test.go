@@ -0,0 +1,69 @@
+package web
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsandov/go-sdk/pkg/logs"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGo(t *testing.T) {
+	app := &GinApp{engine: gin.New(), logger: logs.GetLogger()}
+
+	var stopped atomic.Bool
+	started := make(chan struct{})
+	app.Go("ticker", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		stopped.Store(true)
+		return ctx.Err()
+	})
+	app.Go("panics", func(ctx context.Context) error {
+		panic("boom")
+	})
+	<-started
+
+	var hookSawWorkers atomic.Bool
+	app.OnShutdown(func(ctx context.Context) error {
+		hookSawWorkers.Store(stopped.Load())
+		return nil
+	})
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !stopped.Load() || !hookSawWorkers.Load() {
+		t.Error("expected workers to stop before the shutdown hooks")
+	}
+
+	ran := make(chan struct{}, 1)
+	app.Go("late", func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	})
+	select {
+	case <-ran:
+		t.Error("expected workers started during shutdown to be ignored")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGo_ShutdownTimeout(t *testing.T) {
+	app := &GinApp{engine: gin.New(), logger: logs.GetLogger()}
+	release := make(chan struct{})
+	defer close(release)
+	app.Go("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := app.Shutdown(ctx); err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Errorf("expected the stuck worker to be reported, got %v", err)
+	}
+}